@@ -3,6 +3,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
@@ -16,6 +18,12 @@ var renderFunc js.Func
 var stopFunc js.Func
 var stepFunc js.Func
 var detailFunc js.Func
+var breedFunc js.Func
+var undoFunc js.Func
+var redoFunc js.Func
+var panFunc js.Func
+var zoomFunc js.Func
+var resetViewFunc js.Func
 var evo evolutionState
 
 func main() {
@@ -34,7 +42,8 @@ type fitnessConfig struct {
 }
 
 type evolutionState struct {
-	running        bool
+	ctx            context.Context
+	cancel         context.CancelFunc
 	seed           int64
 	current        int
 	total          int
@@ -47,14 +56,25 @@ type evolutionState struct {
 	noveltyArchive []featureVec
 	runner         neat.Runner
 	spec           cppn.InputSpec
+	renderCfg      cppn.RenderConfig
 	fitnessSize    int
 	fitnessCfg     fitnessConfig
+	history        *neat.SelectionHistory
+	detailIndex    int
+	detailSize     int
+	detailView     cppn.Viewport
 }
 
 func registerCallbacks() {
 	stepFunc = js.FuncOf(step)
 	stopFunc = js.FuncOf(stopEvolution)
 	detailFunc = js.FuncOf(renderDetail)
+	breedFunc = js.FuncOf(breedSelected)
+	undoFunc = js.FuncOf(undoGeneration)
+	redoFunc = js.FuncOf(redoGeneration)
+	panFunc = js.FuncOf(panDetail)
+	zoomFunc = js.FuncOf(zoomDetail)
+	resetViewFunc = js.FuncOf(resetDetailView)
 	renderFunc = js.FuncOf(func(this js.Value, args []js.Value) any {
 		seed := int64(0)
 		if len(args) > 0 {
@@ -103,6 +123,15 @@ func registerCallbacks() {
 		if len(args) > 11 {
 			noveltyWeight = args[11].Float()
 		}
+		model := cppn.ColorRGB
+		if len(args) > 12 {
+			m, err := cppn.ParseColorModel(args[12].String())
+			if err != nil {
+				setStatus(fmt.Sprintf("render failed: %v", err))
+				return nil
+			}
+			model = m
+		}
 
 		setStatus(fmt.Sprintf("generating 0/%d", generations))
 		cfg := fitnessConfig{
@@ -112,7 +141,7 @@ func registerCallbacks() {
 			noveltyThreshold:  0.35,
 			noveltyArchiveMax: 80,
 		}
-		if err := startEvolution(seed, tileSize, popSize, generations, color, cfg); err != nil {
+		if err := startEvolution(seed, tileSize, popSize, generations, color, model, cfg); err != nil {
 			setStatus(fmt.Sprintf("render failed: %v", err))
 			setRunning(false)
 			return nil
@@ -123,13 +152,19 @@ func registerCallbacks() {
 	js.Global().Set("renderGallery", renderFunc)
 	js.Global().Set("stopEvolution", stopFunc)
 	js.Global().Set("renderDetail", detailFunc)
+	js.Global().Set("breedSelected", breedFunc)
+	js.Global().Set("undoGeneration", undoFunc)
+	js.Global().Set("redoGeneration", redoFunc)
+	js.Global().Set("panDetail", panFunc)
+	js.Global().Set("zoomDetail", zoomFunc)
+	js.Global().Set("resetDetailView", resetViewFunc)
 }
 
 func stopEvolution(this js.Value, args []js.Value) any {
-	if !evo.running {
+	if !evo.active() {
 		return nil
 	}
-	evo.running = false
+	evo.cancel()
 	setRunning(false)
 	setStatus(fmt.Sprintf("cancelled %d/%d", evo.current, evo.total))
 	return nil
@@ -147,15 +182,65 @@ func renderDetail(this js.Value, args []js.Value) any {
 	if size <= 0 {
 		size = evo.tileSize * 2
 	}
+	evo.detailIndex = idx
+	evo.detailSize = size
+	evo.detailView = cppn.Viewport{}
+	showDetail()
+	return nil
+}
+
+// panDetail moves the detail view by a drag of (args[0], args[1]) canvas
+// pixels.
+func panDetail(this js.Value, args []js.Value) any {
+	if len(args) < 2 || evo.detailSize == 0 {
+		return nil
+	}
+	size := evo.detailSize
+	evo.detailView = evo.detailView.Pan(args[0].Float(), args[1].Float(), size, size)
+	showDetail()
+	return nil
+}
+
+// zoomDetail magnifies the detail view by args[0] about canvas pixel
+// (args[1], args[2]).
+func zoomDetail(this js.Value, args []js.Value) any {
+	if len(args) < 3 || evo.detailSize == 0 {
+		return nil
+	}
+	size := evo.detailSize
+	evo.detailView = evo.detailView.Zoom(args[0].Float(), args[1].Float(), args[2].Float(), size, size)
+	showDetail()
+	return nil
+}
+
+func resetDetailView(this js.Value, args []js.Value) any {
+	if evo.detailSize == 0 {
+		return nil
+	}
+	evo.detailView = cppn.Viewport{}
+	showDetail()
+	return nil
+}
 
+// showDetail renders the selected genome through the detail viewport.
+func showDetail() {
+	idx, size := evo.detailIndex, evo.detailSize
+	if idx < 0 || idx >= len(evo.ordered) {
+		return
+	}
 	g := evo.ordered[idx]
 	plan, err := neat.BuildAcyclicPlan(g, nil, nil)
 	if err != nil {
-		return nil
+		return
 	}
-	pixels, err := cppn.RenderGrayscale(plan, size, size, evo.spec)
+	renderCfg, err := cppn.RenderConfigForGenome(g, displayConfig(evo.renderCfg))
 	if err != nil {
-		return nil
+		return
+	}
+	renderCfg.Viewport = evo.detailView
+	pixels, err := cppn.Render(plan, size, size, evo.spec, renderCfg)
+	if err != nil {
+		return
 	}
 
 	hidden := countKind(g.Nodes, neat.NodeHidden)
@@ -165,22 +250,108 @@ func renderDetail(this js.Value, args []js.Value) any {
 	var novelty float64
 	if idx < len(evo.orderedMetrics) {
 		metrics = evo.orderedMetrics[idx]
+		// Fitness skips the optional metrics its weights ignore; measure
+		// them all for the one genome on display.
+		if full, err := fitnessMetrics(plan, g); err == nil {
+			metrics = full
+		}
 	}
 	if idx < len(evo.orderedNovelty) {
 		novelty = evo.orderedNovelty[idx]
 	}
 
 	summary := g.String() + formatMetrics(metrics, novelty)
-	updateDetail(size, size, pixels, g.Fitness, len(g.Nodes), len(g.Connections), hidden, outputs, summary)
+	network, err := g.SVG(neat.DefaultSVGConfig())
+	if err != nil {
+		network = ""
+	}
+	updateDetail(size, size, pixels, g.Fitness, len(g.Nodes), len(g.Connections), hidden, outputs, summary, network)
+}
+
+// breedSelected breeds the next generation from the tiles at the given
+// gallery indices (args[0], an array) with optional per-tile weights
+// (args[1]). The first call after an automatic run takes over the gallery as
+// shown, so undoing all the way returns to it.
+func breedSelected(this js.Value, args []js.Value) any {
+	if evo.active() {
+		setStatus("stop the run before breeding")
+		return nil
+	}
+	if len(evo.ordered) == 0 || len(args) == 0 {
+		setStatus("generate a gallery first")
+		return nil
+	}
+	selected := make([]int, args[0].Length())
+	for i := range selected {
+		selected[i] = args[0].Index(i).Int()
+	}
+	var weights []float64
+	if len(args) > 1 && args[1].Truthy() {
+		weights = make([]float64, args[1].Length())
+		for i := range weights {
+			weights[i] = args[1].Index(i).Float()
+		}
+	}
+	if evo.history == nil {
+		pop := evo.runner.Population
+		pop.Genomes = append([]neat.Genome(nil), evo.ordered...)
+		pop.Species = nil
+		history, err := neat.NewSelectionHistory(pop, evo.runner.Mutation, evo.runner.Reproduction)
+		if err != nil {
+			setStatus(fmt.Sprintf("breed failed: %v", err))
+			return nil
+		}
+		evo.history = history
+	}
+	if err := evo.history.Breed(selected, weights); err != nil {
+		setStatus(fmt.Sprintf("breed failed: %v", err))
+		return nil
+	}
+	if err := mutatePalettes(evo.history.Population); err != nil {
+		setStatus(fmt.Sprintf("breed failed: %v", err))
+		return nil
+	}
+	showInteractive()
+	return nil
+}
+
+func undoGeneration(this js.Value, args []js.Value) any {
+	if evo.active() || evo.history == nil || !evo.history.Undo() {
+		return nil
+	}
+	showInteractive()
+	return nil
+}
+
+func redoGeneration(this js.Value, args []js.Value) any {
+	if evo.active() || evo.history == nil || !evo.history.Redo() {
+		return nil
+	}
+	showInteractive()
 	return nil
 }
 
-func startEvolution(seed int64, tileSize, popSize, generations int, color bool, cfg fitnessConfig) error {
+// showInteractive renders the interactive population in population order.
+// Bred genomes have no fitness or metrics until the next automatic run.
+func showInteractive() {
+	pop := evo.history.Population
+	evo.ordered = append([]neat.Genome(nil), pop.Genomes...)
+	evo.orderedMetrics = nil
+	evo.orderedNovelty = nil
+	if err := renderPopulation(evo.ordered, evo.spec, displayConfig(evo.renderCfg), evo.tileSize, evo.popSize); err != nil {
+		setStatus(fmt.Sprintf("render failed: %v", err))
+		return
+	}
+	setHistory(evo.history.CanUndo(), evo.history.CanRedo())
+	setStatus(fmt.Sprintf("generation %d (selected)", pop.Generation))
+}
+
+func startEvolution(seed int64, tileSize, popSize, generations int, color bool, model cppn.ColorModel, cfg fitnessConfig) error {
 	if popSize < 1 || generations < 1 {
 		return fmt.Errorf("invalid parameters")
 	}
-	if evo.running {
-		evo.running = false
+	if evo.cancel != nil {
+		evo.cancel()
 	}
 
 	rng := neat.NewRand(seed)
@@ -190,8 +361,12 @@ func startEvolution(seed int64, tileSize, popSize, generations int, color bool,
 	}
 
 	spec := cppn.DefaultInputSpec()
+	renderCfg := cppn.RenderConfig{ColorModel: model}
 	outputCount := 1
-	if color {
+	if model == cppn.ColorPalette {
+		// One output indexes each genome's own evolving gradient.
+		color = true
+	} else if color {
 		outputCount = 3
 	}
 	genomes := make([]neat.Genome, 0, popSize)
@@ -200,6 +375,18 @@ func startEvolution(seed int64, tileSize, popSize, generations int, color bool,
 		if err != nil {
 			return err
 		}
+		if err := spec.Label(&g); err != nil {
+			return err
+		}
+		cfg := renderCfg
+		if model == cppn.ColorPalette {
+			if cfg.Palette, err = cppn.RandomGradient(rng, 4); err != nil {
+				return err
+			}
+		}
+		if err := cfg.Label(&g); err != nil {
+			return err
+		}
 		genomes = append(genomes, g)
 	}
 
@@ -233,14 +420,16 @@ func startEvolution(seed int64, tileSize, popSize, generations int, color bool,
 	}
 
 	runner := neat.Runner{
-		Population:  pop,
-		Mutation:    mcfg,
+		Population:   pop,
+		Mutation:     mcfg,
 		Reproduction: rcfg,
 		Fitness:      nil,
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
 	evo = evolutionState{
-		running:        true,
+		ctx:            ctx,
+		cancel:         cancel,
 		seed:           seed,
 		current:        0,
 		total:          generations,
@@ -253,33 +442,44 @@ func startEvolution(seed int64, tileSize, popSize, generations int, color bool,
 		noveltyArchive: nil,
 		runner:         runner,
 		spec:           spec,
+		renderCfg:      renderCfg,
 		fitnessSize:    fitnessSize,
 		fitnessCfg:     cfg,
 	}
 	prepareGallery(popSize, tileSize)
+	setHistory(false, false)
 	setRunning(true)
 	scheduleStep()
 	return nil
 }
 
+// active reports whether a run is in progress. Finished, failed and stopped
+// runs all cancel their context.
+func (e *evolutionState) active() bool {
+	return e.ctx != nil && e.ctx.Err() == nil
+}
+
 func scheduleStep() {
 	js.Global().Call("setTimeout", stepFunc, 0)
 }
 
 func step(this js.Value, args []js.Value) any {
-	if !evo.running {
+	if !evo.active() {
 		return nil
 	}
 	if evo.current >= evo.total {
-		evo.running = false
+		evo.cancel()
 		setRunning(false)
 		setStatus(fmt.Sprintf("done %d/%d", evo.total, evo.total))
 		return nil
 	}
 
-	metrics, novelty, err := evaluatePopulation(evo.runner.Population, evo.spec, evo.fitnessSize, evo.fitnessCfg, evo.color, &evo.noveltyArchive)
+	metrics, novelty, err := evaluatePopulation(evo.ctx, evo.runner.Population, evo.spec, evo.renderCfg, evo.fitnessSize, evo.fitnessCfg, evo.color, &evo.noveltyArchive)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
 	if err != nil {
-		evo.running = false
+		evo.cancel()
 		setRunning(false)
 		setStatus(fmt.Sprintf("render failed: %v", err))
 		return nil
@@ -299,8 +499,8 @@ func step(this js.Value, args []js.Value) any {
 	evo.orderedMetrics = orderedMetrics
 	evo.orderedNovelty = orderedNovelty
 
-	if err := renderPopulation(ordered, evo.spec, evo.tileSize, evo.popSize); err != nil {
-		evo.running = false
+	if err := renderPopulation(ordered, evo.spec, displayConfig(evo.renderCfg), evo.tileSize, evo.popSize); err != nil {
+		evo.cancel()
 		setRunning(false)
 		setStatus(fmt.Sprintf("render failed: %v", err))
 		return nil
@@ -309,7 +509,13 @@ func step(this js.Value, args []js.Value) any {
 	setStatus(fmt.Sprintf("generating %d/%d", evo.current+1, evo.total))
 	if evo.current < evo.total-1 {
 		if err := evo.runner.Population.NextGeneration(evo.runner.Mutation, evo.runner.Reproduction); err != nil {
-			evo.running = false
+			evo.cancel()
+			setRunning(false)
+			setStatus(fmt.Sprintf("render failed: %v", err))
+			return nil
+		}
+		if err := mutatePalettes(evo.runner.Population); err != nil {
+			evo.cancel()
 			setRunning(false)
 			setStatus(fmt.Sprintf("render failed: %v", err))
 			return nil
@@ -322,31 +528,46 @@ func step(this js.Value, args []js.Value) any {
 		return nil
 	}
 
-	evo.running = false
+	evo.cancel()
 	setRunning(false)
 	setStatus(fmt.Sprintf("done %d/%d", evo.total, evo.total))
 	return nil
 }
 
-func evaluatePopulation(pop *neat.Population, spec cppn.InputSpec, size int, cfg fitnessConfig, color bool, archive *[]featureVec) ([]cppn.Metrics, []float64, error) {
+func evaluatePopulation(ctx context.Context, pop *neat.Population, spec cppn.InputSpec, renderCfg cppn.RenderConfig, size int, cfg fitnessConfig, color bool, archive *[]featureVec) ([]cppn.Metrics, []float64, error) {
 	if pop == nil {
 		return nil, nil, fmt.Errorf("population is nil")
 	}
 	metrics := make([]cppn.Metrics, len(pop.Genomes))
 	features := make([]featureVec, len(pop.Genomes))
 	baseScores := make([]float64, len(pop.Genomes))
+	needs := cfg.weights.Needs()
+	if cfg.noveltyWeight > 0 {
+		// Complexity is part of the novelty descriptor.
+		needs |= cppn.MetricComplexity
+	}
 
 	for i := range pop.Genomes {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
 		plan, err := neat.BuildAcyclicPlan(pop.Genomes[i], nil, nil)
 		if err != nil {
 			pop.Genomes[i].Fitness = 0
 			continue
 		}
-		pixels, err := cppn.RenderGrayscale(plan, size, size, spec)
+		genomeCfg, err := cppn.RenderConfigForGenome(pop.Genomes[i], renderCfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		pixels, err := cppn.Render(plan, size, size, spec, genomeCfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		metrics[i], err = cppn.ComputeMetricsWith(pixels, size, size, needs)
 		if err != nil {
 			return nil, nil, err
 		}
-		metrics[i] = cppn.ComputeMetrics(pixels, size, size)
 		features[i] = featureFromMetrics(metrics[i])
 		baseScores[i] = cppn.ScoreFromMetrics(metrics[i], cfg.weights, color)
 		pop.Genomes[i].Fitness = baseScores[i]
@@ -364,6 +585,20 @@ func evaluatePopulation(pop *neat.Population, spec cppn.InputSpec, size int, cfg
 	return metrics, noveltyScores, nil
 }
 
+// fitnessMetrics renders g as evaluatePopulation does and computes every
+// metric, including the optional ones.
+func fitnessMetrics(plan *neat.Plan, g neat.Genome) (cppn.Metrics, error) {
+	genomeCfg, err := cppn.RenderConfigForGenome(g, evo.renderCfg)
+	if err != nil {
+		return cppn.Metrics{}, err
+	}
+	pixels, err := cppn.Render(plan, evo.fitnessSize, evo.fitnessSize, evo.spec, genomeCfg)
+	if err != nil {
+		return cppn.Metrics{}, err
+	}
+	return cppn.ComputeMetricsWith(pixels, evo.fitnessSize, evo.fitnessSize, cppn.AllMetrics)
+}
+
 func computeNovelty(features []featureVec, archive *[]featureVec, k int) []float64 {
 	if k <= 0 {
 		k = 5
@@ -431,6 +666,7 @@ func featureFromMetrics(m cppn.Metrics) featureVec {
 		clamp01((m.SymmetryX + m.SymmetryY) * 0.5),
 		clamp01(m.HighFreq / 1.0),
 		clamp01(m.ColorVar / 0.25),
+		m.Complexity,
 	}
 }
 
@@ -450,14 +686,18 @@ func featureDistance(a, b featureVec) float64 {
 	return math.Sqrt(sum)
 }
 
-func renderPopulation(ordered []neat.Genome, spec cppn.InputSpec, tileSize, popSize int) error {
+func renderPopulation(ordered []neat.Genome, spec cppn.InputSpec, renderCfg cppn.RenderConfig, tileSize, popSize int) error {
 	for i := 0; i < popSize && i < len(ordered); i++ {
 		g := ordered[i]
 		plan, err := neat.BuildAcyclicPlan(g, nil, nil)
 		if err != nil {
 			continue
 		}
-		pixels, err := cppn.RenderGrayscale(plan, tileSize, tileSize, spec)
+		genomeCfg, err := cppn.RenderConfigForGenome(g, renderCfg)
+		if err != nil {
+			return err
+		}
+		pixels, err := cppn.Render(plan, tileSize, tileSize, spec, genomeCfg)
 		if err != nil {
 			return err
 		}
@@ -466,6 +706,34 @@ func renderPopulation(ordered []neat.Genome, spec cppn.InputSpec, tileSize, popS
 	return nil
 }
 
+// displayConfig supersamples gallery and detail renders so high-frequency
+// patterns do not alias, and renders them in float32. Fitness renders keep
+// one float64 sample per pixel, so scores do not depend on display settings.
+func displayConfig(cfg cppn.RenderConfig) cppn.RenderConfig {
+	cfg.Precision = cppn.PrecisionFloat32
+	cfg.Samples = 2
+	cfg.Pattern = cppn.SampleRotatedGrid
+	return cfg
+}
+
+// mutatePalettes perturbs the gradient of about half the genomes born this
+// generation. Survivors keep theirs, so palettes evolve alongside networks.
+func mutatePalettes(pop *neat.Population) error {
+	if evo.renderCfg.ColorModel != cppn.ColorPalette {
+		return nil
+	}
+	for i := range pop.Genomes {
+		g := &pop.Genomes[i]
+		if g.Birth != pop.Generation || pop.RNG.Float64() >= 0.5 {
+			continue
+		}
+		if err := cppn.MutatePalette(g, pop.RNG, 0.15); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func countKind(nodes []neat.NodeGene, kind neat.NodeKind) int {
 	count := 0
 	for _, n := range nodes {
@@ -477,7 +745,7 @@ func countKind(nodes []neat.NodeGene, kind neat.NodeKind) int {
 }
 
 func formatMetrics(m cppn.Metrics, novelty float64) string {
-	return fmt.Sprintf("\nMetrics\n  entropy=%.3f\n  variance=%.4f\n  edgeDensity=%.3f\n  fineEdges=%.3f\n  symmetryX=%.3f\n  symmetryY=%.3f\n  highFreq=%.3f\n  colorVar=%.4f\n  novelty=%.3f\n", m.Entropy, m.Variance, m.EdgeDensity, m.FineEdges, m.SymmetryX, m.SymmetryY, m.HighFreq, m.ColorVar, novelty)
+	return fmt.Sprintf("\nMetrics\n  entropy=%.3f\n  variance=%.4f\n  edgeDensity=%.3f\n  fineEdges=%.3f\n  symmetryX=%.3f\n  symmetryY=%.3f\n  highFreq=%.3f\n  colorVar=%.4f\n  spectralSlope=%.3f\n  spectralFlatness=%.3f\n  dominantFreq=%.3f\n  fractalDim=%.3f\n  colorfulness=%.1f\n  complexity=%.3f\n  novelty=%.3f\n", m.Entropy, m.Variance, m.EdgeDensity, m.FineEdges, m.SymmetryX, m.SymmetryY, m.HighFreq, m.ColorVar, m.SpectralSlope, m.SpectralFlatness, m.DominantFreq, m.FractalDim, m.Colorfulness, m.Complexity, novelty)
 }
 
 func sortIndicesByFitness(genomes []neat.Genome) []int {
@@ -502,10 +770,10 @@ func updateTile(index, width, height int, pixels []byte) {
 	js.Global().Call("updateTile", index, width, height, jsPixels)
 }
 
-func updateDetail(width, height int, pixels []byte, fitness float64, nodes, conns, hidden, outputs int, summary string) {
+func updateDetail(width, height int, pixels []byte, fitness float64, nodes, conns, hidden, outputs int, summary, network string) {
 	jsPixels := js.Global().Get("Uint8ClampedArray").New(len(pixels))
 	js.CopyBytesToJS(jsPixels, pixels)
-	js.Global().Call("updateDetail", width, height, jsPixels, fitness, nodes, conns, hidden, outputs, summary)
+	js.Global().Call("updateDetail", width, height, jsPixels, fitness, nodes, conns, hidden, outputs, summary, network)
 }
 
 func prepareGallery(popSize, tileSize int) {
@@ -529,3 +797,7 @@ func setStatus(msg string) {
 func setRunning(running bool) {
 	js.Global().Call("setRunning", running)
 }
+
+func setHistory(canUndo, canRedo bool) {
+	js.Global().Call("setHistory", canUndo, canRedo)
+}
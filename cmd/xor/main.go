@@ -4,106 +4,140 @@ import (
 	"flag"
 	"fmt"
 	"math"
+	"os"
 
 	"github.com/zacharyburkett/image-zoo/pkg/neat"
 )
 
 func main() {
+	configPath := flag.String("config", "", "load settings from a JSON or neat-python INI config")
 	seed := flag.Int64("seed", 42, "random seed")
 	popSize := flag.Int("pop", 150, "population size")
 	maxGen := flag.Int("gen", 200, "max generations")
 	target := flag.Float64("target", 3.9, "target fitness")
+	csvPath := flag.String("csv", "", "write per-generation stats as CSV to this file")
+	jsonlPath := flag.String("jsonl", "", "write per-generation stats as JSON Lines to this file")
 	flag.Parse()
 
-	rng := neat.NewRand(*seed)
-	initTracker, err := neat.NewInnovationTracker(nil)
-	if err != nil {
-		panic(err)
-	}
-
-	genomes := make([]neat.Genome, 0, *popSize)
-	for i := 0; i < *popSize; i++ {
-		g, err := neat.NewMinimalGenome(3, 1, neat.ActivationSigmoid, rng, initTracker, 1.0)
+	cfg := defaultXORConfig()
+	if *configPath != "" {
+		loaded, warnings, err := neat.LoadConfigFile(*configPath)
 		if err != nil {
 			panic(err)
 		}
-		genomes = append(genomes, g)
+		for _, w := range warnings {
+			fmt.Fprintln(os.Stderr, "config:", w)
+		}
+		cfg = loaded
 	}
-
-	pcfg := neat.DefaultPopulationConfig()
-	pcfg.CompatibilityThreshold = 3.0
-
-	pop, err := neat.NewPopulation(rng, pcfg, genomes)
-	if err != nil {
-		panic(err)
+	apply := func(name string) {
+		switch name {
+		case "seed":
+			cfg.Seed = *seed
+		case "pop":
+			cfg.PopulationSize = *popSize
+		case "gen":
+			cfg.MaxGenerations = *maxGen
+		case "target":
+			cfg.FitnessThreshold = *target
+		}
 	}
-
-	mcfg := neat.DefaultMutationConfig()
-	mcfg.AllowedActivations = []neat.ActivationType{
-		neat.ActivationSigmoid,
+	if *configPath == "" {
+		flag.VisitAll(func(f *flag.Flag) { apply(f.Name) })
+	} else {
+		// Only flags given explicitly override the config file.
+		flag.Visit(func(f *flag.Flag) { apply(f.Name) })
+	}
+	if cfg.Inputs != 2 && cfg.Inputs != 3 || cfg.Outputs != 1 {
+		panic(fmt.Sprintf("xor needs 2 inputs (3 with a bias input) and 1 output, config has %d/%d", cfg.Inputs, cfg.Outputs))
 	}
-	// Match the original NEAT XOR setup more closely (bias node only).
-	mcfg.BiasMutateProb = 0
-
-	rcfg := neat.DefaultReproductionConfig()
 
-	runner := neat.Runner{
-		Population:  pop,
-		Mutation:    mcfg,
-		Reproduction: rcfg,
-		Fitness:     xorFitness,
+	rng := neat.NewRand(cfg.Seed)
+	pop, err := cfg.NewPopulation(rng)
+	if err != nil {
+		panic(err)
 	}
 
-	best := neat.Genome{}
-	for gen := 0; gen < *maxGen; gen++ {
-		currentBest, err := runner.Evaluate()
+	reporters := []neat.Reporter{neat.NewStdoutReporter()}
+	if *csvPath != "" {
+		f, err := os.Create(*csvPath)
 		if err != nil {
 			panic(err)
 		}
-		best = currentBest
-		if err := pop.Speciate(); err != nil {
+		defer f.Close()
+		reporters = append(reporters, neat.NewCSVReporter(f))
+	}
+	if *jsonlPath != "" {
+		f, err := os.Create(*jsonlPath)
+		if err != nil {
 			panic(err)
 		}
-		fmt.Printf("gen %d best=%.4f species=%d\n", gen, best.Fitness, len(pop.Species))
-		if best.Fitness >= *target {
-			break
-		}
-		if gen < *maxGen-1 {
-			if err := pop.NextGeneration(mcfg, rcfg); err != nil {
-				panic(err)
-			}
-		}
+		defer f.Close()
+		reporters = append(reporters, neat.NewJSONLReporter(f))
 	}
 
-	fmt.Printf("best fitness=%.4f\n", best.Fitness)
-}
+	runner := neat.Runner{
+		Population:   pop,
+		Mutation:     cfg.Mutation,
+		Reproduction: cfg.Reproduction,
+		Fitness:      xorFitness(cfg.Inputs == 3),
+		Reporters:    reporters,
+	}
 
-func xorFitness(g *neat.Genome) (float64, error) {
-	plan, err := neat.BuildAcyclicPlan(*g, nil, nil)
+	best, gen, err := runner.Run(cfg.MaxGenerations, cfg.FitnessThreshold)
 	if err != nil {
-		return 0, nil
+		panic(err)
 	}
 
-	tests := []struct {
-		x1, x2 float64
-		expect float64
-	}{
-		{0, 0, 0},
-		{0, 1, 1},
-		{1, 0, 1},
-		{1, 1, 0},
+	fmt.Printf("best fitness=%.4f generation=%d\n", best.Fitness, gen)
+}
+
+// defaultXORConfig matches the original NEAT XOR setup: a bias input,
+// sigmoid nodes only and no per-node bias mutation.
+func defaultXORConfig() neat.Config {
+	cfg := neat.DefaultConfig()
+	cfg.Inputs = 3
+	cfg.Outputs = 1
+	cfg.Population.CompatibilityThreshold = 3.0
+	cfg.Mutation.AllowedActivations = []neat.ActivationType{
+		neat.ActivationSigmoid,
 	}
+	cfg.Mutation.BiasMutateProb = 0
+	return cfg
+}
 
-	sum := 0.0
-	for _, tt := range tests {
-		out, err := plan.Eval([]float64{tt.x1, tt.x2, 1})
+func xorFitness(biasInput bool) neat.FitnessFunc {
+	return func(g *neat.Genome) (float64, error) {
+		plan, err := neat.BuildAcyclicPlan(*g, nil, nil)
 		if err != nil {
-			return 0, err
+			return 0, nil
 		}
-		diff := tt.expect - out[0]
-		sum += diff * diff
-	}
 
-	fitness := 4.0 - sum
-	return math.Max(0, fitness), nil
+		tests := []struct {
+			x1, x2 float64
+			expect float64
+		}{
+			{0, 0, 0},
+			{0, 1, 1},
+			{1, 0, 1},
+			{1, 1, 0},
+		}
+
+		sum := 0.0
+		for _, tt := range tests {
+			inputs := []float64{tt.x1, tt.x2}
+			if biasInput {
+				inputs = append(inputs, 1)
+			}
+			out, err := plan.Eval(inputs)
+			if err != nil {
+				return 0, err
+			}
+			diff := tt.expect - out[0]
+			sum += diff * diff
+		}
+
+		fitness := 4.0 - sum
+		return math.Max(0, fitness), nil
+	}
 }
@@ -0,0 +1,302 @@
+package cppn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+// RenderFrames renders frames evenly spaced over t in [0,1). With
+// spec.LoopTime the sequence loops seamlessly.
+func RenderFrames(plan *neat.Plan, width, height int, spec InputSpec, cfg RenderConfig, frames int) ([][]byte, error) {
+	if frames <= 0 {
+		return nil, fmt.Errorf("frames must be > 0")
+	}
+	if !spec.UseTime {
+		return nil, fmt.Errorf("input spec has no time input")
+	}
+	out := make([][]byte, frames)
+	for i := range out {
+		pixels, err := RenderFrame(plan, width, height, spec, cfg, float64(i)/float64(frames))
+		if err != nil {
+			return nil, err
+		}
+		out[i] = pixels
+	}
+	return out, nil
+}
+
+// EncodeGIF writes RGBA frames as a looping animated GIF with delay in
+// hundredths of a second per frame. Grayscale animations use a 256-level
+// gray palette; colour ones are dithered to the Plan 9 palette.
+func EncodeGIF(w io.Writer, frames [][]byte, width, height, delay int) error {
+	if err := checkFrames(frames, width, height); err != nil {
+		return err
+	}
+	if err := checkDelay(delay); err != nil {
+		return err
+	}
+	pal := color.Palette(palette.Plan9)
+	if framesAreGray(frames) {
+		pal = make(color.Palette, 256)
+		for i := range pal {
+			pal[i] = color.Gray{Y: uint8(i)}
+		}
+	}
+	anim := &gif.GIF{LoopCount: 0}
+	bounds := image.Rect(0, 0, width, height)
+	for _, pixels := range frames {
+		src := &image.RGBA{Pix: pixels, Stride: width * 4, Rect: bounds}
+		dst := image.NewPaletted(bounds, pal)
+		draw.FloydSteinberg.Draw(dst, bounds, src, image.Point{})
+		anim.Image = append(anim.Image, dst)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// EncodeAPNG writes RGBA frames as a looping animated PNG with delay in
+// hundredths of a second per frame. Viewers without APNG support show the
+// first frame.
+func EncodeAPNG(w io.Writer, frames [][]byte, width, height, delay int) error {
+	if err := checkFrames(frames, width, height); err != nil {
+		return err
+	}
+	if err := checkDelay(delay); err != nil {
+		return err
+	}
+	if _, err := w.Write(pngSignature); err != nil {
+		return err
+	}
+	var ihdr []byte
+	seq := uint32(0)
+	bounds := image.Rect(0, 0, width, height)
+	for i, pixels := range frames {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, &image.NRGBA{Pix: pixels, Stride: width * 4, Rect: bounds}); err != nil {
+			return err
+		}
+		header, data, err := splitPNG(buf.Bytes())
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			ihdr = header
+			if err := writePNGChunk(w, "IHDR", ihdr); err != nil {
+				return err
+			}
+			actl := make([]byte, 8)
+			binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+			// Zero plays forever.
+			binary.BigEndian.PutUint32(actl[4:], 0)
+			if err := writePNGChunk(w, "acTL", actl); err != nil {
+				return err
+			}
+		} else if !bytes.Equal(header, ihdr) {
+			return fmt.Errorf("frame %d encodes with a different PNG header", i)
+		}
+
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(width))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(height))
+		binary.BigEndian.PutUint16(fctl[20:], uint16(delay))
+		binary.BigEndian.PutUint16(fctl[22:], 100)
+		// Bytes 12-19 are the zero offset; 24 and 25 dispose and blend
+		// nothing, since every frame covers the whole canvas.
+		seq++
+		if err := writePNGChunk(w, "fcTL", fctl); err != nil {
+			return err
+		}
+		if i == 0 {
+			err = writePNGChunk(w, "IDAT", data)
+		} else {
+			fdat := make([]byte, 4+len(data))
+			binary.BigEndian.PutUint32(fdat, seq)
+			copy(fdat[4:], data)
+			seq++
+			err = writePNGChunk(w, "fdAT", fdat)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return writePNGChunk(w, "IEND", nil)
+}
+
+// splitPNG returns the IHDR payload and the concatenated IDAT payloads of an
+// encoded PNG.
+func splitPNG(data []byte) ([]byte, []byte, error) {
+	data = data[len(pngSignature):]
+	var header, idat []byte
+	for len(data) >= 12 {
+		length := int(binary.BigEndian.Uint32(data[:4]))
+		kind := string(data[4:8])
+		if len(data) < 12+length {
+			break
+		}
+		payload := data[8 : 8+length]
+		switch kind {
+		case "IHDR":
+			header = payload
+		case "IDAT":
+			idat = append(idat, payload...)
+		}
+		data = data[12+length:]
+	}
+	if header == nil || idat == nil {
+		return nil, nil, fmt.Errorf("malformed png frame")
+	}
+	return header, idat, nil
+}
+
+func checkFrames(frames [][]byte, width, height int) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames")
+	}
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid size %dx%d", width, height)
+	}
+	for i, f := range frames {
+		if len(f) != width*height*4 {
+			return fmt.Errorf("frame %d has %d bytes, expected %d", i, len(f), width*height*4)
+		}
+	}
+	return nil
+}
+
+// checkDelay rejects delays that GIF and APNG cannot store in 16 bits.
+func checkDelay(delay int) error {
+	if delay < 0 || delay > math.MaxUint16 {
+		return fmt.Errorf("delay %d outside [0, %d]", delay, math.MaxUint16)
+	}
+	return nil
+}
+
+func framesAreGray(frames [][]byte) bool {
+	for _, f := range frames {
+		for i := 0; i < len(f); i += 4 {
+			if f[i] != f[i+1] || f[i] != f[i+2] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// TemporalMetrics describes how an animation changes between frames.
+type TemporalMetrics struct {
+	// Motion is the mean absolute luminance change between consecutive
+	// frames, in [0,1].
+	Motion float64
+	// Smoothness is in [0,1]: 1 when motion is steady or absent, lower
+	// when the change between frames itself jumps around.
+	Smoothness float64
+	// LoopSeam is the mean absolute luminance change from the last frame to
+	// the first, in [0,1].
+	LoopSeam float64
+}
+
+// ComputeTemporalMetrics measures motion and smoothness of RGBA frames. With
+// loop set the last frame is followed by the first.
+func ComputeTemporalMetrics(frames [][]byte, width, height int, loop bool) TemporalMetrics {
+	var tm TemporalMetrics
+	if checkFrames(frames, width, height) != nil {
+		return tm
+	}
+	count := width * height
+	lums := make([][]float64, len(frames))
+	for i, f := range frames {
+		lums[i] = luminance(f, count)
+	}
+	n := len(frames)
+	tm.Smoothness = 1
+	if n < 2 {
+		return tm
+	}
+	tm.LoopSeam = meanAbsDiff(lums[n-1], lums[0])
+
+	steps := n - 1
+	if loop {
+		steps = n
+	}
+	for i := 0; i < steps; i++ {
+		tm.Motion += meanAbsDiff(lums[(i+1)%n], lums[i])
+	}
+	tm.Motion /= float64(steps)
+
+	// Mean absolute second difference, relative to the motion it disturbs.
+	first, last := 1, n-2
+	if loop {
+		first, last = 0, n-1
+	}
+	jerk, jerks := 0.0, 0
+	for i := first; i <= last; i++ {
+		prev, cur, next := lums[(i-1+n)%n], lums[i], lums[(i+1)%n]
+		sum := 0.0
+		for p := range cur {
+			sum += math.Abs(next[p] - 2*cur[p] + prev[p])
+		}
+		jerk += sum / float64(count)
+		jerks++
+	}
+	if jerks > 0 && tm.Motion > 0 {
+		tm.Smoothness = clamp01(1 - jerk/float64(jerks)/(2*tm.Motion))
+	}
+	return tm
+}
+
+func meanAbsDiff(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += math.Abs(a[i] - b[i])
+	}
+	return sum / float64(len(a))
+}
+
+// TemporalWeights controls how temporal metrics add to an animation's score.
+type TemporalWeights struct {
+	// Motion rewards mean frame-to-frame change near 0.05.
+	Motion     float64
+	Smoothness float64
+	// LoopSeam penalises a visible jump from the last frame to the first.
+	LoopSeam float64
+}
+
+// DefaultTemporalWeights rewards gentle, smooth motion.
+func DefaultTemporalWeights() TemporalWeights {
+	return TemporalWeights{
+		Motion:     0.3,
+		Smoothness: 0.2,
+		LoopSeam:   0.2,
+	}
+}
+
+// ScoreAnimation is the mean per-frame ScoreFromMetrics plus the weighted
+// temporal terms.
+func ScoreAnimation(frames []Metrics, tm TemporalMetrics, weights FitnessWeights, tw TemporalWeights, color bool) float64 {
+	score := 0.0
+	for _, m := range frames {
+		score += ScoreFromMetrics(m, weights, color)
+	}
+	if len(frames) > 0 {
+		score /= float64(len(frames))
+	}
+	score += tw.Motion*targetScore(tm.Motion, 0.05, 0.05) +
+		tw.Smoothness*tm.Smoothness -
+		tw.LoopSeam*clamp01(tm.LoopSeam/0.1)
+	if score < 0 {
+		return 0
+	}
+	return score
+}
@@ -0,0 +1,213 @@
+package cppn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/gif"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+func TestInputSpecTime(t *testing.T) {
+	linear := InputSpec{UseX: true, UseBias: true, UseTime: true}
+	if linear.Count() != 3 {
+		t.Fatalf("expected 3 inputs, got %d", linear.Count())
+	}
+	in := make([]float64, 3)
+	if err := linear.FillAt(in, 0.5, 0, 0.75); err != nil {
+		t.Fatalf("FillAt error: %v", err)
+	}
+	if in[0] != 0.5 || in[1] != 1 || in[2] != 0.5 {
+		t.Fatalf("unexpected linear time inputs %v", in)
+	}
+
+	loop := InputSpec{UseX: true, UseTime: true, LoopTime: true}
+	if loop.Count() != 3 {
+		t.Fatalf("expected 3 inputs, got %d", loop.Count())
+	}
+	if err := loop.FillAt(in, 0, 0, 0.25); err != nil {
+		t.Fatalf("FillAt error: %v", err)
+	}
+	if math.Abs(in[1]-1) > 1e-12 || math.Abs(in[2]) > 1e-12 {
+		t.Fatalf("unexpected loop time inputs %v", in)
+	}
+}
+
+// animationPlan maps x and a looping time pair to one output.
+func animationPlan(t *testing.T) (*neat.Plan, InputSpec) {
+	t.Helper()
+	spec := InputSpec{UseX: true, UseTime: true, LoopTime: true}
+	g := neat.Genome{
+		Nodes: []neat.NodeGene{
+			{ID: 1, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 2, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 3, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 4, Kind: neat.NodeOutput, Activation: neat.ActivationSin},
+		},
+		Connections: []neat.ConnectionGene{
+			{Innovation: 1, In: 1, Out: 4, Weight: 3, Enabled: true},
+			{Innovation: 2, In: 2, Out: 4, Weight: 1, Enabled: true},
+			{Innovation: 3, In: 3, Out: 4, Weight: 0.5, Enabled: true},
+		},
+	}
+	plan, err := neat.BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	return plan, spec
+}
+
+func TestRenderFramesLoop(t *testing.T) {
+	plan, spec := animationPlan(t)
+	frames, err := RenderFrames(plan, 16, 16, spec, DefaultRenderConfig(), 12)
+	if err != nil {
+		t.Fatalf("RenderFrames error: %v", err)
+	}
+	if len(frames) != 12 {
+		t.Fatalf("expected 12 frames, got %d", len(frames))
+	}
+	if bytes.Equal(frames[0], frames[3]) {
+		t.Fatalf("expected frames to change over time")
+	}
+	wrapped, err := RenderFrame(plan, 16, 16, spec, DefaultRenderConfig(), 1)
+	if err != nil {
+		t.Fatalf("RenderFrame error: %v", err)
+	}
+	for i := range wrapped {
+		if d := int(wrapped[i]) - int(frames[0][i]); d < -1 || d > 1 {
+			t.Fatalf("expected t=1 to match the first frame")
+		}
+	}
+	if _, err := RenderFrames(plan, 16, 16, InputSpec{UseX: true}, DefaultRenderConfig(), 4); err == nil {
+		t.Fatalf("expected error without a time input")
+	}
+}
+
+func TestEncodeGIF(t *testing.T) {
+	plan, spec := animationPlan(t)
+	frames, err := RenderFrames(plan, 8, 8, spec, DefaultRenderConfig(), 5)
+	if err != nil {
+		t.Fatalf("RenderFrames error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := EncodeGIF(&buf, frames, 8, 8, 4); err != nil {
+		t.Fatalf("EncodeGIF error: %v", err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("DecodeAll error: %v", err)
+	}
+	if len(anim.Image) != 5 || anim.Delay[0] != 4 || anim.LoopCount != 0 {
+		t.Fatalf("unexpected gif: %d frames, delay %d, loop %d", len(anim.Image), anim.Delay[0], anim.LoopCount)
+	}
+	for _, delay := range []int{-1, 65536} {
+		if err := EncodeGIF(&bytes.Buffer{}, frames, 8, 8, delay); err == nil {
+			t.Fatalf("expected error for delay %d", delay)
+		}
+		if err := EncodeAPNG(&bytes.Buffer{}, frames, 8, 8, delay); err == nil {
+			t.Fatalf("expected error for delay %d", delay)
+		}
+	}
+	// Grayscale frames use an exact gray palette.
+	r, _, _, _ := anim.Image[2].At(3, 3).RGBA()
+	if want := frames[2][(3*8+3)*4]; uint8(r>>8) != want {
+		t.Fatalf("expected exact gray %d, got %d", want, r>>8)
+	}
+}
+
+func TestEncodeAPNG(t *testing.T) {
+	plan, spec := animationPlan(t)
+	frames, err := RenderFrames(plan, 8, 8, spec, DefaultRenderConfig(), 3)
+	if err != nil {
+		t.Fatalf("RenderFrames error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := EncodeAPNG(&buf, frames, 8, 8, 5); err != nil {
+		t.Fatalf("EncodeAPNG error: %v", err)
+	}
+	data := buf.Bytes()
+
+	// Plain PNG decoders see the first frame.
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.Decode error: %v", err)
+	}
+	r, _, _, _ := img.At(1, 1).RGBA()
+	if uint8(r>>8) != frames[0][(1*8+1)*4] {
+		t.Fatalf("first frame mismatch")
+	}
+
+	var kinds []string
+	var seqs []uint32
+	rest := data[len(pngSignature):]
+	for len(rest) >= 12 {
+		length := int(binary.BigEndian.Uint32(rest[:4]))
+		kind := string(rest[4:8])
+		payload := rest[8 : 8+length]
+		kinds = append(kinds, kind)
+		switch kind {
+		case "acTL":
+			if n := binary.BigEndian.Uint32(payload); n != 3 {
+				t.Fatalf("acTL frame count %d", n)
+			}
+		case "fcTL", "fdAT":
+			seqs = append(seqs, binary.BigEndian.Uint32(payload))
+		}
+		rest = rest[12+length:]
+	}
+	want := []string{"IHDR", "acTL", "fcTL", "IDAT", "fcTL", "fdAT", "fcTL", "fdAT", "IEND"}
+	if len(kinds) != len(want) {
+		t.Fatalf("unexpected chunks %v", kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("unexpected chunks %v", kinds)
+		}
+	}
+	for i, s := range seqs {
+		if s != uint32(i) {
+			t.Fatalf("sequence numbers %v are not consecutive", seqs)
+		}
+	}
+}
+
+func TestTemporalMetrics(t *testing.T) {
+	size := 16
+	frame := func(shift float64) []byte {
+		lums := make([]float64, size*size)
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				lums[y*size+x] = 0.5 + 0.4*math.Sin(2*math.Pi*(float64(x)/float64(size)+shift))
+			}
+		}
+		return grayPixels(lums)
+	}
+
+	static := [][]byte{frame(0), frame(0), frame(0)}
+	tm := ComputeTemporalMetrics(static, size, size, false)
+	if tm.Motion != 0 || tm.Smoothness != 1 || tm.LoopSeam != 0 {
+		t.Fatalf("unexpected metrics for a still animation: %+v", tm)
+	}
+
+	var steady, flicker [][]byte
+	for i := 0; i < 8; i++ {
+		steady = append(steady, frame(float64(i)/8))
+		flicker = append(flicker, frame(float64(i%2)*0.5))
+	}
+	s := ComputeTemporalMetrics(steady, size, size, true)
+	f := ComputeTemporalMetrics(flicker, size, size, true)
+	if s.Motion <= 0 || f.Motion <= 0 {
+		t.Fatalf("expected motion, got %v and %v", s.Motion, f.Motion)
+	}
+	if s.Smoothness <= f.Smoothness {
+		t.Fatalf("expected steady motion %v to be smoother than flicker %v", s.Smoothness, f.Smoothness)
+	}
+
+	tw := TemporalWeights{Smoothness: 1}
+	if ScoreAnimation(nil, s, FitnessWeights{}, tw, false) <= ScoreAnimation(nil, f, FitnessWeights{}, tw, false) {
+		t.Fatalf("expected smooth animation to score higher")
+	}
+}
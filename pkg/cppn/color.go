@@ -0,0 +1,375 @@
+package cppn
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+// ColorModel selects how CPPN outputs are turned into colour. Every output is
+// first mapped into [0,1] as for grayscale.
+type ColorModel uint8
+
+const (
+	// ColorRGB reads three outputs as red, green and blue.
+	ColorRGB ColorModel = iota
+	// ColorHSV reads three outputs as hue, saturation and value.
+	ColorHSV
+	// ColorHSL reads three outputs as hue, saturation and lightness.
+	ColorHSL
+	// ColorOKLab reads three outputs as OKLab L, a and b; a and b span
+	// [-0.4, 0.4].
+	ColorOKLab
+	// ColorPalette reads the first output as a position in
+	// RenderConfig.Palette.
+	ColorPalette
+)
+
+var colorModelNames = map[ColorModel]string{
+	ColorRGB:     "rgb",
+	ColorHSV:     "hsv",
+	ColorHSL:     "hsl",
+	ColorOKLab:   "oklab",
+	ColorPalette: "palette",
+}
+
+// channelNames are the output labels of the three-channel models.
+var channelNames = map[ColorModel][3]string{
+	ColorRGB:   {"red", "green", "blue"},
+	ColorHSV:   {"hue", "saturation", "value"},
+	ColorHSL:   {"hue", "saturation", "lightness"},
+	ColorOKLab: {"oklab_l", "oklab_a", "oklab_b"},
+}
+
+// grayLabel labels the single output of a grayscale genome.
+const grayLabel = "gray"
+
+// String returns the model name.
+func (m ColorModel) String() string {
+	if name, ok := colorModelNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("color_model(%d)", m)
+}
+
+// ParseColorModel parses a name returned by ColorModel.String.
+func ParseColorModel(name string) (ColorModel, error) {
+	for m, n := range colorModelNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown color model %q", name)
+}
+
+// GradientStop is a colour at a position in [0,1].
+type GradientStop struct {
+	Pos   float64  `json:"pos"`
+	Color [3]uint8 `json:"color"`
+}
+
+// Gradient is a piecewise-linear palette. Stops are kept sorted by Pos.
+type Gradient []GradientStop
+
+// DefaultGradient returns a dark-to-light purple and orange palette.
+func DefaultGradient() Gradient {
+	return Gradient{
+		{Pos: 0, Color: [3]uint8{0x00, 0x00, 0x04}},
+		{Pos: 0.25, Color: [3]uint8{0x3b, 0x0f, 0x70}},
+		{Pos: 0.5, Color: [3]uint8{0x8c, 0x29, 0x81}},
+		{Pos: 0.75, Color: [3]uint8{0xde, 0x49, 0x68}},
+		{Pos: 1, Color: [3]uint8{0xfc, 0xfd, 0xbf}},
+	}
+}
+
+// RandomGradient returns evenly spaced stops of random colours.
+func RandomGradient(rng neat.RNG, stops int) (Gradient, error) {
+	if rng == nil {
+		return nil, fmt.Errorf("rng is nil")
+	}
+	if stops < 2 {
+		return nil, fmt.Errorf("gradient needs at least 2 stops")
+	}
+	g := make(Gradient, stops)
+	for i := range g {
+		g[i].Pos = float64(i) / float64(stops-1)
+		for c := range g[i].Color {
+			g[i].Color[c] = uint8(rng.Intn(256))
+		}
+	}
+	return g, nil
+}
+
+// At returns the colour at t in [0,1] as red, green and blue in [0,1].
+func (g Gradient) At(t float64) [3]float64 {
+	if len(g) == 0 {
+		return [3]float64{t, t, t}
+	}
+	if t <= g[0].Pos {
+		return g[0].rgb()
+	}
+	for i := 1; i < len(g); i++ {
+		if t > g[i].Pos {
+			continue
+		}
+		a, b := g[i-1], g[i]
+		f := 0.0
+		if b.Pos > a.Pos {
+			f = (t - a.Pos) / (b.Pos - a.Pos)
+		}
+		ca, cb := a.rgb(), b.rgb()
+		return [3]float64{
+			ca[0] + f*(cb[0]-ca[0]),
+			ca[1] + f*(cb[1]-ca[1]),
+			ca[2] + f*(cb[2]-ca[2]),
+		}
+	}
+	return g[len(g)-1].rgb()
+}
+
+func (s GradientStop) rgb() [3]float64 {
+	return [3]float64{float64(s.Color[0]) / 255, float64(s.Color[1]) / 255, float64(s.Color[2]) / 255}
+}
+
+// Mutate returns a copy of g with one stop's colour perturbed by up to
+// power·255 per channel and, half the time, its position moved by up to
+// power/2.
+func (g Gradient) Mutate(rng neat.RNG, power float64) Gradient {
+	out := append(Gradient(nil), g...)
+	if len(out) == 0 || rng == nil {
+		return out
+	}
+	stop := &out[rng.Intn(len(out))]
+	for c := range stop.Color {
+		v := float64(stop.Color[c]) + (2*rng.Float64()-1)*power*255
+		stop.Color[c] = uint8(math.Round(math.Max(0, math.Min(255, v))))
+	}
+	if rng.Float64() < 0.5 {
+		pos := stop.Pos + (2*rng.Float64()-1)*power/2
+		stop.Pos = math.Round(clamp01(pos)*1000) / 1000
+		sort.SliceStable(out, func(i, j int) bool { return out[i].Pos < out[j].Pos })
+	}
+	return out
+}
+
+// String encodes g as comma-separated pos:rrggbb stops, the form stored in
+// genome labels.
+func (g Gradient) String() string {
+	parts := make([]string, len(g))
+	for i, s := range g {
+		parts[i] = fmt.Sprintf("%s:%02x%02x%02x", strconv.FormatFloat(s.Pos, 'g', -1, 64), s.Color[0], s.Color[1], s.Color[2])
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseGradient parses the form written by Gradient.String.
+func ParseGradient(s string) (Gradient, error) {
+	var g Gradient
+	for _, part := range strings.Split(s, ",") {
+		pos, hex, ok := strings.Cut(part, ":")
+		if !ok || len(hex) != 6 {
+			return nil, fmt.Errorf("invalid gradient stop %q", part)
+		}
+		p, err := strconv.ParseFloat(pos, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gradient stop %q: %w", part, err)
+		}
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gradient stop %q: %w", part, err)
+		}
+		g = append(g, GradientStop{Pos: p, Color: [3]uint8{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb)}})
+	}
+	sort.SliceStable(g, func(i, j int) bool { return g[i].Pos < g[j].Pos })
+	return g, nil
+}
+
+// painter maps plan outputs to red, green and blue in [0,1].
+type painter func(out []float64) [3]float64
+
+// painter picks how outputs become colour for a network with the given
+// number of outputs. Networks with fewer than three outputs render gray
+// unless the model is ColorPalette.
+func (cfg RenderConfig) painter(outputs int) painter {
+	if cfg.ColorModel == ColorPalette {
+		palette := cfg.gradient()
+		return func(out []float64) [3]float64 {
+			return palette.At(unit(out[0]))
+		}
+	}
+	if outputs < 3 {
+		return func(out []float64) [3]float64 {
+			v := unit(out[0])
+			return [3]float64{v, v, v}
+		}
+	}
+	var convert func(a, b, c float64) (float64, float64, float64)
+	switch cfg.ColorModel {
+	case ColorHSV:
+		convert = hsvToRGB
+	case ColorHSL:
+		convert = hslToRGB
+	case ColorOKLab:
+		convert = oklabToRGB
+	}
+	if convert == nil {
+		return func(out []float64) [3]float64 {
+			return [3]float64{unit(out[0]), unit(out[1]), unit(out[2])}
+		}
+	}
+	return func(out []float64) [3]float64 {
+		r, g, b := convert(unit(out[0]), unit(out[1]), unit(out[2]))
+		return [3]float64{clamp01(r), clamp01(g), clamp01(b)}
+	}
+}
+
+func (cfg RenderConfig) gradient() Gradient {
+	if len(cfg.Palette) == 0 {
+		return DefaultGradient()
+	}
+	return cfg.Palette
+}
+
+// hueToRGB returns the fully saturated colour at hue h in [0,1].
+func hueToRGB(h float64) (float64, float64, float64) {
+	h = 6 * (h - math.Floor(h))
+	return clamp01(math.Abs(h-3) - 1), clamp01(2 - math.Abs(h-2)), clamp01(2 - math.Abs(h-4))
+}
+
+func hsvToRGB(h, s, v float64) (float64, float64, float64) {
+	r, g, b := hueToRGB(h)
+	return v * (1 - s + s*r), v * (1 - s + s*g), v * (1 - s + s*b)
+}
+
+func hslToRGB(h, s, l float64) (float64, float64, float64) {
+	r, g, b := hueToRGB(h)
+	c := (1 - math.Abs(2*l-1)) * s
+	return l + c*(r-0.5), l + c*(g-0.5), l + c*(b-0.5)
+}
+
+// oklabToRGB converts OKLab with L, a and b given in [0,1] to sRGB, clipping
+// colours outside the gamut.
+func oklabToRGB(l, a, b float64) (float64, float64, float64) {
+	a = 0.8*a - 0.4
+	b = 0.8*b - 0.4
+	lc := cube(l + 0.3963377774*a + 0.2158037573*b)
+	mc := cube(l - 0.1055613458*a - 0.0638541728*b)
+	sc := cube(l - 0.0894841775*a - 1.2914855480*b)
+	return srgbGamma(4.0767416621*lc - 3.3077115913*mc + 0.2309699292*sc),
+		srgbGamma(-1.2684380046*lc + 2.6097574011*mc - 0.3413193965*sc),
+		srgbGamma(-0.0041960863*lc - 0.7034186147*mc + 1.7076147010*sc)
+}
+
+func cube(v float64) float64 { return v * v * v }
+
+func srgbGamma(v float64) float64 {
+	v = clamp01(v)
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// outputLabels names the outputs of a network with the given number of
+// outputs under cfg.
+func (cfg RenderConfig) outputLabels(outputs int) []string {
+	labels := make([]string, outputs)
+	switch {
+	case outputs == 0:
+	case cfg.ColorModel == ColorPalette:
+		labels[0] = "palette(" + cfg.gradient().String() + ")"
+	case outputs < 3:
+		labels[0] = grayLabel
+	default:
+		names := channelNames[cfg.ColorModel]
+		copy(labels, names[:])
+	}
+	return labels
+}
+
+// Label stores the colour model, and the palette for ColorPalette, on g's
+// output nodes.
+func (cfg RenderConfig) Label(g *neat.Genome) error {
+	if _, ok := colorModelNames[cfg.ColorModel]; !ok {
+		return fmt.Errorf("unknown color model %d", cfg.ColorModel)
+	}
+	return g.SetOutputLabels(cfg.outputLabels(len(g.OutputLabels())))
+}
+
+// ColorFromGenome returns the colour model a genome was labelled with and,
+// for ColorPalette, its gradient. It reports false for unlabelled genomes.
+func ColorFromGenome(g neat.Genome) (ColorModel, Gradient, bool, error) {
+	return colorFromLabels(g.OutputLabels())
+}
+
+func colorFromLabels(labels []string) (ColorModel, Gradient, bool, error) {
+	if len(labels) == 0 || labels[0] == "" {
+		return ColorRGB, nil, false, nil
+	}
+	first := labels[0]
+	if strings.HasPrefix(first, "palette(") && strings.HasSuffix(first, ")") {
+		g, err := ParseGradient(first[len("palette(") : len(first)-1])
+		if err != nil {
+			return 0, nil, false, err
+		}
+		return ColorPalette, g, true, nil
+	}
+	if first == grayLabel {
+		return ColorRGB, nil, true, nil
+	}
+	if len(labels) >= 3 {
+		for m, names := range channelNames {
+			if labels[0] == names[0] && labels[1] == names[1] && labels[2] == names[2] {
+				return m, nil, true, nil
+			}
+		}
+	}
+	return 0, nil, false, fmt.Errorf("unknown color outputs %q", labels)
+}
+
+// RenderConfigForGenome returns cfg with the colour model and palette a
+// genome was labelled with. Unlabelled genomes get cfg unchanged.
+func RenderConfigForGenome(g neat.Genome, cfg RenderConfig) (RenderConfig, error) {
+	model, palette, ok, err := ColorFromGenome(g)
+	if err != nil || !ok {
+		return cfg, err
+	}
+	if len(g.OutputLabels()) >= 3 || model == ColorPalette {
+		cfg.ColorModel = model
+	}
+	if model == ColorPalette {
+		cfg.Palette = palette
+	}
+	return cfg, nil
+}
+
+// MutatePalette perturbs the gradient stored on a palette genome's output
+// with Gradient.Mutate. Other genomes are left alone.
+func MutatePalette(g *neat.Genome, rng neat.RNG, power float64) error {
+	model, palette, ok, err := ColorFromGenome(*g)
+	if err != nil || !ok || model != ColorPalette {
+		return err
+	}
+	cfg := RenderConfig{ColorModel: ColorPalette, Palette: palette.Mutate(rng, power)}
+	return cfg.Label(g)
+}
+
+// checkOutputs reports whether a labelled plan was evolved for cfg's colour
+// model. Gray networks render the same under every model but ColorPalette.
+func (cfg RenderConfig) checkOutputs(plan *neat.Plan) error {
+	model, _, ok, err := colorFromLabels(plan.OutputLabels)
+	if err != nil || !ok {
+		return err
+	}
+	if model == cfg.ColorModel {
+		return nil
+	}
+	if len(plan.Outputs) < 3 && model != ColorPalette && cfg.ColorModel != ColorPalette {
+		return nil
+	}
+	return fmt.Errorf("genome outputs are %s but render color model is %s", model, cfg.ColorModel)
+}
@@ -0,0 +1,161 @@
+package cppn
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+func TestColorConversions(t *testing.T) {
+	near := func(r, g, b float64, want [3]float64) bool {
+		return math.Abs(r-want[0]) < 1e-3 && math.Abs(g-want[1]) < 1e-3 && math.Abs(b-want[2]) < 1e-3
+	}
+	cases := []struct {
+		name    string
+		fn      func(a, b, c float64) (float64, float64, float64)
+		a, b, c float64
+		want    [3]float64
+	}{
+		{"hsv red", hsvToRGB, 0, 1, 1, [3]float64{1, 0, 0}},
+		{"hsv green", hsvToRGB, 1.0 / 3, 1, 1, [3]float64{0, 1, 0}},
+		{"hsv gray", hsvToRGB, 0.7, 0, 0.5, [3]float64{0.5, 0.5, 0.5}},
+		{"hsl blue", hslToRGB, 2.0 / 3, 1, 0.5, [3]float64{0, 0, 1}},
+		{"hsl white", hslToRGB, 0.2, 1, 1, [3]float64{1, 1, 1}},
+		// a = b = 0.5 is OKLab's neutral axis.
+		{"oklab white", oklabToRGB, 1, 0.5, 0.5, [3]float64{1, 1, 1}},
+		{"oklab black", oklabToRGB, 0, 0.5, 0.5, [3]float64{0, 0, 0}},
+	}
+	for _, tc := range cases {
+		r, g, b := tc.fn(tc.a, tc.b, tc.c)
+		if !near(r, g, b, tc.want) {
+			t.Fatalf("%s: got %v %v %v, want %v", tc.name, r, g, b, tc.want)
+		}
+	}
+	if r, g, b := oklabToRGB(0.6, 0.5, 0.5); math.Abs(r-g) > 1e-6 || math.Abs(g-b) > 1e-6 {
+		t.Fatalf("expected neutral OKLab to be gray, got %v %v %v", r, g, b)
+	}
+}
+
+func TestGradient(t *testing.T) {
+	g := Gradient{
+		{Pos: 0, Color: [3]uint8{0, 0, 0}},
+		{Pos: 0.5, Color: [3]uint8{255, 0, 0}},
+		{Pos: 1, Color: [3]uint8{255, 255, 255}},
+	}
+	if c := g.At(0.25); math.Abs(c[0]-0.5) > 1e-9 || c[1] != 0 {
+		t.Fatalf("unexpected midpoint colour %v", c)
+	}
+	if c := g.At(2); c != [3]float64{1, 1, 1} {
+		t.Fatalf("expected clamp past the last stop, got %v", c)
+	}
+
+	text := g.String()
+	if text != "0:000000,0.5:ff0000,1:ffffff" {
+		t.Fatalf("unexpected encoding %q", text)
+	}
+	back, err := ParseGradient(text)
+	if err != nil {
+		t.Fatalf("ParseGradient error: %v", err)
+	}
+	if !reflect.DeepEqual(back, g) {
+		t.Fatalf("gradient did not round-trip: %v", back)
+	}
+	if _, err := ParseGradient("0:fff"); err == nil {
+		t.Fatalf("expected error for short colour")
+	}
+
+	rng := neat.NewRand(3)
+	mutated := g.Mutate(rng, 0.2)
+	if reflect.DeepEqual(mutated, g) {
+		t.Fatalf("expected mutation to change the gradient")
+	}
+	if !reflect.DeepEqual(back, g) {
+		t.Fatalf("mutation changed the original")
+	}
+	for i := 1; i < len(mutated); i++ {
+		if mutated[i].Pos < mutated[i-1].Pos {
+			t.Fatalf("mutated stops are not sorted: %v", mutated)
+		}
+	}
+}
+
+func TestRenderColorModels(t *testing.T) {
+	g := neat.Genome{
+		Nodes: []neat.NodeGene{
+			{ID: 1, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 2, Kind: neat.NodeOutput, Activation: neat.ActivationLinear},
+			{ID: 3, Kind: neat.NodeOutput, Activation: neat.ActivationLinear, Bias: 1},
+			{ID: 4, Kind: neat.NodeOutput, Activation: neat.ActivationLinear, Bias: 1},
+		},
+		Connections: []neat.ConnectionGene{
+			{Innovation: 1, In: 1, Out: 2, Weight: 1, Enabled: true},
+		},
+	}
+	plan, err := neat.BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	spec := InputSpec{UseX: true}
+
+	// A hue sweep at full saturation and value.
+	hsv, err := Render(plan, 7, 1, spec, RenderConfig{ColorModel: ColorHSV})
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	if hsv[0] != 255 || hsv[1] != 0 || hsv[2] != 0 {
+		t.Fatalf("expected red at hue 0, got %v", hsv[:3])
+	}
+	if hsv[2*4] != 0 || hsv[2*4+1] != 255 {
+		t.Fatalf("expected green at hue 1/3, got %v", hsv[8:11])
+	}
+
+	palette := Gradient{{Pos: 0, Color: [3]uint8{0, 0, 255}}, {Pos: 1, Color: [3]uint8{255, 255, 0}}}
+	cfg := RenderConfig{ColorModel: ColorPalette, Palette: palette}
+	pixels, err := Render(plan, 3, 1, spec, cfg)
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	if pixels[2] != 255 || pixels[8] != 255 || pixels[10] != 0 {
+		t.Fatalf("unexpected palette pixels %v", pixels)
+	}
+
+	if err := cfg.Label(&g); err != nil {
+		t.Fatalf("Label error: %v", err)
+	}
+	restored, err := RenderConfigForGenome(g, DefaultRenderConfig())
+	if err != nil {
+		t.Fatalf("RenderConfigForGenome error: %v", err)
+	}
+	if restored.ColorModel != ColorPalette || !reflect.DeepEqual(restored.Palette, palette) {
+		t.Fatalf("unexpected restored config %+v", restored)
+	}
+	labelled, err := neat.BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	if _, err := Render(labelled, 3, 1, spec, DefaultRenderConfig()); err == nil {
+		t.Fatalf("expected error rendering a palette genome as RGB")
+	}
+
+	if err := MutatePalette(&g, neat.NewRand(5), 0.3); err != nil {
+		t.Fatalf("MutatePalette error: %v", err)
+	}
+	_, evolved, _, err := ColorFromGenome(g)
+	if err != nil || reflect.DeepEqual(evolved, palette) {
+		t.Fatalf("expected the stored palette to change, got %v (err %v)", evolved, err)
+	}
+}
+
+func TestParseColorModel(t *testing.T) {
+	for m := range colorModelNames {
+		got, err := ParseColorModel(m.String())
+		if err != nil || got != m {
+			t.Fatalf("ParseColorModel(%q) = %v, %v", m.String(), got, err)
+		}
+	}
+	if _, err := ParseColorModel("cmyk"); err == nil {
+		t.Fatalf("expected error for unknown model")
+	}
+}
@@ -0,0 +1,78 @@
+package cppn
+
+import (
+	"bytes"
+	"compress/flate"
+)
+
+// Complexity measures how poorly an RGBA buffer compresses, in [0,1]: near 0
+// for flat images and near 1 for noise. Unlike histogram entropy it sees
+// spatial structure, so a smooth gradient scores far below the same pixels
+// shuffled. Rows are Paeth-filtered as in PNG and deflated; grayscale
+// images (R=G=B everywhere) are compressed as one channel, others as RGB.
+func Complexity(pixels []byte, width, height int) (float64, error) {
+	if width <= 0 || height <= 0 || len(pixels) < width*height*4 {
+		return 0, nil
+	}
+	channels := 1
+	for i := 0; i < width*height*4; i += 4 {
+		if pixels[i] != pixels[i+1] || pixels[i] != pixels[i+2] {
+			channels = 3
+			break
+		}
+	}
+	stride := width * channels
+	raw := make([]byte, height*stride)
+	for i := 0; i < width*height; i++ {
+		copy(raw[i*channels:(i+1)*channels], pixels[i*4:i*4+channels])
+	}
+	filtered := make([]byte, len(raw))
+	for y := 0; y < height; y++ {
+		for x := 0; x < stride; x++ {
+			var left, up, upLeft byte
+			if x >= channels {
+				left = raw[y*stride+x-channels]
+			}
+			if y > 0 {
+				up = raw[(y-1)*stride+x]
+				if x >= channels {
+					upLeft = raw[(y-1)*stride+x-channels]
+				}
+			}
+			filtered[y*stride+x] = raw[y*stride+x] - paeth(left, up, upLeft)
+		}
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(filtered); err != nil {
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	return clamp01(float64(buf.Len()) / float64(len(raw))), nil
+}
+
+// paeth is the PNG Paeth predictor.
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := absInt(p-int(a)), absInt(p-int(b)), absInt(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,82 @@
+package cppn
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func complexity(t *testing.T, pixels []byte, size int) float64 {
+	t.Helper()
+	c, err := Complexity(pixels, size, size)
+	if err != nil {
+		t.Fatalf("Complexity error: %v", err)
+	}
+	return c
+}
+
+func TestComplexitySeesSpatialStructure(t *testing.T) {
+	size := 64
+	lums := make([]float64, size*size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			lums[y*size+x] = float64(x+y) / float64(2*size-2)
+		}
+	}
+	gradient := grayPixels(lums)
+	shuffled := append([]byte(nil), gradient...)
+	rng := rand.New(rand.NewSource(4))
+	rng.Shuffle(size*size, func(i, j int) {
+		for c := 0; c < 4; c++ {
+			shuffled[i*4+c], shuffled[j*4+c] = shuffled[j*4+c], shuffled[i*4+c]
+		}
+	})
+
+	if Entropy(gradient) != Entropy(shuffled) {
+		t.Fatalf("expected equal histogram entropy")
+	}
+	g := complexity(t, gradient, size)
+	s := complexity(t, shuffled, size)
+	if g > 0.2 {
+		t.Fatalf("expected a gradient to compress well, got %v", g)
+	}
+	if s < 0.6 {
+		t.Fatalf("expected a shuffled gradient to compress poorly, got %v", s)
+	}
+}
+
+func TestComplexityRange(t *testing.T) {
+	size := 32
+	flat := grayPixels(make([]float64, size*size))
+	if c := complexity(t, flat, size); c > 0.05 {
+		t.Fatalf("expected near 0 for a flat image, got %v", c)
+	}
+
+	noise := make([]byte, size*size*4)
+	rng := rand.New(rand.NewSource(8))
+	rng.Read(noise)
+	for i := 3; i < len(noise); i += 4 {
+		noise[i] = 255
+	}
+	if c := complexity(t, noise, size); c < 0.9 || c > 1 {
+		t.Fatalf("expected near 1 for RGB noise, got %v", c)
+	}
+	if c := complexity(t, grayPixels(noiseLums(size, 2)), size); c < 0.9 || c > 1 {
+		t.Fatalf("expected near 1 for grayscale noise, got %v", c)
+	}
+	if c := complexity(t, nil, size); c != 0 {
+		t.Fatalf("expected 0 for a short buffer, got %v", c)
+	}
+}
+
+func TestScoreFromMetricsComplexityBand(t *testing.T) {
+	w := FitnessWeights{Complexity: 1}
+	inBand := ScoreFromMetrics(Metrics{Complexity: 0.35}, w, false)
+	tooSimple := ScoreFromMetrics(Metrics{Complexity: 0.05}, w, false)
+	tooNoisy := ScoreFromMetrics(Metrics{Complexity: 0.95}, w, false)
+	if inBand != 1 || tooSimple != 0 || tooNoisy != 0 {
+		t.Fatalf("unexpected band scores %v %v %v", inBand, tooSimple, tooNoisy)
+	}
+	if w.Needs() != MetricComplexity {
+		t.Fatalf("expected the complexity weight to need MetricComplexity")
+	}
+}
@@ -0,0 +1,126 @@
+package cppn
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FeatureFunc computes one extra CPPN input from a coordinate in [-1, 1].
+type FeatureFunc func(x, y float64) float64
+
+// Built-in feature names. Parametric features are named by AnchorFeature and
+// FourierFeatures.
+const (
+	// FeatureAngle is the polar angle atan2(y, x) scaled into [-1, 1].
+	FeatureAngle = "angle"
+	// FeatureManhattan is |x| + |y|.
+	FeatureManhattan = "manhattan"
+	// FeatureChebyshev is max(|x|, |y|).
+	FeatureChebyshev = "chebyshev"
+)
+
+var builtinFeatures = map[string]FeatureFunc{
+	FeatureAngle:     func(x, y float64) float64 { return math.Atan2(y, x) / math.Pi },
+	FeatureManhattan: func(x, y float64) float64 { return math.Abs(x) + math.Abs(y) },
+	FeatureChebyshev: func(x, y float64) float64 { return math.Max(math.Abs(x), math.Abs(y)) },
+}
+
+// parametricFeatures build a feature from the arguments in its name, such as
+// "anchor(0.5,-0.5)".
+var parametricFeatures = map[string]func(x, y float64) FeatureFunc{
+	"anchor": func(ax, ay float64) FeatureFunc {
+		return func(x, y float64) float64 { return math.Hypot(x-ax, y-ay) }
+	},
+	"sin": func(fx, fy float64) FeatureFunc {
+		return func(x, y float64) float64 { return math.Sin(math.Pi * (fx*x + fy*y)) }
+	},
+	"cos": func(fx, fy float64) FeatureFunc {
+		return func(x, y float64) float64 { return math.Cos(math.Pi * (fx*x + fy*y)) }
+	},
+}
+
+var (
+	featureMu       sync.RWMutex
+	customFeatures  = map[string]FeatureFunc{}
+	reservedFeature = map[string]bool{
+		inputX: true, inputY: true, inputRadius: true, inputBias: true,
+		inputTime: true, inputTimeSin: true, inputTimeCos: true,
+		inputZ: true, inputDist: true,
+	}
+)
+
+// AnchorFeature names the distance from (x, y).
+func AnchorFeature(x, y float64) string {
+	return "anchor(" + formatFeatureArgs(x, y) + ")"
+}
+
+// FourierFeatures names the pair sin(π(fx·x + fy·y)), cos(π(fx·x + fy·y)).
+func FourierFeatures(fx, fy float64) []string {
+	args := formatFeatureArgs(fx, fy)
+	return []string{"sin(" + args + ")", "cos(" + args + ")"}
+}
+
+func formatFeatureArgs(a, b float64) string {
+	return strconv.FormatFloat(a, 'g', -1, 64) + "," + strconv.FormatFloat(b, 'g', -1, 64)
+}
+
+// RegisterFeature makes a user-supplied feature available under name. Names
+// are stored with genomes, so a program loading them must register the same
+// features before rendering.
+func RegisterFeature(name string, fn FeatureFunc) error {
+	if fn == nil {
+		return fmt.Errorf("feature %q is nil", name)
+	}
+	if name == "" || strings.ContainsAny(name, "(), \t\n") {
+		return fmt.Errorf("invalid feature name %q", name)
+	}
+	if _, ok := builtinFeatures[name]; ok || reservedFeature[name] {
+		return fmt.Errorf("feature name %q is reserved", name)
+	}
+	if _, ok := parametricFeatures[name]; ok {
+		return fmt.Errorf("feature name %q is reserved", name)
+	}
+	featureMu.Lock()
+	defer featureMu.Unlock()
+	if _, ok := customFeatures[name]; ok {
+		return fmt.Errorf("feature %q is already registered", name)
+	}
+	customFeatures[name] = fn
+	return nil
+}
+
+// LookupFeature resolves a feature name to its function.
+func LookupFeature(name string) (FeatureFunc, error) {
+	if fn, ok := builtinFeatures[name]; ok {
+		return fn, nil
+	}
+	if open := strings.IndexByte(name, '('); open > 0 && strings.HasSuffix(name, ")") {
+		build, ok := parametricFeatures[name[:open]]
+		if !ok {
+			return nil, fmt.Errorf("unknown feature %q", name)
+		}
+		a, b, ok := strings.Cut(name[open+1:len(name)-1], ",")
+		if !ok {
+			return nil, fmt.Errorf("feature %q needs two arguments", name)
+		}
+		x, err := strconv.ParseFloat(a, 64)
+		if err != nil {
+			return nil, fmt.Errorf("feature %q: %w", name, err)
+		}
+		y, err := strconv.ParseFloat(b, 64)
+		if err != nil {
+			return nil, fmt.Errorf("feature %q: %w", name, err)
+		}
+		return build(x, y), nil
+	}
+	featureMu.RLock()
+	fn, ok := customFeatures[name]
+	featureMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown feature %q", name)
+	}
+	return fn, nil
+}
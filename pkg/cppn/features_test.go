@@ -0,0 +1,122 @@
+package cppn
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+func TestBuiltinFeatures(t *testing.T) {
+	cases := []struct {
+		name string
+		x, y float64
+		want float64
+	}{
+		{FeatureAngle, 0, 1, 0.5},
+		{FeatureAngle, -1, 0, 1},
+		{FeatureManhattan, -0.5, 0.25, 0.75},
+		{FeatureChebyshev, -0.5, 0.25, 0.5},
+		{AnchorFeature(0.5, -0.5), 0.5, 0.5, 1},
+		{FourierFeatures(2, 0)[0], 0.25, 0.9, 1},
+		{FourierFeatures(2, 0)[1], 0.5, 0.9, -1},
+	}
+	for _, tc := range cases {
+		fn, err := LookupFeature(tc.name)
+		if err != nil {
+			t.Fatalf("LookupFeature(%q) error: %v", tc.name, err)
+		}
+		if got := fn(tc.x, tc.y); math.Abs(got-tc.want) > 1e-12 {
+			t.Fatalf("%s(%v, %v) = %v, want %v", tc.name, tc.x, tc.y, got, tc.want)
+		}
+	}
+	if AnchorFeature(0.5, -0.25) != "anchor(0.5,-0.25)" {
+		t.Fatalf("unexpected anchor name %q", AnchorFeature(0.5, -0.25))
+	}
+	for _, bad := range []string{"nope", "anchor(1)", "anchor(a,b)", "spiral(1,2)"} {
+		if _, err := LookupFeature(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestRegisterFeature(t *testing.T) {
+	double := func(x, y float64) float64 { return 2 * x }
+	if err := RegisterFeature("test_double_x", double); err != nil {
+		t.Fatalf("RegisterFeature error: %v", err)
+	}
+	t.Cleanup(func() {
+		featureMu.Lock()
+		delete(customFeatures, "test_double_x")
+		featureMu.Unlock()
+	})
+	if err := RegisterFeature("test_double_x", double); err == nil {
+		t.Fatalf("expected error registering twice")
+	}
+	for _, bad := range []string{"", "x", "bias", FeatureAngle, "anchor", "a(b)"} {
+		if err := RegisterFeature(bad, double); err == nil {
+			t.Fatalf("expected error registering %q", bad)
+		}
+	}
+
+	spec := InputSpec{UseX: true, UseBias: true, Features: []string{"test_double_x", FeatureManhattan}}
+	in := make([]float64, spec.Count())
+	if err := spec.Fill(in, 0.5, -0.25); err != nil {
+		t.Fatalf("Fill error: %v", err)
+	}
+	if !reflect.DeepEqual(in, []float64{0.5, 1, 1, 0.75}) {
+		t.Fatalf("unexpected inputs %v", in)
+	}
+}
+
+func TestInputSpecGenomeLabels(t *testing.T) {
+	spec := InputSpec{
+		UseX: true, UseY: true, UseBias: true,
+		UseTime: true, LoopTime: true,
+		Features: append([]string{FeatureAngle, AnchorFeature(0, 0.5)}, FourierFeatures(3, 1)...),
+	}
+	want := []string{"x", "y", "bias", "time_sin", "time_cos", "angle", "anchor(0,0.5)", "sin(3,1)", "cos(3,1)"}
+	if !reflect.DeepEqual(spec.Names(), want) {
+		t.Fatalf("unexpected names %v", spec.Names())
+	}
+
+	tracker, err := neat.NewInnovationTracker(nil)
+	if err != nil {
+		t.Fatalf("NewInnovationTracker error: %v", err)
+	}
+	g, err := neat.NewMinimalGenome(spec.Count(), 1, neat.ActivationSigmoid, neat.NewRand(1), tracker, 1)
+	if err != nil {
+		t.Fatalf("NewMinimalGenome error: %v", err)
+	}
+	if err := spec.Label(&g); err != nil {
+		t.Fatalf("Label error: %v", err)
+	}
+	back, err := InputSpecFromGenome(g)
+	if err != nil {
+		t.Fatalf("InputSpecFromGenome error: %v", err)
+	}
+	if !reflect.DeepEqual(back, spec) {
+		t.Fatalf("spec did not round-trip: %+v", back)
+	}
+
+	plan, err := neat.BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	if _, err := Render(plan, 4, 4, spec, DefaultRenderConfig()); err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	swapped := spec
+	swapped.Features = []string{AnchorFeature(0, 0.5), FeatureAngle, "sin(3,1)", "cos(3,1)"}
+	if _, err := Render(plan, 4, 4, swapped, DefaultRenderConfig()); err == nil {
+		t.Fatalf("expected error when spec order disagrees with the genome")
+	}
+
+	if err := g.SetInputLabels([]string{"y", "x", "bias", "time_sin", "time_cos", "angle", "a", "b", "c"}); err != nil {
+		t.Fatalf("SetInputLabels error: %v", err)
+	}
+	if _, err := InputSpecFromGenome(g); err == nil {
+		t.Fatalf("expected error for out of order inputs")
+	}
+}
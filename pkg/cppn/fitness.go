@@ -11,6 +11,17 @@ type FitnessWeights struct {
 	Symmetry        float64
 	ColorVar        float64
 	HighFreqPenalty float64
+	// The remaining terms are off by default. SpectralSlope rewards slopes
+	// near -2, SpectralFlatness rewards low flatness, DominantFreq rewards a
+	// dominant frequency near a tenth of Nyquist, FractalDim rewards
+	// dimensions near 1.4, Colorfulness rewards colourful images in colour
+	// mode and Complexity rewards compression ratios near 0.35.
+	SpectralSlope    float64
+	SpectralFlatness float64
+	DominantFreq     float64
+	FractalDim       float64
+	Colorfulness     float64
+	Complexity       float64
 }
 
 // DefaultFitnessWeights provides a balanced exploratory mix.
@@ -26,7 +37,26 @@ func DefaultFitnessWeights() FitnessWeights {
 	}
 }
 
-// ScoreFromMetrics converts metrics into a fitness score.
+// Needs returns the optional metrics that the non-zero weights read.
+func (w FitnessWeights) Needs() MetricSet {
+	var set MetricSet
+	if w.SpectralSlope != 0 || w.SpectralFlatness != 0 || w.DominantFreq != 0 {
+		set |= MetricSpectral
+	}
+	if w.FractalDim != 0 {
+		set |= MetricFractal
+	}
+	if w.Colorfulness != 0 {
+		set |= MetricColorfulness
+	}
+	if w.Complexity != 0 {
+		set |= MetricComplexity
+	}
+	return set
+}
+
+// ScoreFromMetrics converts metrics into a fitness score. The optional
+// metrics that weights.Needs lists must have been computed.
 func ScoreFromMetrics(m Metrics, weights FitnessWeights, color bool) float64 {
 	entropyScore := clamp01(m.Entropy / 8.0)
 	varianceScore := clamp01(m.Variance / 0.25)
@@ -35,6 +65,13 @@ func ScoreFromMetrics(m Metrics, weights FitnessWeights, color bool) float64 {
 	symScore := clamp01((m.SymmetryX + m.SymmetryY) * 0.5)
 	colorScore := clamp01(m.ColorVar / 0.25)
 
+	slopeScore := targetScore(m.SpectralSlope, -2.0, 1.0)
+	structureScore := 1 - clamp01(m.SpectralFlatness)
+	dominantScore := targetScore(m.DominantFreq, 0.1, 0.1)
+	fractalScore := targetScore(m.FractalDim, 1.4, 0.4)
+	colorfulScore := clamp01(m.Colorfulness / 100.0)
+	complexityScore := targetScore(m.Complexity, 0.35, 0.25)
+
 	hfNorm := clamp01(m.HighFreq / 1.0)
 	hfPenalty := clamp01((hfNorm - 0.35) / 0.65)
 
@@ -42,9 +79,14 @@ func ScoreFromMetrics(m Metrics, weights FitnessWeights, color bool) float64 {
 		weights.EdgeDensity*edgeScore +
 		weights.FineEdges*fineEdgeScore +
 		weights.Variance*varianceScore +
-		weights.Symmetry*symScore
+		weights.Symmetry*symScore +
+		weights.SpectralSlope*slopeScore +
+		weights.SpectralFlatness*structureScore +
+		weights.DominantFreq*dominantScore +
+		weights.FractalDim*fractalScore +
+		weights.Complexity*complexityScore
 	if color {
-		score += weights.ColorVar * colorScore
+		score += weights.ColorVar*colorScore + weights.Colorfulness*colorfulScore
 	}
 	score -= weights.HighFreqPenalty * hfPenalty
 
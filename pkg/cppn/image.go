@@ -0,0 +1,309 @@
+package cppn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strconv"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+// RenderRGBA renders a CPPN into an *image.RGBA.
+func RenderRGBA(plan *neat.Plan, width, height int, spec InputSpec, cfg RenderConfig) (*image.RGBA, error) {
+	pixels, err := Render(plan, width, height, spec, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &image.RGBA{Pix: pixels, Stride: width * 4, Rect: image.Rect(0, 0, width, height)}, nil
+}
+
+// RenderNRGBA renders a CPPN into an *image.NRGBA. Renders are opaque, so
+// the pixels are the same as RenderRGBA's.
+func RenderNRGBA(plan *neat.Plan, width, height int, spec InputSpec, cfg RenderConfig) (*image.NRGBA, error) {
+	pixels, err := Render(plan, width, height, spec, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &image.NRGBA{Pix: pixels, Stride: width * 4, Rect: image.Rect(0, 0, width, height)}, nil
+}
+
+// Metadata is what an image needs to be rendered again.
+type Metadata struct {
+	Genome *neat.Genome `json:"genome,omitempty"`
+	Spec   InputSpec    `json:"input_spec"`
+	Seed   int64        `json:"seed"`
+	// Render is the render config, including viewport and sampling. Nil
+	// means DefaultRenderConfig.
+	Render *RenderConfig `json:"render_config,omitempty"`
+}
+
+// Config returns the render config to re-render the image with.
+func (m Metadata) Config() RenderConfig {
+	if m.Render == nil {
+		return DefaultRenderConfig()
+	}
+	return *m.Render
+}
+
+// Plan builds the genome's execution plan.
+func (m Metadata) Plan() (*neat.Plan, error) {
+	if m.Genome == nil {
+		return nil, fmt.Errorf("metadata has no genome")
+	}
+	return neat.BuildAcyclicPlan(*m.Genome, nil, nil)
+}
+
+// Metadata keys used for PNG tEXt chunks.
+const (
+	metaKeyGenome = "image-zoo:genome"
+	metaKeySpec   = "image-zoo:input-spec"
+	metaKeySeed   = "image-zoo:seed"
+	metaKeyRender = "image-zoo:render-config"
+	// jpegCommentPrefix starts the JPEG COM segment holding Metadata as JSON.
+	jpegCommentPrefix = "image-zoo:"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// maxPNGTextChunk bounds the tEXt chunks ReadMetadata will load, so a
+// crafted length cannot force a huge allocation.
+const maxPNGTextChunk = 16 << 20
+
+// EncodePNG writes img as PNG. A non-nil meta is stored as tEXt chunks
+// holding the genome JSON, the input spec JSON, the seed and the render
+// config JSON.
+func EncodePNG(w io.Writer, img image.Image, meta *Metadata) error {
+	if meta == nil {
+		return png.Encode(w, img)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	text, err := meta.textChunks()
+	if err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	// The IHDR chunk is always first: 8 byte signature, then 25 bytes.
+	ihdrEnd := len(pngSignature) + 25
+	if _, err := w.Write(data[:ihdrEnd]); err != nil {
+		return err
+	}
+	for _, kv := range text {
+		if err := writePNGChunk(w, "tEXt", append(append([]byte(kv[0]), 0), kv[1]...)); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(data[ihdrEnd:])
+	return err
+}
+
+func (m *Metadata) textChunks() ([][2]string, error) {
+	spec, err := json.Marshal(m.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var out [][2]string
+	if m.Genome != nil {
+		genome, err := json.Marshal(m.Genome)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, [2]string{metaKeyGenome, string(genome)})
+	}
+	out = append(out,
+		[2]string{metaKeySpec, string(spec)},
+		[2]string{metaKeySeed, strconv.FormatInt(m.Seed, 10)},
+	)
+	if m.Render != nil {
+		render, err := json.Marshal(m.Render)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, [2]string{metaKeyRender, string(render)})
+	}
+	return out, nil
+}
+
+func writePNGChunk(w io.Writer, kind string, data []byte) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], kind)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	var footer [4]byte
+	binary.BigEndian.PutUint32(footer[:], crc.Sum32())
+	for _, b := range [][]byte{header[:], data, footer[:]} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeJPEG writes img as JPEG at the given quality (1-100). A non-nil
+// meta is stored as JSON in a COM segment, which limits it to about 64KB.
+func EncodeJPEG(w io.Writer, img image.Image, quality int, meta *Metadata) error {
+	opts := &jpeg.Options{Quality: quality}
+	if meta == nil {
+		return jpeg.Encode(w, img, opts)
+	}
+	payload, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	comment := append([]byte(jpegCommentPrefix), payload...)
+	if len(comment) > 0xffff-2 {
+		return fmt.Errorf("metadata is %d bytes, too large for a JPEG comment", len(comment))
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, opts); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	// Insert the comment straight after the SOI marker.
+	if _, err := w.Write(data[:2]); err != nil {
+		return err
+	}
+	var header [4]byte
+	header[0], header[1] = 0xff, 0xfe
+	binary.BigEndian.PutUint16(header[2:], uint16(len(comment)+2))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(comment); err != nil {
+		return err
+	}
+	_, err = w.Write(data[2:])
+	return err
+}
+
+// ReadMetadata reads the metadata written by EncodePNG or EncodeJPEG without
+// decoding the image. It reports false if the image has none.
+func ReadMetadata(r io.Reader) (Metadata, bool, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(pngSignature))
+	if err != nil && len(head) < 2 {
+		return Metadata{}, false, fmt.Errorf("read image header: %w", err)
+	}
+	switch {
+	case bytes.Equal(head, pngSignature):
+		return readPNGMetadata(br)
+	case head[0] == 0xff && head[1] == 0xd8:
+		return readJPEGMetadata(br)
+	default:
+		return Metadata{}, false, fmt.Errorf("not a PNG or JPEG image")
+	}
+}
+
+func readPNGMetadata(r *bufio.Reader) (Metadata, bool, error) {
+	if _, err := r.Discard(len(pngSignature)); err != nil {
+		return Metadata{}, false, err
+	}
+	var meta Metadata
+	found := false
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return Metadata{}, false, fmt.Errorf("read png chunk: %w", err)
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		kind := string(header[4:])
+		if length > 1<<31-1 {
+			return Metadata{}, false, fmt.Errorf("invalid png chunk length %d", length)
+		}
+		if kind == "IDAT" || kind == "IEND" {
+			// Our chunks always precede the image data.
+			return meta, found, nil
+		}
+		if kind != "tEXt" {
+			if _, err := r.Discard(int(length) + 4); err != nil {
+				return Metadata{}, false, fmt.Errorf("read png chunk: %w", err)
+			}
+			continue
+		}
+		if length > maxPNGTextChunk {
+			return Metadata{}, false, fmt.Errorf("png text chunk of %d bytes exceeds %d", length, maxPNGTextChunk)
+		}
+		data := make([]byte, length+4)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return Metadata{}, false, fmt.Errorf("read png chunk: %w", err)
+		}
+		key, value, ok := bytes.Cut(data[:length], []byte{0})
+		if !ok {
+			continue
+		}
+		switch string(key) {
+		case metaKeyGenome:
+			var g neat.Genome
+			if err := json.Unmarshal(value, &g); err != nil {
+				return Metadata{}, false, fmt.Errorf("decode genome metadata: %w", err)
+			}
+			meta.Genome = &g
+		case metaKeySpec:
+			if err := json.Unmarshal(value, &meta.Spec); err != nil {
+				return Metadata{}, false, fmt.Errorf("decode input spec metadata: %w", err)
+			}
+		case metaKeySeed:
+			seed, err := strconv.ParseInt(string(value), 10, 64)
+			if err != nil {
+				return Metadata{}, false, fmt.Errorf("decode seed metadata: %w", err)
+			}
+			meta.Seed = seed
+		case metaKeyRender:
+			var cfg RenderConfig
+			if err := json.Unmarshal(value, &cfg); err != nil {
+				return Metadata{}, false, fmt.Errorf("decode render config metadata: %w", err)
+			}
+			meta.Render = &cfg
+		default:
+			continue
+		}
+		found = true
+	}
+}
+
+func readJPEGMetadata(r *bufio.Reader) (Metadata, bool, error) {
+	if _, err := r.Discard(2); err != nil {
+		return Metadata{}, false, err
+	}
+	for {
+		var marker [4]byte
+		if _, err := io.ReadFull(r, marker[:]); err != nil {
+			return Metadata{}, false, fmt.Errorf("read jpeg segment: %w", err)
+		}
+		if marker[0] != 0xff {
+			return Metadata{}, false, fmt.Errorf("invalid jpeg marker %x", marker[:2])
+		}
+		// Start of scan: the rest is entropy-coded image data.
+		if marker[1] == 0xda {
+			return Metadata{}, false, nil
+		}
+		length := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if length < 0 {
+			return Metadata{}, false, fmt.Errorf("invalid jpeg segment length")
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return Metadata{}, false, fmt.Errorf("read jpeg segment: %w", err)
+		}
+		if marker[1] != 0xfe || !bytes.HasPrefix(data, []byte(jpegCommentPrefix)) {
+			continue
+		}
+		var meta Metadata
+		if err := json.Unmarshal(data[len(jpegCommentPrefix):], &meta); err != nil {
+			return Metadata{}, false, fmt.Errorf("decode jpeg metadata: %w", err)
+		}
+		return meta, true, nil
+	}
+}
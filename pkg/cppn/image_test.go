@@ -0,0 +1,170 @@
+package cppn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+func imageTestGenome() neat.Genome {
+	return neat.Genome{
+		ID: 7,
+		Nodes: []neat.NodeGene{
+			{ID: 1, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 2, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 3, Kind: neat.NodeOutput, Activation: neat.ActivationSin, Bias: 0.2},
+		},
+		Connections: []neat.ConnectionGene{
+			{Innovation: 1, In: 1, Out: 3, Weight: 3, Enabled: true},
+			{Innovation: 2, In: 2, Out: 3, Weight: -2, Enabled: true},
+		},
+	}
+}
+
+func TestRenderRGBAMatchesRender(t *testing.T) {
+	spec := InputSpec{UseX: true, UseY: true}
+	plan, err := neat.BuildAcyclicPlan(imageTestGenome(), nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	want, err := Render(plan, 8, 4, spec, DefaultRenderConfig())
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	rgba, err := RenderRGBA(plan, 8, 4, spec, DefaultRenderConfig())
+	if err != nil {
+		t.Fatalf("RenderRGBA error: %v", err)
+	}
+	nrgba, err := RenderNRGBA(plan, 8, 4, spec, DefaultRenderConfig())
+	if err != nil {
+		t.Fatalf("RenderNRGBA error: %v", err)
+	}
+	if !bytes.Equal(rgba.Pix, want) || !bytes.Equal(nrgba.Pix, want) {
+		t.Fatalf("image pixels differ from Render")
+	}
+	if rgba.Bounds().Dx() != 8 || rgba.Bounds().Dy() != 4 {
+		t.Fatalf("unexpected bounds %v", rgba.Bounds())
+	}
+}
+
+func TestPNGMetadataRoundTrip(t *testing.T) {
+	spec := InputSpec{UseX: true, UseY: true}
+	g := imageTestGenome()
+	plan, err := neat.BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	// A zoomed, rotated and jittered render only comes back with its config.
+	cfg := DefaultRenderConfig()
+	cfg.Viewport = Viewport{CenterX: 0.3, Scale: 0.5, Rotation: 0.4, Aspect: AspectFit}
+	cfg.Samples = 2
+	cfg.Pattern = SampleJitter
+	cfg.Filter = FilterGaussian
+	cfg.Seed = 9
+	img, err := RenderNRGBA(plan, 16, 16, spec, cfg)
+	if err != nil {
+		t.Fatalf("RenderNRGBA error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := EncodePNG(&buf, img, &Metadata{Genome: &g, Spec: spec, Seed: 42, Render: &cfg}); err != nil {
+		t.Fatalf("EncodePNG error: %v", err)
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("decode png with metadata: %v", err)
+	}
+	if decoded.Bounds() != img.Bounds() {
+		t.Fatalf("unexpected decoded bounds %v", decoded.Bounds())
+	}
+
+	meta, ok, err := ReadMetadata(bytes.NewReader(buf.Bytes()))
+	if err != nil || !ok {
+		t.Fatalf("ReadMetadata: ok=%v err=%v", ok, err)
+	}
+	if meta.Seed != 42 || !reflect.DeepEqual(meta.Spec, spec) || !reflect.DeepEqual(meta.Config(), cfg) {
+		t.Fatalf("unexpected metadata %+v", meta)
+	}
+	if !reflect.DeepEqual(*meta.Genome, g) {
+		t.Fatalf("genome did not round-trip: %+v", *meta.Genome)
+	}
+
+	replan, err := meta.Plan()
+	if err != nil {
+		t.Fatalf("Plan error: %v", err)
+	}
+	again, err := RenderNRGBA(replan, 16, 16, meta.Spec, meta.Config())
+	if err != nil {
+		t.Fatalf("RenderNRGBA error: %v", err)
+	}
+	if !bytes.Equal(again.Pix, img.Pix) {
+		t.Fatalf("re-rendered image differs")
+	}
+}
+
+func TestJPEGMetadataRoundTrip(t *testing.T) {
+	g := imageTestGenome()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	var buf bytes.Buffer
+	cfg := RenderConfig{ColorModel: ColorPalette, Palette: DefaultGradient(), Samples: 3, Viewport: Viewport{Scale: 2}}
+	if err := EncodeJPEG(&buf, img, 90, &Metadata{Genome: &g, Spec: DefaultInputSpec(), Seed: -3, Render: &cfg}); err != nil {
+		t.Fatalf("EncodeJPEG error: %v", err)
+	}
+	if _, _, err := image.Decode(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("decode jpeg with metadata: %v", err)
+	}
+	meta, ok, err := ReadMetadata(&buf)
+	if err != nil || !ok {
+		t.Fatalf("ReadMetadata: ok=%v err=%v", ok, err)
+	}
+	if meta.Seed != -3 || !reflect.DeepEqual(meta.Spec, DefaultInputSpec()) || meta.Genome == nil || meta.Genome.ID != 7 {
+		t.Fatalf("unexpected metadata %+v", meta)
+	}
+	if !reflect.DeepEqual(meta.Config(), cfg) {
+		t.Fatalf("render config did not round-trip: %+v", meta.Config())
+	}
+	if !reflect.DeepEqual((Metadata{}).Config(), DefaultRenderConfig()) {
+		t.Fatalf("expected the default render config without one stored")
+	}
+}
+
+func TestReadMetadataWithoutMetadata(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	var pngBuf, jpegBuf bytes.Buffer
+	if err := EncodePNG(&pngBuf, img, nil); err != nil {
+		t.Fatalf("EncodePNG error: %v", err)
+	}
+	if err := EncodeJPEG(&jpegBuf, img, 80, nil); err != nil {
+		t.Fatalf("EncodeJPEG error: %v", err)
+	}
+	for name, buf := range map[string]*bytes.Buffer{"png": &pngBuf, "jpeg": &jpegBuf} {
+		if _, ok, err := ReadMetadata(buf); err != nil || ok {
+			t.Fatalf("%s: expected no metadata, ok=%v err=%v", name, ok, err)
+		}
+	}
+	if _, _, err := ReadMetadata(bytes.NewReader([]byte("GIF89a..."))); err == nil {
+		t.Fatalf("expected error for unsupported format")
+	}
+}
+
+func TestReadMetadataRejectsOversizedTextChunk(t *testing.T) {
+	for _, length := range []uint32{maxPNGTextChunk + 1, 0xfffffff0} {
+		data := append([]byte(nil), pngSignature...)
+		data = binary.BigEndian.AppendUint32(data, length)
+		data = append(data, "tEXt"...)
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		if _, _, err := ReadMetadata(bytes.NewReader(data)); err == nil {
+			t.Fatalf("expected error for a %d byte text chunk", length)
+		}
+		runtime.ReadMemStats(&after)
+		if grown := after.TotalAlloc - before.TotalAlloc; grown > 1<<20 {
+			t.Fatalf("a %d byte text chunk allocated %d bytes", length, grown)
+		}
+	}
+}
@@ -3,14 +3,37 @@ package cppn
 import (
 	"fmt"
 	"math"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+// Input names, used as genome input labels.
+const (
+	inputX       = "x"
+	inputY       = "y"
+	inputRadius  = "radius"
+	inputBias    = "bias"
+	inputTime    = "time"
+	inputTimeSin = "time_sin"
+	inputTimeCos = "time_cos"
+	inputZ       = "z"
+	inputDist    = "distance"
 )
 
 // InputSpec controls which CPPN inputs are provided and in what order.
 type InputSpec struct {
-	UseX      bool
-	UseY      bool
-	UseRadius bool
-	UseBias   bool
+	UseX      bool `json:"use_x"`
+	UseY      bool `json:"use_y"`
+	UseRadius bool `json:"use_radius"`
+	UseBias   bool `json:"use_bias"`
+	// UseTime adds an animation time input after bias: 2t-1 for t in [0,1),
+	// or with LoopTime the pair sin(2πt), cos(2πt) so the last frame flows
+	// back into the first.
+	UseTime  bool `json:"use_time,omitempty"`
+	LoopTime bool `json:"loop_time,omitempty"`
+	// Features names extra coordinate inputs, appended in order after the
+	// others; see LookupFeature.
+	Features []string `json:"features,omitempty"`
 }
 
 // DefaultInputSpec returns the standard CPPN input configuration.
@@ -38,11 +61,139 @@ func (s InputSpec) Count() int {
 	if s.UseBias {
 		count++
 	}
-	return count
+	if s.UseTime {
+		count++
+		if s.LoopTime {
+			count++
+		}
+	}
+	return count + len(s.Features)
+}
+
+// Names returns the name of each input in order.
+func (s InputSpec) Names() []string {
+	names := make([]string, 0, s.Count())
+	for _, in := range []struct {
+		use  bool
+		name string
+	}{
+		{s.UseX, inputX},
+		{s.UseY, inputY},
+		{s.UseRadius, inputRadius},
+		{s.UseBias, inputBias},
+	} {
+		if in.use {
+			names = append(names, in.name)
+		}
+	}
+	if s.UseTime {
+		if s.LoopTime {
+			names = append(names, inputTimeSin, inputTimeCos)
+		} else {
+			names = append(names, inputTime)
+		}
+	}
+	return append(names, s.Features...)
+}
+
+// Label stores the input names on g's input nodes, so the genome records
+// which spec it was evolved with.
+func (s InputSpec) Label(g *neat.Genome) error {
+	return g.SetInputLabels(s.Names())
+}
+
+// Check reports whether plan takes the inputs of s. Unlabelled plan inputs
+// are only checked by count.
+func (s InputSpec) Check(plan *neat.Plan) error {
+	return checkInputs(s.Names(), plan)
+}
+
+// checkInputs compares input names with a plan's input count and labels.
+func checkInputs(names []string, plan *neat.Plan) error {
+	if len(names) != len(plan.Inputs) {
+		return fmt.Errorf("input spec count %d does not match plan inputs %d", len(names), len(plan.Inputs))
+	}
+	for i, name := range names {
+		if i < len(plan.InputLabels) && plan.InputLabels[i] != "" && plan.InputLabels[i] != name {
+			return fmt.Errorf("input %d is %q in the spec but %q in the genome", i, name, plan.InputLabels[i])
+		}
+	}
+	return nil
+}
+
+// InputSpecFromGenome rebuilds the spec a genome was labelled with.
+func InputSpecFromGenome(g neat.Genome) (InputSpec, error) {
+	var s InputSpec
+	labels := g.InputLabels()
+	i := 0
+	next := func(name string) bool {
+		if i < len(labels) && labels[i] == name {
+			i++
+			return true
+		}
+		return false
+	}
+	s.UseX = next(inputX)
+	s.UseY = next(inputY)
+	s.UseRadius = next(inputRadius)
+	s.UseBias = next(inputBias)
+	switch {
+	case next(inputTime):
+		s.UseTime = true
+	case next(inputTimeSin):
+		if !next(inputTimeCos) {
+			return InputSpec{}, fmt.Errorf("input %q must be followed by %q", inputTimeSin, inputTimeCos)
+		}
+		s.UseTime, s.LoopTime = true, true
+	}
+	for _, name := range labels[i:] {
+		if name == "" {
+			return InputSpec{}, fmt.Errorf("genome has unlabelled inputs")
+		}
+		if reservedFeature[name] {
+			return InputSpec{}, fmt.Errorf("input %q is out of order", name)
+		}
+		if _, err := LookupFeature(name); err != nil {
+			return InputSpec{}, err
+		}
+		s.Features = append(s.Features, name)
+	}
+	return s, nil
 }
 
 // Fill populates dst with inputs derived from x and y in the configured order.
+// The time input, if any, is fed t=0.
 func (s InputSpec) Fill(dst []float64, x, y float64) error {
+	return s.FillAt(dst, x, y, 0)
+}
+
+// FillAt is Fill at animation time t in [0,1).
+func (s InputSpec) FillAt(dst []float64, x, y, t float64) error {
+	features, err := s.resolveFeatures()
+	if err != nil {
+		return err
+	}
+	return s.fill(dst, features, x, y, t)
+}
+
+// resolveFeatures looks up the functions for s.Features.
+func (s InputSpec) resolveFeatures() ([]FeatureFunc, error) {
+	if len(s.Features) == 0 {
+		return nil, nil
+	}
+	out := make([]FeatureFunc, len(s.Features))
+	for i, name := range s.Features {
+		fn, err := LookupFeature(name)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = fn
+	}
+	return out, nil
+}
+
+// fill is FillAt with the features already resolved.
+func (s InputSpec) fill(dst []float64, features []FeatureFunc, x, y, t float64) error {
 	if len(dst) != s.Count() {
 		return fmt.Errorf("input length %d does not match spec %d", len(dst), s.Count())
 	}
@@ -63,6 +214,20 @@ func (s InputSpec) Fill(dst []float64, x, y float64) error {
 		dst[idx] = 1.0
 		idx++
 	}
+	if s.UseTime {
+		if s.LoopTime {
+			dst[idx] = math.Sin(2 * math.Pi * t)
+			dst[idx+1] = math.Cos(2 * math.Pi * t)
+			idx += 2
+		} else {
+			dst[idx] = 2*t - 1
+			idx++
+		}
+	}
+	for _, fn := range features {
+		dst[idx] = fn(x, y)
+		idx++
+	}
 	return nil
 }
 
@@ -73,3 +238,12 @@ func Coord(pos, size int) float64 {
 	}
 	return (float64(pos)/float64(size-1))*2 - 1
 }
+
+// coordAt is Coord at a fractional pixel position. A one-pixel axis spans
+// [-1, 1] across its pixel.
+func coordAt(pos float64, size int) float64 {
+	if size <= 1 {
+		return pos * 2
+	}
+	return (pos/float64(size-1))*2 - 1
+}
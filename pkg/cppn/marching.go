@@ -0,0 +1,234 @@
+package cppn
+
+import "math/bits"
+
+// Mesh is an indexed triangle mesh. Triangles wind counter-clockwise seen
+// from outside the solid.
+type Mesh struct {
+	Vertices [][3]float64
+	// Colors holds a red, green and blue colour in [0,1] per vertex, or is
+	// nil.
+	Colors    [][3]float64
+	Triangles [][3]int
+}
+
+// Cube corner c sits at offset (c&1, c>>1&1, c>>2&1) from the cell origin.
+// cubeEdges joins corners that differ in one bit, lower corner first.
+var cubeEdges = func() [][2]int {
+	var edges [][2]int
+	for c := 0; c < 8; c++ {
+		for bit := 0; bit < 3; bit++ {
+			if c&(1<<bit) == 0 {
+				edges = append(edges, [2]int{c, c | 1<<bit})
+			}
+		}
+	}
+	return edges
+}()
+
+// mcTriangles lists, for each mask of solid corners, triangles as triples
+// of cubeEdges indices.
+var mcTriangles = buildMarchingCubesTable()
+
+// buildMarchingCubesTable derives the marching cubes table instead of
+// transcribing the classic one. For each case it draws the iso-contour on
+// every cube face, chains the face segments into closed loops and fans each
+// loop into triangles. On faces where solid corners sit diagonally the
+// contour always separates them; the rule depends only on the face, so
+// neighbouring cells agree and meshes are watertight. Each loop is wound so
+// its normal points from solid to empty corners, and fanned from a vertex
+// whose diagonals leave every face: a triangle lying in a face would be
+// emitted by both cells sharing it.
+func buildMarchingCubesTable() [256][][3]int {
+	edgeOf := map[[2]int]int{}
+	for i, e := range cubeEdges {
+		edgeOf[e] = i
+		edgeOf[[2]int{e[1], e[0]}] = i
+	}
+	// onFace reports whether edges a and b lie in a common cube face.
+	onFace := func(a, b int) bool {
+		ea, eb := cubeEdges[a], cubeEdges[b]
+		for bit := 0; bit < 3; bit++ {
+			side := ea[0] >> bit & 1
+			if ea[1]>>bit&1 == side && eb[0]>>bit&1 == side && eb[1]>>bit&1 == side {
+				return true
+			}
+		}
+		return false
+	}
+	corner := func(c int) [3]float64 {
+		return [3]float64{float64(c & 1), float64(c >> 1 & 1), float64(c >> 2 & 1)}
+	}
+	// Faces list their corners in cyclic order.
+	var faces [][4]int
+	for axis := 0; axis < 3; axis++ {
+		a, b := 1<<((axis+1)%3), 1<<((axis+2)%3)
+		for side := 0; side < 2; side++ {
+			base := side << axis
+			faces = append(faces, [4]int{base, base | a, base | a | b, base | b})
+		}
+	}
+
+	var table [256][][3]int
+	for mask := 1; mask < 255; mask++ {
+		solid := func(c int) bool { return mask&(1<<c) != 0 }
+		links := map[int][]int{}
+		link := func(e1, e2 int) {
+			links[e1] = append(links[e1], e2)
+			links[e2] = append(links[e2], e1)
+		}
+		for _, f := range faces {
+			var crossing []int
+			for k := 0; k < 4; k++ {
+				if solid(f[k]) != solid(f[(k+1)%4]) {
+					crossing = append(crossing, edgeOf[[2]int{f[k], f[(k+1)%4]}])
+				}
+			}
+			switch len(crossing) {
+			case 2:
+				link(crossing[0], crossing[1])
+			case 4:
+				for k := 0; k < 4; k++ {
+					if solid(f[k]) {
+						link(edgeOf[[2]int{f[(k+3)%4], f[k]}], edgeOf[[2]int{f[k], f[(k+1)%4]}])
+					}
+				}
+			}
+		}
+
+		visited := map[int]bool{}
+		for e := range cubeEdges {
+			if visited[e] || len(links[e]) == 0 {
+				continue
+			}
+			loop := []int{e}
+			visited[e] = true
+			for prev, cur := -1, e; ; {
+				next := links[cur][0]
+				if next == prev {
+					next = links[cur][1]
+				}
+				if next == e {
+					break
+				}
+				loop = append(loop, next)
+				visited[next] = true
+				prev, cur = cur, next
+			}
+
+			// Newell's normal of the loop through edge midpoints, against
+			// the solid-to-empty direction along its edges.
+			mid := func(e int) [3]float64 {
+				p, q := corner(cubeEdges[e][0]), corner(cubeEdges[e][1])
+				return [3]float64{(p[0] + q[0]) / 2, (p[1] + q[1]) / 2, (p[2] + q[2]) / 2}
+			}
+			var normal, outward [3]float64
+			for i, e := range loop {
+				p, q := mid(e), mid(loop[(i+1)%len(loop)])
+				normal[0] += (p[1] - q[1]) * (p[2] + q[2])
+				normal[1] += (p[2] - q[2]) * (p[0] + q[0])
+				normal[2] += (p[0] - q[0]) * (p[1] + q[1])
+				in, out := corner(cubeEdges[e][0]), corner(cubeEdges[e][1])
+				if !solid(cubeEdges[e][0]) {
+					in, out = out, in
+				}
+				for c := range outward {
+					outward[c] += out[c] - in[c]
+				}
+			}
+			if normal[0]*outward[0]+normal[1]*outward[1]+normal[2]*outward[2] < 0 {
+				for i, j := 0, len(loop)-1; i < j; i, j = i+1, j-1 {
+					loop[i], loop[j] = loop[j], loop[i]
+				}
+			}
+			n := len(loop)
+			start := 0
+			for s := 0; s < n; s++ {
+				ok := true
+				for i := 2; i <= n-2; i++ {
+					if onFace(loop[s], loop[(s+i)%n]) {
+						ok = false
+					}
+				}
+				if ok {
+					start = s
+					break
+				}
+			}
+			for i := 1; i+1 < n; i++ {
+				table[mask] = append(table[mask], [3]int{loop[start], loop[(start+i)%n], loop[(start+i+1)%n]})
+			}
+		}
+	}
+	return table
+}
+
+// MarchingCubes extracts the surface where v's density crosses iso. The
+// volume is treated as empty beyond its grid, so the mesh is always closed.
+// Vertex colours are interpolated from v.Colors when present.
+func MarchingCubes(v *Volume, iso float64) *Mesh {
+	n := v.Size
+	mesh := &Mesh{}
+	// Vertices are shared through the grid edge they lie on, keyed by the
+	// lower grid point (offset by one for the padding layer) and axis.
+	span := n + 2
+	vertexOf := map[int]int{}
+	vertex := func(i, j, k, axis int) int {
+		key := (((k+1)*span+(j+1))*span+(i+1))*3 + axis
+		if idx, ok := vertexOf[key]; ok {
+			return idx
+		}
+		i2, j2, k2 := i, j, k
+		switch axis {
+		case 0:
+			i2++
+		case 1:
+			j2++
+		default:
+			k2++
+		}
+		d1, d2 := v.at(i, j, k), v.at(i2, j2, k2)
+		t := (iso - d1) / (d2 - d1)
+		p1 := [3]float64{coordAt(float64(i), n), coordAt(float64(j), n), coordAt(float64(k), n)}
+		p2 := [3]float64{coordAt(float64(i2), n), coordAt(float64(j2), n), coordAt(float64(k2), n)}
+		var pos [3]float64
+		for c := range pos {
+			pos[c] = p1[c] + t*(p2[c]-p1[c])
+		}
+		idx := len(mesh.Vertices)
+		mesh.Vertices = append(mesh.Vertices, pos)
+		if v.Colors != nil {
+			c1, c2 := v.color(i, j, k), v.color(i2, j2, k2)
+			var col [3]float64
+			for c := range col {
+				col[c] = c1[c] + t*(c2[c]-c1[c])
+			}
+			mesh.Colors = append(mesh.Colors, col)
+		}
+		vertexOf[key] = idx
+		return idx
+	}
+
+	for k := -1; k < n; k++ {
+		for j := -1; j < n; j++ {
+			for i := -1; i < n; i++ {
+				mask := 0
+				for c := 0; c < 8; c++ {
+					if v.at(i+c&1, j+c>>1&1, k+c>>2&1) >= iso {
+						mask |= 1 << c
+					}
+				}
+				for _, tri := range mcTriangles[mask] {
+					var t [3]int
+					for x, e := range tri {
+						c := cubeEdges[e][0]
+						axis := bits.TrailingZeros(uint(cubeEdges[e][1] - c))
+						t[x] = vertex(i+c&1, j+c>>1&1, k+c>>2&1, axis)
+					}
+					mesh.Triangles = append(mesh.Triangles, t)
+				}
+			}
+		}
+	}
+	return mesh
+}
@@ -0,0 +1,107 @@
+package cppn
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// densityVolume samples fn over a size³ grid.
+func densityVolume(size int, fn func(x, y, z float64) float64) *Volume {
+	v := &Volume{Size: size, Density: make([]float64, size*size*size)}
+	for k := 0; k < size; k++ {
+		for j := 0; j < size; j++ {
+			for i := 0; i < size; i++ {
+				v.Density[(k*size+j)*size+i] = fn(Coord(i, size), Coord(j, size), Coord(k, size))
+			}
+		}
+	}
+	return v
+}
+
+func ballDensity(cx, cy, cz, r float64) func(x, y, z float64) float64 {
+	return func(x, y, z float64) float64 {
+		d := math.Sqrt((x-cx)*(x-cx) + (y-cy)*(y-cy) + (z-cz)*(z-cz))
+		return clamp01(0.5 + (r-d)*2)
+	}
+}
+
+// checkClosed fails unless every directed edge is matched by its reverse
+// exactly once, which means the mesh is closed and consistently wound.
+func checkClosed(t *testing.T, m *Mesh) {
+	t.Helper()
+	edges := map[[2]int]int{}
+	for _, tri := range m.Triangles {
+		for e := 0; e < 3; e++ {
+			edges[[2]int{tri[e], tri[(e+1)%3]}]++
+		}
+	}
+	for e, n := range edges {
+		if n != 1 || edges[[2]int{e[1], e[0]}] != 1 {
+			t.Fatalf("edge %v used %d times, reverse %d times", e, n, edges[[2]int{e[1], e[0]}])
+		}
+	}
+}
+
+// signedVolume is positive when triangles wind outward.
+func signedVolume(m *Mesh) float64 {
+	vol := 0.0
+	for _, tri := range m.Triangles {
+		a, b, c := m.Vertices[tri[0]], m.Vertices[tri[1]], m.Vertices[tri[2]]
+		vol += (a[0]*(b[1]*c[2]-b[2]*c[1]) - a[1]*(b[0]*c[2]-b[2]*c[0]) + a[2]*(b[0]*c[1]-b[1]*c[0])) / 6
+	}
+	return vol
+}
+
+func TestMarchingCubesTable(t *testing.T) {
+	if len(cubeEdges) != 12 {
+		t.Fatalf("expected 12 cube edges, got %d", len(cubeEdges))
+	}
+	if len(mcTriangles[0]) != 0 || len(mcTriangles[255]) != 0 {
+		t.Fatalf("expected no triangles for empty and full cells")
+	}
+	if len(mcTriangles[1]) != 1 || len(mcTriangles[0b0011]) != 2 {
+		t.Fatalf("unexpected triangle counts %d and %d", len(mcTriangles[1]), len(mcTriangles[3]))
+	}
+	for mask := 1; mask < 255; mask++ {
+		if len(mcTriangles[mask]) == 0 {
+			t.Fatalf("case %08b has no triangles", mask)
+		}
+	}
+}
+
+func TestMarchingCubesBall(t *testing.T) {
+	r := 0.6
+	m := MarchingCubes(densityVolume(40, ballDensity(0, 0, 0, r)), DefaultIso)
+	checkClosed(t, m)
+	wantVol := 4.0 / 3 * math.Pi * r * r * r
+	if vol := signedVolume(m); math.Abs(vol-wantVol)/wantVol > 0.03 {
+		t.Fatalf("ball volume %v, want about %v", vol, wantVol)
+	}
+	wantArea := 4 * math.Pi * r * r
+	if area := m.Area(); math.Abs(area-wantArea)/wantArea > 0.05 {
+		t.Fatalf("ball area %v, want about %v", area, wantArea)
+	}
+}
+
+func TestMarchingCubesClosedOnNoise(t *testing.T) {
+	// Random densities reach every case, including the ambiguous ones.
+	for seed := int64(1); seed <= 5; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		v := densityVolume(9, func(x, y, z float64) float64 { return rng.Float64() })
+		m := MarchingCubes(v, DefaultIso)
+		checkClosed(t, m)
+		if vol := signedVolume(m); vol <= 0 {
+			t.Fatalf("seed %d: expected outward winding, signed volume %v", seed, vol)
+		}
+	}
+}
+
+func TestMarchingCubesClosesAtBoundary(t *testing.T) {
+	full := densityVolume(4, func(x, y, z float64) float64 { return 1 })
+	m := MarchingCubes(full, DefaultIso)
+	checkClosed(t, m)
+	if vol := signedVolume(m); vol < 8 {
+		t.Fatalf("expected the closed solid to cover the grid, volume %v", vol)
+	}
+}
@@ -0,0 +1,85 @@
+package cppn
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Area returns the total triangle area.
+func (m *Mesh) Area() float64 {
+	area := 0.0
+	for _, t := range m.Triangles {
+		n := m.normal(t)
+		area += math.Sqrt(n[0]*n[0]+n[1]*n[1]+n[2]*n[2]) / 2
+	}
+	return area
+}
+
+// normal returns the cross product of a triangle's edges, whose length is
+// twice its area.
+func (m *Mesh) normal(t [3]int) [3]float64 {
+	a, b, c := m.Vertices[t[0]], m.Vertices[t[1]], m.Vertices[t[2]]
+	u := [3]float64{b[0] - a[0], b[1] - a[1], b[2] - a[2]}
+	v := [3]float64{c[0] - a[0], c[1] - a[1], c[2] - a[2]}
+	return [3]float64{u[1]*v[2] - u[2]*v[1], u[2]*v[0] - u[0]*v[2], u[0]*v[1] - u[1]*v[0]}
+}
+
+// WriteOBJ writes the mesh as Wavefront OBJ. Vertex colours, if any, follow
+// each vertex position as "v x y z r g b", which most mesh tools read.
+func (m *Mesh) WriteOBJ(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# image-zoo volume: %d vertices, %d triangles\n", len(m.Vertices), len(m.Triangles))
+	for i, p := range m.Vertices {
+		if m.Colors != nil {
+			c := m.Colors[i]
+			fmt.Fprintf(bw, "v %.6f %.6f %.6f %.4f %.4f %.4f\n", p[0], p[1], p[2], c[0], c[1], c[2])
+		} else {
+			fmt.Fprintf(bw, "v %.6f %.6f %.6f\n", p[0], p[1], p[2])
+		}
+	}
+	for _, t := range m.Triangles {
+		fmt.Fprintf(bw, "f %d %d %d\n", t[0]+1, t[1]+1, t[2]+1)
+	}
+	return bw.Flush()
+}
+
+// WriteSTL writes the mesh as binary STL. STL has no standard vertex colour,
+// so colours are dropped.
+func (m *Mesh) WriteSTL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	var header [80]byte
+	copy(header[:], "image-zoo volume")
+	if _, err := bw.Write(header[:]); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, uint32(len(m.Triangles))); err != nil {
+		return err
+	}
+	var rec [50]byte
+	put := func(off int, v float64) {
+		binary.LittleEndian.PutUint32(rec[off:], math.Float32bits(float32(v)))
+	}
+	for _, t := range m.Triangles {
+		n := m.normal(t)
+		if l := math.Sqrt(n[0]*n[0] + n[1]*n[1] + n[2]*n[2]); l > 0 {
+			n = [3]float64{n[0] / l, n[1] / l, n[2] / l}
+		}
+		for c := 0; c < 3; c++ {
+			put(4*c, n[c])
+		}
+		for v := 0; v < 3; v++ {
+			p := m.Vertices[t[v]]
+			for c := 0; c < 3; c++ {
+				put(12+12*v+4*c, p[c])
+			}
+		}
+		// The final two bytes are the unused attribute count.
+		if _, err := bw.Write(rec[:]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,78 @@
+package cppn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+func TestWriteOBJ(t *testing.T) {
+	g, spec := ballGenome()
+	plan, err := neat.BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	v, err := SampleVolume(plan, 10, spec, DefaultRenderConfig())
+	if err != nil {
+		t.Fatalf("SampleVolume error: %v", err)
+	}
+	m := MarchingCubes(v, DefaultIso)
+	if len(m.Colors) != len(m.Vertices) {
+		t.Fatalf("expected a colour per vertex, got %d for %d", len(m.Colors), len(m.Vertices))
+	}
+	var buf bytes.Buffer
+	if err := m.WriteOBJ(&buf); err != nil {
+		t.Fatalf("WriteOBJ error: %v", err)
+	}
+	verts, faces := 0, 0
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		switch fields[0] {
+		case "v":
+			verts++
+			if len(fields) != 7 || fields[4] != "1.0000" || fields[5] != "0.0000" {
+				t.Fatalf("unexpected vertex line %q", sc.Text())
+			}
+		case "f":
+			faces++
+			if fields[1] == "0" {
+				t.Fatalf("expected 1-based indices, got %q", sc.Text())
+			}
+		}
+	}
+	if verts != len(m.Vertices) || faces != len(m.Triangles) {
+		t.Fatalf("expected %d vertices and %d faces, got %d and %d", len(m.Vertices), len(m.Triangles), verts, faces)
+	}
+}
+
+func TestWriteSTL(t *testing.T) {
+	m := MarchingCubes(densityVolume(8, ballDensity(0, 0, 0, 0.5)), DefaultIso)
+	var buf bytes.Buffer
+	if err := m.WriteSTL(&buf); err != nil {
+		t.Fatalf("WriteSTL error: %v", err)
+	}
+	data := buf.Bytes()
+	if len(data) != 84+50*len(m.Triangles) {
+		t.Fatalf("unexpected STL size %d for %d triangles", len(data), len(m.Triangles))
+	}
+	if n := binary.LittleEndian.Uint32(data[80:]); int(n) != len(m.Triangles) {
+		t.Fatalf("header counts %d triangles, want %d", n, len(m.Triangles))
+	}
+	// The first normal is unit length and points away from the centre.
+	var rec [12]float64
+	for i := range rec {
+		rec[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(data[84+4*i:])))
+	}
+	if l := math.Sqrt(rec[0]*rec[0] + rec[1]*rec[1] + rec[2]*rec[2]); math.Abs(l-1) > 1e-5 {
+		t.Fatalf("expected a unit normal, got length %v", l)
+	}
+	if rec[0]*rec[3]+rec[1]*rec[4]+rec[2]*rec[5] <= 0 {
+		t.Fatalf("expected an outward normal")
+	}
+}
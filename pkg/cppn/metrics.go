@@ -13,9 +13,77 @@ type Metrics struct {
 	SymmetryY   float64
 	HighFreq    float64
 	ColorVar    float64
+	// SpectralSlope is the log-log slope of the radial power spectrum; about
+	// -2 for natural images and 0 for white noise.
+	SpectralSlope float64
+	// SpectralFlatness is in [0,1]; near 1 for noise, near 0 for images
+	// dominated by a few frequencies.
+	SpectralFlatness float64
+	// DominantFreq is the strongest radial frequency as a fraction of
+	// Nyquist.
+	DominantFreq float64
+	// FractalDim is the box-counting dimension of the mean-luminance
+	// contour, between 1 and 2 when a contour exists.
+	FractalDim float64
+	// Colorfulness is the Hasler–Süsstrunk measure on a 0-255 scale.
+	Colorfulness float64
+	// Complexity is the lossless compression ratio in [0,1].
+	Complexity float64
 }
 
-// ComputeMetrics derives metrics from an RGBA buffer.
+// MetricSet selects optional metrics. They cost far more than the rest, so
+// ComputeMetrics leaves them zero.
+type MetricSet uint
+
+const (
+	// MetricSpectral fills SpectralSlope, SpectralFlatness and DominantFreq.
+	MetricSpectral MetricSet = 1 << iota
+	// MetricFractal fills FractalDim.
+	MetricFractal
+	// MetricColorfulness fills Colorfulness.
+	MetricColorfulness
+	// MetricComplexity fills Complexity.
+	MetricComplexity
+
+	// AllMetrics selects every optional metric.
+	AllMetrics = MetricSpectral | MetricFractal | MetricColorfulness | MetricComplexity
+)
+
+// ComputeMetricsWith derives the basic metrics plus the optional metrics in
+// set from an RGBA buffer.
+func ComputeMetricsWith(pixels []byte, width, height int, set MetricSet) (Metrics, error) {
+	m := ComputeMetrics(pixels, width, height)
+	if set == 0 || width <= 0 || height <= 0 || len(pixels) < width*height*4 {
+		return m, nil
+	}
+	count := width * height
+	if set&(MetricSpectral|MetricFractal) != 0 {
+		lums := luminance(pixels, count)
+		if set&MetricSpectral != 0 {
+			spec := spectrum(lums, width, height)
+			m.SpectralSlope = spec.slope
+			m.SpectralFlatness = spec.flatness
+			m.DominantFreq = spec.dominant
+		}
+		if set&MetricFractal != 0 {
+			m.FractalDim = FractalDimension(lums, width, height)
+		}
+	}
+	if set&MetricColorfulness != 0 {
+		m.Colorfulness = Colorfulness(pixels[:count*4])
+	}
+	if set&MetricComplexity != 0 {
+		c, err := Complexity(pixels, width, height)
+		if err != nil {
+			return Metrics{}, err
+		}
+		m.Complexity = c
+	}
+	return m, nil
+}
+
+// ComputeMetrics derives the basic metrics from an RGBA buffer. Optional
+// metrics are left zero; see ComputeMetricsWith.
 func ComputeMetrics(pixels []byte, width, height int) Metrics {
 	m := Metrics{}
 	if width <= 0 || height <= 0 || len(pixels) < width*height*4 {
@@ -7,59 +7,200 @@ import (
 	"github.com/zacharyburkett/image-zoo/pkg/neat"
 )
 
+// Precision selects the numeric type used to evaluate a CPPN.
+type Precision uint8
+
+const (
+	PrecisionFloat64 Precision = iota
+	PrecisionFloat32
+)
+
+// RenderConfig controls how a CPPN is sampled into pixels.
+type RenderConfig struct {
+	Precision Precision `json:"precision,omitempty"`
+	// ColorModel interprets the outputs; the zero value is RGB.
+	ColorModel ColorModel `json:"color_model,omitempty"`
+	// Palette is the gradient for ColorPalette. Empty uses DefaultGradient.
+	Palette Gradient `json:"palette,omitempty"`
+	// Samples is the number of samples per pixel along each axis; 0 and 1
+	// both take one sample at the pixel centre.
+	Samples int `json:"samples,omitempty"`
+	// Pattern places the samples within a pixel.
+	Pattern SamplePattern `json:"pattern,omitempty"`
+	// Filter weights the samples into the pixel colour.
+	Filter SampleFilter `json:"filter,omitempty"`
+	// Seed drives SampleJitter.
+	Seed int64 `json:"seed,omitempty"`
+	// Viewport selects the region of the plane to render.
+	Viewport Viewport `json:"viewport"`
+}
+
+// DefaultRenderConfig returns float64 rendering with one sample per pixel.
+func DefaultRenderConfig() RenderConfig {
+	return RenderConfig{
+		Precision: PrecisionFloat64,
+	}
+}
+
 // RenderGrayscale evaluates a CPPN over a grid and returns RGBA bytes.
 func RenderGrayscale(plan *neat.Plan, width, height int, spec InputSpec) ([]byte, error) {
+	return Render(plan, width, height, spec, DefaultRenderConfig())
+}
+
+// Render evaluates a CPPN over a grid using cfg and returns RGBA bytes.
+// Networks with three or more outputs, or any network under ColorPalette, are
+// rendered in colour.
+func Render(plan *neat.Plan, width, height int, spec InputSpec, cfg RenderConfig) ([]byte, error) {
+	return RenderFrame(plan, width, height, spec, cfg, 0)
+}
+
+// RenderFrame is Render at animation time t in [0,1); see InputSpec.UseTime.
+func RenderFrame(plan *neat.Plan, width, height int, spec InputSpec, cfg RenderConfig, t float64) ([]byte, error) {
 	if plan == nil {
 		return nil, fmt.Errorf("plan is nil")
 	}
 	if width <= 0 || height <= 0 {
 		return nil, fmt.Errorf("invalid size %dx%d", width, height)
 	}
-	if spec.Count() != len(plan.Inputs) {
-		return nil, fmt.Errorf("input spec count %d does not match plan inputs %d", spec.Count(), len(plan.Inputs))
+	r, err := newRenderer(plan, spec, cfg, t, width, height)
+	if err != nil {
+		return nil, err
 	}
-
-	exec := plan.NewExecutor()
+	s := newSampler(plan, cfg)
 	inputs := make([]float64, spec.Count())
 	pixels := make([]byte, width*height*4)
-
 	for y := 0; y < height; y++ {
-		ny := Coord(y, height)
 		for x := 0; x < width; x++ {
-			nx := Coord(x, width)
-			if err := spec.Fill(inputs, nx, ny); err != nil {
-				return nil, err
-			}
-			out, err := exec.Eval(inputs)
-			if err != nil {
-				return nil, err
-			}
 			idx := (y*width + x) * 4
-			if len(out) >= 3 {
-				pixels[idx] = toByte(out[0])
-				pixels[idx+1] = toByte(out[1])
-				pixels[idx+2] = toByte(out[2])
-			} else {
-				v := toByte(out[0])
-				pixels[idx] = v
-				pixels[idx+1] = v
-				pixels[idx+2] = v
+			if err := r.pixel(s, inputs, x, y, pixels[idx:idx+4]); err != nil {
+				return nil, err
 			}
-			pixels[idx+3] = 255
 		}
 	}
 	return pixels, nil
 }
 
-func toByte(v float64) byte {
-	if v < 0 || v > 1 {
-		v = 0.5*(v+1)
+// renderer holds what every pixel of a render shares. It is read-only, so
+// workers may share one renderer with a sampler each.
+type renderer struct {
+	spec     InputSpec
+	cfg      RenderConfig
+	t        float64
+	width    int
+	height   int
+	view     viewTransform
+	features []FeatureFunc
+	paint    painter
+	// gray is set when every pixel comes out gray.
+	gray bool
+	// pattern is nil for SampleJitter, whose offsets vary per pixel.
+	pattern []subsample
+}
+
+func newRenderer(plan *neat.Plan, spec InputSpec, cfg RenderConfig, t float64, width, height int) (*renderer, error) {
+	if err := spec.Check(plan); err != nil {
+		return nil, err
+	}
+	if err := cfg.checkOutputs(plan); err != nil {
+		return nil, err
+	}
+	features, err := spec.resolveFeatures()
+	if err != nil {
+		return nil, err
+	}
+	r := &renderer{
+		spec:     spec,
+		cfg:      cfg,
+		t:        t,
+		width:    width,
+		height:   height,
+		view:     cfg.Viewport.transform(width, height),
+		features: features,
+		paint:    cfg.painter(len(plan.Outputs)),
+		gray:     cfg.ColorModel != ColorPalette && len(plan.Outputs) < 3,
 	}
-	if v < 0 {
-		v = 0
+	if cfg.Pattern != SampleJitter {
+		r.pattern = cfg.subsamples(0, 0)
 	}
-	if v > 1 {
-		v = 1
+	return r, nil
+}
+
+// pixel renders pixel (x, y) into dst as RGBA.
+func (r *renderer) pixel(s *sampler, inputs []float64, x, y int, dst []byte) error {
+	pattern := r.pattern
+	if pattern == nil {
+		pattern = r.cfg.subsamples(x, y)
+	}
+	var sum [3]float64
+	total := 0.0
+	for _, p := range pattern {
+		nx, ny := r.view.apply(coordAt(float64(x)+p.dx, r.width), coordAt(float64(y)+p.dy, r.height))
+		if err := r.spec.fill(inputs, r.features, nx, ny, r.t); err != nil {
+			return err
+		}
+		out, err := s.eval(inputs)
+		if err != nil {
+			return err
+		}
+		c := r.paint(out)
+		for i := range sum {
+			sum[i] += p.weight * c[i]
+		}
+		total += p.weight
+	}
+	for i := range sum {
+		dst[i] = toByte(sum[i] / total)
+	}
+	dst[3] = 255
+	return nil
+}
+
+// sampler evaluates a plan at either precision behind a float64 interface.
+type sampler struct {
+	exec   *neat.Executor
+	exec32 *neat.Executor32
+	in32   []float32
+	out    []float64
+}
+
+func newSampler(plan *neat.Plan, cfg RenderConfig) *sampler {
+	s := &sampler{}
+	if cfg.Precision == PrecisionFloat32 {
+		s.exec32 = plan.Float32().NewExecutor()
+		s.in32 = make([]float32, len(plan.Inputs))
+		s.out = make([]float64, len(plan.Outputs))
+		return s
+	}
+	s.exec = plan.NewExecutor()
+	return s
+}
+
+func (s *sampler) eval(inputs []float64) ([]float64, error) {
+	if s.exec32 == nil {
+		return s.exec.Eval(inputs)
+	}
+	for i, v := range inputs {
+		s.in32[i] = float32(v)
+	}
+	out, err := s.exec32.Eval(s.in32)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range out {
+		s.out[i] = float64(v)
+	}
+	return s.out, nil
+}
+
+func toByte(v float64) byte {
+	return byte(math.Round(unit(v) * 255))
+}
+
+// unit maps an output into [0,1]: values already inside are kept, others are
+// read as [-1,1] and clamped.
+func unit(v float64) float64 {
+	if v < 0 || v > 1 {
+		v = 0.5 * (v + 1)
 	}
-	return byte(math.Round(v * 255))
+	return clamp01(v)
 }
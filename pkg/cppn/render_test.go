@@ -31,3 +31,42 @@ func TestRenderGrayscale(t *testing.T) {
 		t.Fatalf("unexpected pixel length %d", len(pixels))
 	}
 }
+
+func TestRenderFloat32MatchesFloat64(t *testing.T) {
+	spec := DefaultInputSpec()
+	g := neat.Genome{
+		Nodes: []neat.NodeGene{
+			{ID: 1, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 2, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 3, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 4, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 5, Kind: neat.NodeOutput, Activation: neat.ActivationSigmoid},
+			{ID: 6, Kind: neat.NodeHidden, Activation: neat.ActivationSin, Bias: 0.3},
+		},
+		Connections: []neat.ConnectionGene{
+			{Innovation: 1, In: 1, Out: 6, Weight: 4.5, Enabled: true},
+			{Innovation: 2, In: 3, Out: 6, Weight: -2, Enabled: true},
+			{Innovation: 3, In: 6, Out: 5, Weight: 1.5, Enabled: true},
+			{Innovation: 4, In: 2, Out: 5, Weight: 0.7, Enabled: true},
+		},
+	}
+	plan, err := neat.BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+
+	want, err := Render(plan, 16, 16, spec, RenderConfig{Precision: PrecisionFloat64})
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	got, err := Render(plan, 16, 16, spec, RenderConfig{Precision: PrecisionFloat32})
+	if err != nil {
+		t.Fatalf("Render float32 error: %v", err)
+	}
+	for i := range want {
+		diff := int(want[i]) - int(got[i])
+		if diff < -1 || diff > 1 {
+			t.Fatalf("pixel byte %d differs: %d vs %d", i, want[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package cppn
+
+import "math"
+
+// SamplePattern places supersamples within a pixel.
+type SamplePattern uint8
+
+const (
+	// SampleGrid is a regular N×N grid.
+	SampleGrid SamplePattern = iota
+	// SampleRotatedGrid is the N×N grid rotated by atan(1/2) and wrapped
+	// into the pixel, so no two samples share a row or column.
+	SampleRotatedGrid
+	// SampleJitter places one sample at a random point in each cell of the
+	// N×N grid. The points depend only on RenderConfig.Seed and the pixel.
+	SampleJitter
+)
+
+// SampleFilter reconstructs a pixel from its supersamples.
+type SampleFilter uint8
+
+const (
+	// FilterBox averages the samples equally over the pixel.
+	FilterBox SampleFilter = iota
+	// FilterGaussian spreads the pattern over a two pixel wide footprint
+	// and weights samples by a Gaussian with a half-pixel sigma, which
+	// overlaps neighbouring pixels and blurs less coherently than a box.
+	FilterGaussian
+)
+
+// rotatedGridAngle is atan(1/2), the classic rotated-grid supersampling angle.
+var rotatedGridAngle = math.Atan(0.5)
+
+// subsample is an offset from the pixel position, in pixels, and its weight.
+type subsample struct {
+	dx, dy, weight float64
+}
+
+// subsamples returns the sample offsets and weights for pixel (x, y).
+func (cfg RenderConfig) subsamples(x, y int) []subsample {
+	n := cfg.Samples
+	if n < 1 {
+		n = 1
+	}
+	out := make([]subsample, 0, n*n)
+	sin, cos := math.Sincos(rotatedGridAngle)
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			dx := (float64(i)+0.5)/float64(n) - 0.5
+			dy := (float64(j)+0.5)/float64(n) - 0.5
+			switch cfg.Pattern {
+			case SampleRotatedGrid:
+				dx, dy = wrapHalf(dx*cos-dy*sin), wrapHalf(dx*sin+dy*cos)
+			case SampleJitter:
+				u, v := jitter(cfg.Seed, x, y, j*n+i)
+				dx = (float64(i)+u)/float64(n) - 0.5
+				dy = (float64(j)+v)/float64(n) - 0.5
+			}
+			s := subsample{dx: dx, dy: dy, weight: 1}
+			if cfg.Filter == FilterGaussian && n > 1 {
+				s.dx, s.dy = 2*dx, 2*dy
+				s.weight = math.Exp(-(s.dx*s.dx + s.dy*s.dy) / (2 * 0.5 * 0.5))
+			}
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// wrapHalf wraps v into [-0.5, 0.5).
+func wrapHalf(v float64) float64 {
+	return v - math.Floor(v+0.5)
+}
+
+// jitter returns two uniform values in [0,1) hashed from the seed, the pixel
+// and the sample index, so jittered renders do not depend on pixel order.
+func jitter(seed int64, x, y, i int) (float64, float64) {
+	h := splitMix64(uint64(seed) ^ splitMix64(uint64(x)<<32|uint64(uint32(y))) ^ uint64(i)*0x9e3779b97f4a7c15)
+	return float64(h>>11) / (1 << 53), float64(splitMix64(h)>>11) / (1 << 53)
+}
+
+func splitMix64(z uint64) uint64 {
+	z += 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
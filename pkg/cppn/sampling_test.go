@@ -0,0 +1,106 @@
+package cppn
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+// stripesPlan renders sin(weight·x), far above the pixel rate for small
+// images.
+func stripesPlan(t *testing.T, weight float64) *neat.Plan {
+	t.Helper()
+	g := neat.Genome{
+		Nodes: []neat.NodeGene{
+			{ID: 1, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 2, Kind: neat.NodeOutput, Activation: neat.ActivationSin},
+		},
+		Connections: []neat.ConnectionGene{
+			{Innovation: 1, In: 1, Out: 2, Weight: weight, Enabled: true},
+		},
+	}
+	plan, err := neat.BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	return plan
+}
+
+func TestSupersamplingReducesAliasing(t *testing.T) {
+	plan := stripesPlan(t, 150)
+	spec := InputSpec{UseX: true}
+	single, err := Render(plan, 32, 4, spec, DefaultRenderConfig())
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	aliased := ComputeMetrics(single, 32, 4).Variance
+	for _, pattern := range []SamplePattern{SampleGrid, SampleRotatedGrid, SampleJitter} {
+		for _, filter := range []SampleFilter{FilterBox, FilterGaussian} {
+			cfg := DefaultRenderConfig()
+			cfg.Samples, cfg.Pattern, cfg.Filter, cfg.Seed = 6, pattern, filter, 9
+			pixels, err := Render(plan, 32, 4, spec, cfg)
+			if err != nil {
+				t.Fatalf("Render error: %v", err)
+			}
+			if v := ComputeMetrics(pixels, 32, 4).Variance; v > aliased/3 {
+				t.Fatalf("pattern %d filter %d: variance %v, single sample %v", pattern, filter, v, aliased)
+			}
+		}
+	}
+}
+
+func TestSupersamplingSingleSampleMatchesLegacy(t *testing.T) {
+	plan := stripesPlan(t, 3)
+	spec := InputSpec{UseX: true}
+	want, err := Render(plan, 16, 2, spec, DefaultRenderConfig())
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	for _, cfg := range []RenderConfig{
+		{Samples: 1},
+		{Samples: 1, Pattern: SampleRotatedGrid, Filter: FilterGaussian},
+	} {
+		got, err := Render(plan, 16, 2, spec, cfg)
+		if err != nil {
+			t.Fatalf("Render error: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("expected one centred sample to match the legacy render for %+v", cfg)
+		}
+	}
+}
+
+func TestSubsamplePatterns(t *testing.T) {
+	rotated := RenderConfig{Samples: 4, Pattern: SampleRotatedGrid}.subsamples(0, 0)
+	xs, ys := map[float64]bool{}, map[float64]bool{}
+	for _, s := range rotated {
+		if s.dx < -0.5 || s.dx >= 0.5 || s.dy < -0.5 || s.dy >= 0.5 {
+			t.Fatalf("sample %+v outside the pixel", s)
+		}
+		xs[s.dx], ys[s.dy] = true, true
+	}
+	if len(xs) != 16 || len(ys) != 16 {
+		t.Fatalf("expected 16 distinct columns and rows, got %d and %d", len(xs), len(ys))
+	}
+
+	jitter := RenderConfig{Samples: 3, Pattern: SampleJitter, Seed: 5}
+	a, b := jitter.subsamples(4, 7), jitter.subsamples(4, 7)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("jitter is not deterministic")
+		}
+	}
+	if c := jitter.subsamples(5, 7); c[0] == a[0] {
+		t.Fatalf("expected jitter to vary between pixels")
+	}
+	jitter.Seed = 6
+	if c := jitter.subsamples(4, 7); c[0] == a[0] {
+		t.Fatalf("expected jitter to vary with the seed")
+	}
+
+	gauss := RenderConfig{Samples: 3, Filter: FilterGaussian}.subsamples(0, 0)
+	if gauss[4].weight != 1 || gauss[0].weight >= gauss[1].weight {
+		t.Fatalf("expected weights to fall off from the centre: %+v", gauss)
+	}
+}
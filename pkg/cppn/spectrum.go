@@ -0,0 +1,255 @@
+package cppn
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// spectralStats summarises the radially averaged power spectrum of a
+// luminance image.
+type spectralStats struct {
+	slope    float64
+	flatness float64
+	dominant float64
+}
+
+// spectrum computes the power spectrum of a Hann-windowed, mean-removed
+// luminance image zero-padded to powers of two. The slope is the fitted
+// exponent of power against radial frequency on a log-log scale. Flatness is
+// the geometric over the arithmetic mean of the power, in [0,1]. Dominant is the radial frequency
+// with the most power as a fraction of Nyquist.
+func spectrum(lums []float64, width, height int) spectralStats {
+	var stats spectralStats
+	if width < 4 || height < 4 {
+		return stats
+	}
+	nw, nh := nextPow2(width), nextPow2(height)
+	mean := 0.0
+	for _, v := range lums {
+		mean += v
+	}
+	mean /= float64(len(lums))
+
+	grid := make([]complex128, nw*nh)
+	for y := 0; y < height; y++ {
+		wy := hann(y, height)
+		for x := 0; x < width; x++ {
+			grid[y*nw+x] = complex((lums[y*width+x]-mean)*wy*hann(x, width), 0)
+		}
+	}
+	row := make([]complex128, nw)
+	for y := 0; y < nh; y++ {
+		copy(row, grid[y*nw:(y+1)*nw])
+		fft(row)
+		copy(grid[y*nw:], row)
+	}
+	col := make([]complex128, nh)
+	for x := 0; x < nw; x++ {
+		for y := 0; y < nh; y++ {
+			col[y] = grid[y*nw+x]
+		}
+		fft(col)
+		for y := 0; y < nh; y++ {
+			grid[y*nw+x] = col[y]
+		}
+	}
+
+	bins := min(nw, nh) / 2
+	power := make([]float64, bins)
+	counts := make([]int, bins)
+	var sumP, sumLogP float64
+	n := 0
+	for ky := 0; ky < nh; ky++ {
+		fy := float64(wrapFreq(ky, nh)) / float64(nh)
+		for kx := 0; kx < nw; kx++ {
+			fx := float64(wrapFreq(kx, nw)) / float64(nw)
+			r := math.Hypot(fx, fy)
+			if r == 0 || r > 0.5 {
+				continue
+			}
+			p := cmplx.Abs(grid[ky*nw+kx])
+			p *= p
+			b := min(int(r/0.5*float64(bins)), bins-1)
+			power[b] += p
+			counts[b]++
+			sumP += p
+			sumLogP += math.Log(p + 1e-12)
+			n++
+		}
+	}
+	if n == 0 || sumP < 1e-12 {
+		return stats
+	}
+	stats.flatness = clamp01(math.Exp(sumLogP/float64(n)) / (sumP / float64(n)))
+
+	var xs, ys []float64
+	best := -1.0
+	for b := range power {
+		if counts[b] == 0 {
+			continue
+		}
+		p := power[b] / float64(counts[b])
+		freq := (float64(b) + 0.5) / float64(bins)
+		if p > best {
+			best = p
+			stats.dominant = freq
+		}
+		if p > 0 {
+			xs = append(xs, math.Log(freq))
+			ys = append(ys, math.Log(p))
+		}
+	}
+	stats.slope = fitSlope(xs, ys)
+	return stats
+}
+
+// FractalDimension estimates the box-counting dimension of the boundary
+// between pixels above and below the mean luminance: about 1 for smooth
+// contours and approaching 2 for noise. Images without a boundary return 0.
+func FractalDimension(lums []float64, width, height int) float64 {
+	if width < 4 || height < 4 || len(lums) < width*height {
+		return 0
+	}
+	mean := 0.0
+	for _, v := range lums[:width*height] {
+		mean += v
+	}
+	mean /= float64(width * height)
+	edge := make([]bool, width*height)
+	found := false
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			above := lums[y*width+x] > mean
+			if (x+1 < width && (lums[y*width+x+1] > mean) != above) || (y+1 < height && (lums[(y+1)*width+x] > mean) != above) {
+				edge[y*width+x] = true
+				found = true
+			}
+		}
+	}
+	if !found {
+		return 0
+	}
+
+	var xs, ys []float64
+	for size := 1; size <= min(width, height)/2; size *= 2 {
+		boxes := 0
+		for by := 0; by < height; by += size {
+			for bx := 0; bx < width; bx += size {
+				if boxHasEdge(edge, width, height, bx, by, size) {
+					boxes++
+				}
+			}
+		}
+		xs = append(xs, math.Log(1/float64(size)))
+		ys = append(ys, math.Log(float64(boxes)))
+	}
+	return fitSlope(xs, ys)
+}
+
+func boxHasEdge(edge []bool, width, height, x0, y0, size int) bool {
+	for y := y0; y < min(y0+size, height); y++ {
+		for x := x0; x < min(x0+size, width); x++ {
+			if edge[y*width+x] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Colorfulness is the Hasler–Süsstrunk colourfulness of an RGBA buffer on a
+// 0-255 scale: 0 for grayscale, around 30 for moderately and above 100 for
+// extremely colourful images.
+func Colorfulness(pixels []byte) float64 {
+	count := len(pixels) / 4
+	if count == 0 {
+		return 0
+	}
+	var sumRG, sumYB, sumRG2, sumYB2 float64
+	for i := 0; i < count; i++ {
+		o := i * 4
+		r, g, b := float64(pixels[o]), float64(pixels[o+1]), float64(pixels[o+2])
+		rg := r - g
+		yb := 0.5*(r+g) - b
+		sumRG += rg
+		sumYB += yb
+		sumRG2 += rg * rg
+		sumYB2 += yb * yb
+	}
+	n := float64(count)
+	meanRG, meanYB := sumRG/n, sumYB/n
+	varRG := math.Max(sumRG2/n-meanRG*meanRG, 0)
+	varYB := math.Max(sumYB2/n-meanYB*meanYB, 0)
+	return math.Sqrt(varRG+varYB) + 0.3*math.Hypot(meanRG, meanYB)
+}
+
+// fitSlope returns the least-squares slope of ys against xs.
+func fitSlope(xs, ys []float64) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx /= float64(len(xs))
+	my /= float64(len(xs))
+	var num, den float64
+	for i := range xs {
+		num += (xs[i] - mx) * (ys[i] - my)
+		den += (xs[i] - mx) * (xs[i] - mx)
+	}
+	if den == 0 {
+		return 0
+	}
+	return num / den
+}
+
+// fft is an in-place iterative radix-2 FFT; len(a) must be a power of two.
+func fft(a []complex128) {
+	n := len(a)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u := a[start+k]
+				v := a[start+k+size/2] * w
+				a[start+k] = u + v
+				a[start+k+size/2] = u - v
+				w *= step
+			}
+		}
+	}
+}
+
+func nextPow2(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// wrapFreq maps an FFT index to a signed frequency.
+func wrapFreq(k, n int) int {
+	if k > n/2 {
+		return k - n
+	}
+	return k
+}
+
+func hann(i, n int) float64 {
+	return 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+}
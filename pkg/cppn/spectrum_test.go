@@ -0,0 +1,198 @@
+package cppn
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func grayPixels(lums []float64) []byte {
+	pixels := make([]byte, len(lums)*4)
+	for i, v := range lums {
+		b := byte(math.Round(clamp01(v) * 255))
+		pixels[i*4], pixels[i*4+1], pixels[i*4+2], pixels[i*4+3] = b, b, b, 255
+	}
+	return pixels
+}
+
+func noiseLums(size int, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	lums := make([]float64, size*size)
+	for i := range lums {
+		lums[i] = rng.Float64()
+	}
+	return lums
+}
+
+func sineLums(size int, cycles float64) []float64 {
+	lums := make([]float64, size*size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			lums[y*size+x] = 0.5 + 0.5*math.Sin(2*math.Pi*cycles*float64(x)/float64(size))
+		}
+	}
+	return lums
+}
+
+// pinkLums sums a fixed number of random plane waves per radial frequency f
+// with amplitude 1/sqrt(f). The annulus at f holds about 2*pi*f coefficients,
+// so the mean power per coefficient falls as 1/f^2.
+func pinkLums(size int, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	lums := make([]float64, size*size)
+	for f := 1; f <= size/2; f++ {
+		for k := 0; k < 8; k++ {
+			angle := rng.Float64() * math.Pi
+			phase := rng.Float64() * 2 * math.Pi
+			fx, fy := float64(f)*math.Cos(angle), float64(f)*math.Sin(angle)
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					lums[y*size+x] += math.Sin(2*math.Pi*(fx*float64(x)+fy*float64(y))/float64(size)+phase) / math.Sqrt(float64(f))
+				}
+			}
+		}
+	}
+	lo, hi := lums[0], lums[0]
+	for _, v := range lums {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	for i := range lums {
+		lums[i] = (lums[i] - lo) / (hi - lo)
+	}
+	return lums
+}
+
+// allMetrics computes every optional metric.
+func allMetrics(t *testing.T, pixels []byte, width, height int) Metrics {
+	t.Helper()
+	m, err := ComputeMetricsWith(pixels, width, height, AllMetrics)
+	if err != nil {
+		t.Fatalf("ComputeMetricsWith error: %v", err)
+	}
+	return m
+}
+
+func TestOptionalMetricsAreOptIn(t *testing.T) {
+	pixels := grayPixels(noiseLums(32, 3))
+	m := ComputeMetrics(pixels, 32, 32)
+	if m.SpectralFlatness != 0 || m.FractalDim != 0 || m.Complexity != 0 {
+		t.Fatalf("expected ComputeMetrics to skip optional metrics, got %+v", m)
+	}
+	fractal, err := ComputeMetricsWith(pixels, 32, 32, MetricFractal)
+	if err != nil {
+		t.Fatalf("ComputeMetricsWith error: %v", err)
+	}
+	if fractal.FractalDim == 0 || fractal.SpectralFlatness != 0 || fractal.Entropy != m.Entropy {
+		t.Fatalf("expected only the fractal dimension on top of the basic metrics, got %+v", fractal)
+	}
+
+	if set := DefaultFitnessWeights().Needs(); set != 0 {
+		t.Fatalf("expected default weights to need no optional metrics, got %b", set)
+	}
+	if set := (FitnessWeights{DominantFreq: 1, Colorfulness: 0.5}).Needs(); set != MetricSpectral|MetricColorfulness {
+		t.Fatalf("unexpected metric set %b", set)
+	}
+}
+
+func TestSpectralMetricsUniform(t *testing.T) {
+	lums := make([]float64, 32*32)
+	for i := range lums {
+		lums[i] = 0.5
+	}
+	m := allMetrics(t, grayPixels(lums), 32, 32)
+	if m.SpectralSlope != 0 || m.SpectralFlatness != 0 || m.DominantFreq != 0 || m.FractalDim != 0 || m.Colorfulness != 0 {
+		t.Fatalf("expected zero spectral metrics for a flat image, got %+v", m)
+	}
+}
+
+func TestSpectralMetricsNoiseVsSine(t *testing.T) {
+	noise := allMetrics(t, grayPixels(noiseLums(64, 3)), 64, 64)
+	if noise.SpectralFlatness < 0.4 {
+		t.Fatalf("expected noise to be spectrally flat, got %v", noise.SpectralFlatness)
+	}
+	if math.Abs(noise.SpectralSlope) > 0.5 {
+		t.Fatalf("expected noise slope near 0, got %v", noise.SpectralSlope)
+	}
+
+	sine := allMetrics(t, grayPixels(sineLums(64, 8)), 64, 64)
+	if sine.SpectralFlatness > 0.1 {
+		t.Fatalf("expected a sine to have low flatness, got %v", sine.SpectralFlatness)
+	}
+	// 8 cycles over 64 pixels is a quarter of Nyquist.
+	if math.Abs(sine.DominantFreq-0.25) > 0.05 {
+		t.Fatalf("expected dominant frequency near 0.25, got %v", sine.DominantFreq)
+	}
+}
+
+func TestSpectralSlopeOfPinkNoise(t *testing.T) {
+	m := allMetrics(t, grayPixels(pinkLums(64, 5)), 64, 64)
+	if m.SpectralSlope > -1.2 || m.SpectralSlope < -3 {
+		t.Fatalf("expected a slope near -2 for 1/f noise, got %v", m.SpectralSlope)
+	}
+}
+
+func TestFractalDimension(t *testing.T) {
+	size := 64
+	half := make([]float64, size*size)
+	for y := 0; y < size; y++ {
+		for x := size / 2; x < size; x++ {
+			half[y*size+x] = 1
+		}
+	}
+	if d := FractalDimension(half, size, size); math.Abs(d-1) > 0.15 {
+		t.Fatalf("expected dimension near 1 for a straight edge, got %v", d)
+	}
+	if d := FractalDimension(noiseLums(size, 9), size, size); d < 1.7 {
+		t.Fatalf("expected dimension near 2 for noise, got %v", d)
+	}
+}
+
+func TestColorfulness(t *testing.T) {
+	gray := grayPixels(noiseLums(16, 1))
+	if c := Colorfulness(gray); c != 0 {
+		t.Fatalf("expected 0 for grayscale, got %v", c)
+	}
+	checker := make([]byte, 16*16*4)
+	for i := 0; i < 16*16; i++ {
+		o := i * 4
+		if (i/16+i%16)%2 == 0 {
+			checker[o] = 255
+		} else {
+			checker[o+1] = 255
+		}
+		checker[o+3] = 255
+	}
+	if c := Colorfulness(checker); c < 100 {
+		t.Fatalf("expected a red/green checkerboard to be very colourful, got %v", c)
+	}
+}
+
+func TestScoreFromMetricsNewTermsDefaultOff(t *testing.T) {
+	m := Metrics{SpectralSlope: -2, FractalDim: 1.4, Colorfulness: 100}
+	if s := ScoreFromMetrics(m, DefaultFitnessWeights(), true); s != ScoreFromMetrics(Metrics{}, DefaultFitnessWeights(), true) {
+		t.Fatalf("new terms should not affect the default score")
+	}
+	w := FitnessWeights{SpectralSlope: 1, FractalDim: 1, Colorfulness: 1}
+	if s := ScoreFromMetrics(m, w, true); math.Abs(s-3) > 1e-9 {
+		t.Fatalf("expected score 3, got %v", s)
+	}
+	if s := ScoreFromMetrics(m, w, false); math.Abs(s-2) > 1e-9 {
+		t.Fatalf("expected colourfulness ignored in grayscale, got %v", s)
+	}
+}
+
+func TestFFTMatchesDFT(t *testing.T) {
+	in := []complex128{1, 2, 0, -1, 3, 0.5, -2, 1}
+	got := append([]complex128(nil), in...)
+	fft(got)
+	for k := range in {
+		var want complex128
+		for n, v := range in {
+			angle := -2 * math.Pi * float64(k*n) / float64(len(in))
+			want += v * complex(math.Cos(angle), math.Sin(angle))
+		}
+		if math.Abs(real(got[k]-want)) > 1e-9 || math.Abs(imag(got[k]-want)) > 1e-9 {
+			t.Fatalf("bin %d: got %v want %v", k, got[k], want)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package cppn
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+// Loss selects how a render is compared with a target image. Every loss is
+// in [0,1], with 0 meaning identical.
+type Loss uint8
+
+const (
+	// LossMSE is the mean squared error over RGB.
+	LossMSE Loss = iota
+	// LossMAE is the mean absolute error of each channel, averaged.
+	LossMAE
+	// LossSSIM is (1-SSIM)/2 on luminance over 7x7 windows.
+	LossSSIM
+	// LossEdge is the mean squared error between Sobel edge maps.
+	LossEdge
+)
+
+// WeightedLoss is one term of a target-matching score.
+type WeightedLoss struct {
+	Loss   Loss
+	Weight float64
+}
+
+// ResolutionStage renders at Size pixels on the longer side for Generations
+// generations. A zero Generations on the last stage lasts forever.
+type ResolutionStage struct {
+	Size        int
+	Generations int
+}
+
+// TargetConfig controls target-image matching.
+type TargetConfig struct {
+	Losses []WeightedLoss
+	// Schedule runs from coarse to fine so large structure is matched
+	// before detail. Scores from different stages are not comparable.
+	Schedule []ResolutionStage
+	// Grayscale compares luminance only, for single-output CPPNs.
+	Grayscale bool
+}
+
+// DefaultTargetConfig mixes pixel, structural and edge losses over a
+// 16/32/64 pixel schedule.
+func DefaultTargetConfig() TargetConfig {
+	return TargetConfig{
+		Losses: []WeightedLoss{
+			{Loss: LossMSE, Weight: 0.5},
+			{Loss: LossSSIM, Weight: 0.3},
+			{Loss: LossEdge, Weight: 0.2},
+		},
+		Schedule: []ResolutionStage{
+			{Size: 16, Generations: 20},
+			{Size: 32, Generations: 30},
+			{Size: 64},
+		},
+	}
+}
+
+// Target is a reference image in RGBA bytes. Resized copies are cached, so a
+// Target may be shared by concurrent fitness evaluations.
+type Target struct {
+	Width  int
+	Height int
+	Pixels []byte
+
+	mu      sync.Mutex
+	resized map[[2]int][]byte
+}
+
+// NewTarget converts img to a Target. Transparent areas become black.
+func NewTarget(img image.Image) *Target {
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Over)
+	return &Target{Width: b.Dx(), Height: b.Dy(), Pixels: rgba.Pix}
+}
+
+// LoadTarget decodes a PNG or JPEG target image.
+func LoadTarget(r io.Reader) (*Target, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode target: %w", err)
+	}
+	if img.Bounds().Empty() {
+		return nil, fmt.Errorf("target image is empty")
+	}
+	return NewTarget(img), nil
+}
+
+// LoadTargetFile decodes a PNG or JPEG target image from path.
+func LoadTargetFile(path string) (*Target, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadTarget(f)
+}
+
+// Fit returns the size with the target's aspect ratio whose longer side is
+// size pixels.
+func (t *Target) Fit(size int) (int, int) {
+	if t.Width >= t.Height {
+		return size, max(1, int(math.Round(float64(size)*float64(t.Height)/float64(t.Width))))
+	}
+	return max(1, int(math.Round(float64(size)*float64(t.Width)/float64(t.Height)))), size
+}
+
+// Resize returns the target scaled to width x height by averaging the source
+// pixels under each output pixel. The result is cached and must not be
+// modified.
+func (t *Target) Resize(width, height int) []byte {
+	key := [2]int{width, height}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if out, ok := t.resized[key]; ok {
+		return out
+	}
+	out := make([]byte, width*height*4)
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, t.Height)
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, t.Width)
+			var sum [3]int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					o := (sy*t.Width + sx) * 4
+					sum[0] += int(t.Pixels[o])
+					sum[1] += int(t.Pixels[o+1])
+					sum[2] += int(t.Pixels[o+2])
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			o := (y*width + x) * 4
+			for c := 0; c < 3; c++ {
+				out[o+c] = byte((sum[c] + n/2) / n)
+			}
+			out[o+3] = 255
+		}
+	}
+	if t.resized == nil {
+		t.resized = map[[2]int][]byte{}
+	}
+	t.resized[key] = out
+	return out
+}
+
+// span returns the source pixel range covered by output pixel i; it is never
+// empty, so upscaling repeats pixels.
+func span(i, dst, src int) (int, int) {
+	lo := i * src / dst
+	hi := (i + 1) * src / dst
+	if hi <= lo {
+		hi = lo + 1
+	}
+	return lo, hi
+}
+
+// CompareImages returns the loss between two RGBA buffers of the same size.
+func CompareImages(a, b []byte, width, height int, loss Loss) (float64, error) {
+	if width <= 0 || height <= 0 {
+		return 0, fmt.Errorf("invalid size %dx%d", width, height)
+	}
+	if len(a) < width*height*4 || len(b) < width*height*4 {
+		return 0, fmt.Errorf("pixel buffers are smaller than %dx%d", width, height)
+	}
+	switch loss {
+	case LossMSE:
+		return meanSquaredError(a, b, width*height), nil
+	case LossMAE:
+		mae := ChannelMAE(a, b, width, height)
+		return (mae[0] + mae[1] + mae[2]) / 3, nil
+	case LossSSIM:
+		return (1 - SSIM(luminance(a, width*height), luminance(b, width*height), width, height)) / 2, nil
+	case LossEdge:
+		ea := sobel(luminance(a, width*height), width, height)
+		eb := sobel(luminance(b, width*height), width, height)
+		sum := 0.0
+		for i := range ea {
+			d := ea[i] - eb[i]
+			sum += d * d
+		}
+		return sum / float64(len(ea)), nil
+	default:
+		return 0, fmt.Errorf("unknown loss %d", loss)
+	}
+}
+
+func meanSquaredError(a, b []byte, count int) float64 {
+	sum := 0.0
+	for i := 0; i < count; i++ {
+		o := i * 4
+		for c := 0; c < 3; c++ {
+			d := (float64(a[o+c]) - float64(b[o+c])) / 255.0
+			sum += d * d
+		}
+	}
+	return sum / float64(count*3)
+}
+
+// ChannelMAE returns the mean absolute error of the R, G and B channels.
+func ChannelMAE(a, b []byte, width, height int) [3]float64 {
+	var out [3]float64
+	count := width * height
+	for i := 0; i < count; i++ {
+		o := i * 4
+		for c := 0; c < 3; c++ {
+			out[c] += math.Abs(float64(a[o+c])-float64(b[o+c])) / 255.0
+		}
+	}
+	for c := range out {
+		out[c] /= float64(count)
+	}
+	return out
+}
+
+const ssimWindow = 7
+
+// SSIM returns the mean structural similarity of two luminance images over
+// 7x7 windows, or over the whole image if it is smaller than a window.
+func SSIM(a, b []float64, width, height int) float64 {
+	const c1, c2 = 0.01 * 0.01, 0.03 * 0.03
+	win := ssimWindow
+	if width < win || height < win {
+		return ssimWindowAt(a, b, width, 0, 0, width, height, c1, c2)
+	}
+	sum := 0.0
+	count := 0
+	for y := 0; y+win <= height; y++ {
+		for x := 0; x+win <= width; x++ {
+			sum += ssimWindowAt(a, b, width, x, y, win, win, c1, c2)
+			count++
+		}
+	}
+	return sum / float64(count)
+}
+
+func ssimWindowAt(a, b []float64, stride, x0, y0, w, h int, c1, c2 float64) float64 {
+	n := float64(w * h)
+	var ma, mb float64
+	for y := y0; y < y0+h; y++ {
+		for x := x0; x < x0+w; x++ {
+			ma += a[y*stride+x]
+			mb += b[y*stride+x]
+		}
+	}
+	ma /= n
+	mb /= n
+	var va, vb, cov float64
+	for y := y0; y < y0+h; y++ {
+		for x := x0; x < x0+w; x++ {
+			da := a[y*stride+x] - ma
+			db := b[y*stride+x] - mb
+			va += da * da
+			vb += db * db
+			cov += da * db
+		}
+	}
+	va /= n
+	vb /= n
+	cov /= n
+	return ((2*ma*mb + c1) * (2*cov + c2)) / ((ma*ma + mb*mb + c1) * (va + vb + c2))
+}
+
+func luminance(pixels []byte, count int) []float64 {
+	out := make([]float64, count)
+	for i := range out {
+		o := i * 4
+		out[i] = (float64(pixels[o]) + float64(pixels[o+1]) + float64(pixels[o+2])) / (3 * 255.0)
+	}
+	return out
+}
+
+// sobel returns the Sobel gradient magnitude of a luminance image scaled to
+// [0,1], with edges clamped.
+func sobel(lums []float64, width, height int) []float64 {
+	at := func(x, y int) float64 {
+		x = min(max(x, 0), width-1)
+		y = min(max(y, 0), height-1)
+		return lums[y*width+x]
+	}
+	out := make([]float64, len(lums))
+	scale := 1 / (4 * math.Sqrt2)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+			out[y*width+x] = math.Hypot(gx, gy) * scale
+		}
+	}
+	return out
+}
+
+// TargetFitness scores CPPNs by how closely their render matches a target.
+type TargetFitness struct {
+	Target *Target
+	Config TargetConfig
+	Spec   InputSpec
+	Render RenderConfig
+}
+
+// NewTargetFitness validates cfg and returns a fitness that renders with spec
+// at float64 precision.
+func NewTargetFitness(target *Target, cfg TargetConfig, spec InputSpec) (*TargetFitness, error) {
+	if target == nil || target.Width <= 0 || target.Height <= 0 {
+		return nil, fmt.Errorf("target is empty")
+	}
+	total := 0.0
+	for _, l := range cfg.Losses {
+		if l.Loss > LossEdge {
+			return nil, fmt.Errorf("unknown loss %d", l.Loss)
+		}
+		if l.Weight < 0 {
+			return nil, fmt.Errorf("loss weights must be >= 0")
+		}
+		total += l.Weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("at least one loss needs a positive weight")
+	}
+	if len(cfg.Schedule) == 0 {
+		return nil, fmt.Errorf("resolution schedule is empty")
+	}
+	for i, s := range cfg.Schedule {
+		if s.Size <= 0 {
+			return nil, fmt.Errorf("stage %d size must be > 0", i)
+		}
+		if s.Generations < 0 || (s.Generations == 0 && i < len(cfg.Schedule)-1) {
+			return nil, fmt.Errorf("stage %d generations must be > 0", i)
+		}
+	}
+	return &TargetFitness{Target: target, Config: cfg, Spec: spec, Render: DefaultRenderConfig()}, nil
+}
+
+// Stage returns the index of the schedule stage for generation.
+func (f *TargetFitness) Stage(generation int) int {
+	for i, s := range f.Config.Schedule {
+		if s.Generations == 0 || generation < s.Generations {
+			return i
+		}
+		generation -= s.Generations
+	}
+	return len(f.Config.Schedule) - 1
+}
+
+// Size returns the render size used at generation.
+func (f *TargetFitness) Size(generation int) (int, int) {
+	return f.Target.Fit(f.Config.Schedule[f.Stage(generation)].Size)
+}
+
+// ScorePixels returns 1 minus the weighted mean loss of pixels against the
+// target resized to width x height, so 1 is a perfect match.
+func (f *TargetFitness) ScorePixels(pixels []byte, width, height int) (float64, error) {
+	if width <= 0 || height <= 0 {
+		return 0, fmt.Errorf("invalid size %dx%d", width, height)
+	}
+	if len(pixels) < width*height*4 {
+		return 0, fmt.Errorf("pixel buffer is smaller than %dx%d", width, height)
+	}
+	target := f.Target.Resize(width, height)
+	if f.Config.Grayscale {
+		pixels = grayRGBA(pixels, width*height)
+		target = grayRGBA(target, width*height)
+	}
+	var loss, total float64
+	for _, l := range f.Config.Losses {
+		if l.Weight == 0 {
+			continue
+		}
+		v, err := CompareImages(pixels, target, width, height, l.Loss)
+		if err != nil {
+			return 0, err
+		}
+		loss += l.Weight * v
+		total += l.Weight
+	}
+	return 1 - loss/total, nil
+}
+
+// Score renders plan at the schedule size for generation and scores it.
+func (f *TargetFitness) Score(plan *neat.Plan, generation int) (float64, error) {
+	w, h := f.Size(generation)
+	pixels, err := Render(plan, w, h, f.Spec, f.Render)
+	if err != nil {
+		return 0, err
+	}
+	return f.ScorePixels(pixels, w, h)
+}
+
+// Fitness adapts f to a Runner, following pop's generation through the
+// schedule.
+func (f *TargetFitness) Fitness(pop *neat.Population) neat.FitnessFunc {
+	return func(g *neat.Genome) (float64, error) {
+		plan, err := neat.BuildAcyclicPlan(*g, nil, nil)
+		if err != nil {
+			return 0, err
+		}
+		return f.Score(plan, pop.Generation)
+	}
+}
+
+func grayRGBA(pixels []byte, count int) []byte {
+	out := make([]byte, count*4)
+	for i := 0; i < count; i++ {
+		o := i * 4
+		v := byte((int(pixels[o]) + int(pixels[o+1]) + int(pixels[o+2]) + 1) / 3)
+		out[o], out[o+1], out[o+2], out[o+3] = v, v, v, 255
+	}
+	return out
+}
@@ -0,0 +1,202 @@
+package cppn
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+func gradientImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 255 / (w - 1)), G: uint8(y * 255 / (h - 1)), B: 64, A: 255})
+		}
+	}
+	return img
+}
+
+func TestLoadTargetPNGAndJPEG(t *testing.T) {
+	img := gradientImage(8, 4)
+	var pngBuf, jpegBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, img); err != nil {
+		t.Fatalf("png encode: %v", err)
+	}
+	if err := jpeg.Encode(&jpegBuf, img, nil); err != nil {
+		t.Fatalf("jpeg encode: %v", err)
+	}
+	for name, buf := range map[string]*bytes.Buffer{"png": &pngBuf, "jpeg": &jpegBuf} {
+		target, err := LoadTarget(buf)
+		if err != nil {
+			t.Fatalf("%s: LoadTarget error: %v", name, err)
+		}
+		if target.Width != 8 || target.Height != 4 || len(target.Pixels) != 8*4*4 {
+			t.Fatalf("%s: unexpected target %dx%d", name, target.Width, target.Height)
+		}
+	}
+	if _, err := LoadTarget(bytes.NewReader([]byte("not an image"))); err == nil {
+		t.Fatalf("expected decode error")
+	}
+}
+
+func TestTargetResizeAverages(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			v := uint8(0)
+			if x%2 == 1 {
+				v = 200
+			}
+			img.Set(x, y, color.NRGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	target := NewTarget(img)
+	out := target.Resize(2, 1)
+	if out[0] != 100 || out[4] != 100 || out[3] != 255 {
+		t.Fatalf("expected averaged pixels of 100, got %v", out)
+	}
+	if w, h := target.Fit(8); w != 8 || h != 4 {
+		t.Fatalf("expected fit 8x4, got %dx%d", w, h)
+	}
+}
+
+func TestCompareImagesLosses(t *testing.T) {
+	a := NewTarget(gradientImage(16, 16)).Pixels
+	b := make([]byte, len(a))
+	for i := range b {
+		b[i] = 255 - a[i]
+		if i%4 == 3 {
+			b[i] = 255
+		}
+	}
+	for _, loss := range []Loss{LossMSE, LossMAE, LossSSIM, LossEdge} {
+		same, err := CompareImages(a, a, 16, 16, loss)
+		if err != nil {
+			t.Fatalf("loss %d error: %v", loss, err)
+		}
+		if math.Abs(same) > 1e-9 {
+			t.Fatalf("loss %d: expected 0 for identical images, got %v", loss, same)
+		}
+		diff, err := CompareImages(a, b, 16, 16, loss)
+		if err != nil {
+			t.Fatalf("loss %d error: %v", loss, err)
+		}
+		if loss != LossEdge && (diff <= 0 || diff > 1) {
+			t.Fatalf("loss %d: expected (0,1] for inverted images, got %v", loss, diff)
+		}
+	}
+	if _, err := CompareImages(a, b, 16, 16, Loss(99)); err == nil {
+		t.Fatalf("expected error for unknown loss")
+	}
+}
+
+func TestChannelMAE(t *testing.T) {
+	a := []byte{0, 0, 0, 255}
+	b := []byte{255, 51, 0, 255}
+	mae := ChannelMAE(a, b, 1, 1)
+	if mae[0] != 1 || math.Abs(mae[1]-0.2) > 1e-9 || mae[2] != 0 {
+		t.Fatalf("unexpected channel MAE %v", mae)
+	}
+}
+
+func TestTargetFitnessSchedule(t *testing.T) {
+	f, err := NewTargetFitness(NewTarget(gradientImage(32, 16)), DefaultTargetConfig(), DefaultInputSpec())
+	if err != nil {
+		t.Fatalf("NewTargetFitness error: %v", err)
+	}
+	cases := []struct{ gen, stage, w, h int }{
+		{0, 0, 16, 8},
+		{19, 0, 16, 8},
+		{20, 1, 32, 16},
+		{49, 1, 32, 16},
+		{50, 2, 64, 32},
+		{500, 2, 64, 32},
+	}
+	for _, tc := range cases {
+		if s := f.Stage(tc.gen); s != tc.stage {
+			t.Fatalf("generation %d: expected stage %d, got %d", tc.gen, tc.stage, s)
+		}
+		if w, h := f.Size(tc.gen); w != tc.w || h != tc.h {
+			t.Fatalf("generation %d: expected %dx%d, got %dx%d", tc.gen, tc.w, tc.h, w, h)
+		}
+	}
+
+	bad := DefaultTargetConfig()
+	bad.Schedule[0].Generations = 0
+	if _, err := NewTargetFitness(NewTarget(gradientImage(4, 4)), bad, DefaultInputSpec()); err == nil {
+		t.Fatalf("expected error for an unbounded early stage")
+	}
+}
+
+func TestScorePixelsRejectsBadInput(t *testing.T) {
+	cfg := DefaultTargetConfig()
+	cfg.Grayscale = true
+	f, err := NewTargetFitness(NewTarget(gradientImage(8, 8)), cfg, DefaultInputSpec())
+	if err != nil {
+		t.Fatalf("NewTargetFitness error: %v", err)
+	}
+	cases := []struct {
+		pixels        []byte
+		width, height int
+	}{
+		{make([]byte, 4*4*4-1), 4, 4},
+		{nil, 0, 4},
+		{nil, 4, -1},
+	}
+	for _, tc := range cases {
+		if _, err := f.ScorePixels(tc.pixels, tc.width, tc.height); err == nil {
+			t.Fatalf("expected error for %d bytes at %dx%d", len(tc.pixels), tc.width, tc.height)
+		}
+	}
+}
+
+func TestTargetFitnessPrefersMatchingRender(t *testing.T) {
+	spec := InputSpec{UseX: true}
+	plan := func(weight float64) *neat.Plan {
+		g := neat.Genome{
+			Nodes: []neat.NodeGene{
+				{ID: 1, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+				{ID: 2, Kind: neat.NodeOutput, Activation: neat.ActivationLinear},
+			},
+			Connections: []neat.ConnectionGene{
+				{Innovation: 1, In: 1, Out: 2, Weight: weight, Enabled: true},
+			},
+		}
+		p, err := neat.BuildAcyclicPlan(g, nil, nil)
+		if err != nil {
+			t.Fatalf("BuildAcyclicPlan error: %v", err)
+		}
+		return p
+	}
+	pixels, err := Render(plan(1), 64, 64, spec, DefaultRenderConfig())
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	target := &Target{Width: 64, Height: 64, Pixels: pixels}
+	cfg := DefaultTargetConfig()
+	cfg.Grayscale = true
+	f, err := NewTargetFitness(target, cfg, spec)
+	if err != nil {
+		t.Fatalf("NewTargetFitness error: %v", err)
+	}
+	match, err := f.Score(plan(1), 0)
+	if err != nil {
+		t.Fatalf("Score error: %v", err)
+	}
+	inverted, err := f.Score(plan(-1), 0)
+	if err != nil {
+		t.Fatalf("Score error: %v", err)
+	}
+	if match < 0.95 {
+		t.Fatalf("expected a near-perfect match, got %v", match)
+	}
+	if inverted >= match {
+		t.Fatalf("expected inverted render %v to score below match %v", inverted, match)
+	}
+}
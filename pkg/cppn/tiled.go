@@ -0,0 +1,287 @@
+package cppn
+
+import (
+	"bufio"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+// TileConfig controls RenderPNG.
+type TileConfig struct {
+	// Workers is the number of rendering goroutines; 0 uses one per CPU.
+	Workers int
+	// BandHeight is the number of rows each job renders; 0 uses 32.
+	BandHeight int
+	// Progress, if set, is called on the calling goroutine after each band
+	// is written, with the rows written so far and the image height.
+	Progress func(rows, height int)
+	// Metadata, if set, is embedded as by EncodePNG.
+	Metadata *Metadata
+}
+
+// DefaultTileConfig uses one worker per CPU and 32-row bands.
+func DefaultTileConfig() TileConfig {
+	return TileConfig{
+		Workers:    runtime.NumCPU(),
+		BandHeight: 32,
+	}
+}
+
+// band is a rendered block of rows, tightly packed at 1 (gray) or 3 (RGB)
+// bytes per pixel.
+type band struct {
+	index int
+	pix   []byte
+	err   error
+}
+
+// RenderPNG renders a CPPN straight into a PNG stream. Row bands are
+// rendered by a pool of workers, each with its own executor, and written in
+// order as they complete. At most two bands per worker exist at once, so
+// memory stays bounded however large the image. Grayscale networks are
+// written as 8-bit gray, others as 8-bit RGB.
+func RenderPNG(ctx context.Context, w io.Writer, plan *neat.Plan, width, height int, spec InputSpec, cfg RenderConfig, tcfg TileConfig) error {
+	if plan == nil {
+		return fmt.Errorf("plan is nil")
+	}
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid size %dx%d", width, height)
+	}
+	r, err := newRenderer(plan, spec, cfg, 0, width, height)
+	if err != nil {
+		return err
+	}
+	workers := tcfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	bandHeight := tcfg.BandHeight
+	if bandHeight <= 0 {
+		bandHeight = 32
+	}
+	channels := 3
+	if r.gray {
+		channels = 1
+	}
+	bands := (height + bandHeight - 1) / bandHeight
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// free holds the band buffers; taking one is the only way to start a
+	// band, which bounds memory.
+	window := 2 * workers
+	free := make(chan []byte, window)
+	for i := 0; i < window; i++ {
+		free <- nil
+	}
+	jobs := make(chan band)
+	results := make([]chan band, bands)
+	for i := range results {
+		results[i] = make(chan band, 1)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		for i := 0; i < bands; i++ {
+			var buf []byte
+			select {
+			case buf = <-free:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- band{index: i, pix: buf}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := newSampler(plan, cfg)
+			inputs := make([]float64, spec.Count())
+			for job := range jobs {
+				job.err = r.renderBand(ctx, s, inputs, &job, width, height, bandHeight, channels)
+				results[job.index] <- job
+			}
+		}()
+	}
+
+	enc, err := newPNGStream(w, width, height, channels, tcfg.Metadata)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < bands; i++ {
+		var done band
+		select {
+		case done = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if done.err != nil {
+			return done.err
+		}
+		rowBytes := width * channels
+		for off := 0; off < len(done.pix); off += rowBytes {
+			if err := enc.writeRow(done.pix[off : off+rowBytes]); err != nil {
+				return err
+			}
+		}
+		free <- done.pix
+		if tcfg.Progress != nil {
+			tcfg.Progress(min((i+1)*bandHeight, height), height)
+		}
+	}
+	return enc.close()
+}
+
+// renderBand renders the rows of job into job.pix, reusing its buffer when
+// it is large enough.
+func (r *renderer) renderBand(ctx context.Context, s *sampler, inputs []float64, job *band, width, height, bandHeight, channels int) error {
+	y0 := job.index * bandHeight
+	rows := min(bandHeight, height-y0)
+	size := rows * width * channels
+	if cap(job.pix) < size {
+		job.pix = make([]byte, size)
+	}
+	job.pix = job.pix[:size]
+	var px [4]byte
+	for row := 0; row < rows; row++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		out := job.pix[row*width*channels:]
+		for x := 0; x < width; x++ {
+			if err := r.pixel(s, inputs, x, y0+row, px[:]); err != nil {
+				return err
+			}
+			copy(out[x*channels:(x+1)*channels], px[:channels])
+		}
+	}
+	return nil
+}
+
+// pngStream writes a PNG one row at a time.
+type pngStream struct {
+	w        io.Writer
+	idat     *bufio.Writer
+	z        *zlib.Writer
+	bpp      int
+	prev     []byte
+	filtered [5][]byte
+}
+
+func newPNGStream(w io.Writer, width, height, channels int, meta *Metadata) (*pngStream, error) {
+	if _, err := w.Write(pngSignature); err != nil {
+		return nil, err
+	}
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(width))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(height))
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 2 // colour type RGB
+	if channels == 1 {
+		ihdr[9] = 0 // gray
+	}
+	if err := writePNGChunk(w, "IHDR", ihdr); err != nil {
+		return nil, err
+	}
+	if meta != nil {
+		text, err := meta.textChunks()
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range text {
+			if err := writePNGChunk(w, "tEXt", append(append([]byte(kv[0]), 0), kv[1]...)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	rowBytes := width * channels
+	p := &pngStream{w: w, bpp: channels, prev: make([]byte, rowBytes)}
+	for i := range p.filtered {
+		p.filtered[i] = make([]byte, rowBytes+1)
+		p.filtered[i][0] = byte(i)
+	}
+	p.idat = bufio.NewWriterSize(chunkWriter{w: w, kind: "IDAT"}, 1<<16)
+	p.z = zlib.NewWriter(p.idat)
+	return p, nil
+}
+
+// writeRow filters row with each PNG filter and writes the one with the
+// smallest sum of absolute values, the heuristic image/png also uses.
+func (p *pngStream) writeRow(row []byte) error {
+	best, bestSum := 0, -1
+	for f := range p.filtered {
+		dst := p.filtered[f][1:]
+		sum := 0
+		for i, v := range row {
+			var a, c byte
+			if i >= p.bpp {
+				a, c = row[i-p.bpp], p.prev[i-p.bpp]
+			}
+			b := p.prev[i]
+			switch f {
+			case 0:
+				dst[i] = v
+			case 1:
+				dst[i] = v - a
+			case 2:
+				dst[i] = v - b
+			case 3:
+				dst[i] = v - byte((int(a)+int(b))/2)
+			case 4:
+				dst[i] = v - paeth(a, b, c)
+			}
+			sum += absInt(int(int8(dst[i])))
+			if bestSum >= 0 && sum >= bestSum {
+				break
+			}
+		}
+		if bestSum < 0 || sum < bestSum {
+			best, bestSum = f, sum
+		}
+	}
+	copy(p.prev, row)
+	_, err := p.z.Write(p.filtered[best])
+	return err
+}
+
+func (p *pngStream) close() error {
+	if err := p.z.Close(); err != nil {
+		return err
+	}
+	if err := p.idat.Flush(); err != nil {
+		return err
+	}
+	return writePNGChunk(p.w, "IEND", nil)
+}
+
+// chunkWriter writes each Write as one PNG chunk.
+type chunkWriter struct {
+	w    io.Writer
+	kind string
+}
+
+func (c chunkWriter) Write(data []byte) (int, error) {
+	if err := writePNGChunk(c.w, c.kind, data); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
@@ -0,0 +1,94 @@
+package cppn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+func TestRenderPNGMatchesRender(t *testing.T) {
+	plan, err := neat.BuildAcyclicPlan(imageTestGenome(), nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	spec := InputSpec{UseX: true, UseY: true}
+	cfg := RenderConfig{Samples: 2, Pattern: SampleJitter, Seed: 3}
+	want, err := Render(plan, 37, 23, spec, cfg)
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	var rows []int
+	tcfg := TileConfig{
+		Workers:    3,
+		BandHeight: 5,
+		Progress:   func(done, height int) { rows = append(rows, done) },
+		Metadata:   &Metadata{Spec: spec, Seed: 3},
+	}
+	if err := RenderPNG(context.Background(), &buf, plan, 37, 23, spec, cfg, tcfg); err != nil {
+		t.Fatalf("RenderPNG error: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("png.Decode error: %v", err)
+	}
+	gray, ok := img.(*image.Gray)
+	if !ok {
+		t.Fatalf("expected a gray PNG, got %T", img)
+	}
+	for i, v := range gray.Pix {
+		if v != want[i*4] {
+			t.Fatalf("pixel %d is %d, want %d", i, v, want[i*4])
+		}
+	}
+	if len(rows) != 5 || rows[0] != 5 || rows[4] != 23 {
+		t.Fatalf("unexpected progress %v", rows)
+	}
+	if meta, ok, err := ReadMetadata(&buf); err != nil || !ok || meta.Seed != 3 {
+		t.Fatalf("expected metadata, got %+v ok=%v err=%v", meta, ok, err)
+	}
+}
+
+func TestRenderPNGColor(t *testing.T) {
+	plan, _ := animationPlan(t)
+	spec := InputSpec{UseX: true, UseTime: true, LoopTime: true}
+	cfg := RenderConfig{ColorModel: ColorPalette}
+	want, err := Render(plan, 16, 9, spec, cfg)
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := RenderPNG(context.Background(), &buf, plan, 16, 9, spec, cfg, DefaultTileConfig()); err != nil {
+		t.Fatalf("RenderPNG error: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("png.Decode error: %v", err)
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	for y := 0; y < 9; y++ {
+		for x := 0; x < 16; x++ {
+			rgba.Set(x, y, img.At(x, y))
+		}
+	}
+	if !bytes.Equal(rgba.Pix, want) {
+		t.Fatalf("colour PNG differs from Render")
+	}
+}
+
+func TestRenderPNGCancel(t *testing.T) {
+	plan := stripesPlan(t, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	tcfg := TileConfig{Workers: 2, BandHeight: 1, Progress: func(int, int) { cancel() }}
+	var buf bytes.Buffer
+	err := RenderPNG(ctx, &buf, plan, 64, 64, InputSpec{UseX: true}, DefaultRenderConfig(), tcfg)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package cppn
+
+import "math"
+
+// Aspect decides how a viewport's square [-1,1] region meets a non-square
+// image.
+type Aspect uint8
+
+const (
+	// AspectStretch maps each image axis onto [-1,1] independently, so
+	// non-square images are stretched. It is the legacy mapping.
+	AspectStretch Aspect = iota
+	// AspectFit shows all of [-1,1] on the shorter axis and extends the
+	// longer one.
+	AspectFit
+	// AspectFill covers the image with [-1,1] on the longer axis and crops
+	// the shorter one.
+	AspectFill
+)
+
+// Viewport is the region of the CPPN's coordinate plane that a render
+// shows. The zero value is the legacy [-1,1] mapping on each axis.
+type Viewport struct {
+	// CenterX and CenterY are the coordinates at the image centre.
+	CenterX float64 `json:"center_x,omitempty"`
+	CenterY float64 `json:"center_y,omitempty"`
+	// Scale is the half-width of the view; 0 means 1. Smaller values zoom
+	// in.
+	Scale float64 `json:"scale,omitempty"`
+	// Rotation turns the view counter-clockwise, in radians.
+	Rotation float64 `json:"rotation,omitempty"`
+	Aspect   Aspect  `json:"aspect,omitempty"`
+}
+
+// Map returns the CPPN coordinates at pixel position (px, py) of a
+// width×height image. Fractional positions address points inside a pixel.
+func (v Viewport) Map(px, py float64, width, height int) (float64, float64) {
+	return v.transform(width, height).apply(coordAt(px, width), coordAt(py, height))
+}
+
+// Pan returns the view moved so that content follows a drag of (dx, dy)
+// pixels in a width×height image.
+func (v Viewport) Pan(dx, dy float64, width, height int) Viewport {
+	t := v.transform(width, height)
+	u := coordAt(dx, width) - coordAt(0, width)
+	w := coordAt(dy, height) - coordAt(0, height)
+	v.CenterX -= t.a*u + t.b*w
+	v.CenterY -= t.c*u + t.d*w
+	return v
+}
+
+// Zoom returns the view magnified by factor (above 1 zooms in) about pixel
+// (px, py), which keeps showing the same point.
+func (v Viewport) Zoom(factor, px, py float64, width, height int) Viewport {
+	if factor <= 0 {
+		return v
+	}
+	x, y := v.Map(px, py, width, height)
+	v.CenterX = x - (x-v.CenterX)/factor
+	v.CenterY = y - (y-v.CenterY)/factor
+	v.Scale = v.scale() / factor
+	return v
+}
+
+func (v Viewport) scale() float64 {
+	if v.Scale == 0 {
+		return 1
+	}
+	return v.Scale
+}
+
+// viewTransform is the affine map from legacy coordinates to the view.
+type viewTransform struct {
+	identity   bool
+	a, b, c, d float64
+	cx, cy     float64
+}
+
+func (v Viewport) transform(width, height int) viewTransform {
+	if v == (Viewport{}) {
+		return viewTransform{identity: true, a: 1, d: 1}
+	}
+	ax, ay := 1.0, 1.0
+	ratio := float64(width) / float64(height)
+	switch v.Aspect {
+	case AspectFit:
+		ax, ay = math.Max(ratio, 1), math.Max(1/ratio, 1)
+	case AspectFill:
+		ax, ay = math.Min(ratio, 1), math.Min(1/ratio, 1)
+	}
+	s := v.scale()
+	sin, cos := math.Sincos(v.Rotation)
+	return viewTransform{
+		a:  s * cos * ax,
+		b:  -s * sin * ay,
+		c:  s * sin * ax,
+		d:  s * cos * ay,
+		cx: v.CenterX,
+		cy: v.CenterY,
+	}
+}
+
+func (t viewTransform) apply(u, w float64) (float64, float64) {
+	if t.identity {
+		return u, w
+	}
+	return t.a*u + t.b*w + t.cx, t.c*u + t.d*w + t.cy
+}
@@ -0,0 +1,91 @@
+package cppn
+
+import (
+	"math"
+	"testing"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestViewportMap(t *testing.T) {
+	var legacy Viewport
+	for _, p := range [][2]int{{0, 0}, {3, 7}, {9, 4}} {
+		x, y := legacy.Map(float64(p[0]), float64(p[1]), 10, 8)
+		if x != Coord(p[0], 10) || y != Coord(p[1], 8) {
+			t.Fatalf("zero viewport differs from Coord at %v", p)
+		}
+	}
+
+	cases := []struct {
+		name   string
+		view   Viewport
+		px, py float64
+		x, y   float64
+	}{
+		{"fit corner", Viewport{Aspect: AspectFit}, 0, 0, -201.0 / 101, -1},
+		{"fill corner", Viewport{Aspect: AspectFill}, 200, 100, 1, 101.0 / 201},
+		{"stretch corner", Viewport{Aspect: AspectStretch, Scale: 1}, 0, 100, -1, 1},
+		{"zoomed centre", Viewport{CenterX: 0.3, CenterY: -0.2, Scale: 0.1}, 100, 50, 0.3, -0.2},
+		{"zoomed edge", Viewport{CenterX: 0.3, Scale: 0.1}, 200, 50, 0.4, 0},
+		{"rotated", Viewport{Rotation: math.Pi / 2}, 200, 50, 0, 1},
+	}
+	for _, tc := range cases {
+		x, y := tc.view.Map(tc.px, tc.py, 201, 101)
+		if !approxEqual(x, tc.x) || !approxEqual(y, tc.y) {
+			t.Fatalf("%s: got (%v, %v), want (%v, %v)", tc.name, x, y, tc.x, tc.y)
+		}
+	}
+}
+
+func TestViewportPanZoom(t *testing.T) {
+	v := Viewport{Rotation: 0.4, Aspect: AspectFit}
+	w, h := 64, 48
+
+	zoomed := v.Zoom(4, 10, 30, w, h)
+	bx, by := v.Map(10, 30, w, h)
+	ax, ay := zoomed.Map(10, 30, w, h)
+	if !approxEqual(ax, bx) || !approxEqual(ay, by) {
+		t.Fatalf("zoom moved the anchor: (%v, %v) -> (%v, %v)", bx, by, ax, ay)
+	}
+	if !approxEqual(zoomed.Scale, 0.25) {
+		t.Fatalf("expected scale 0.25, got %v", zoomed.Scale)
+	}
+
+	panned := zoomed.Pan(5, -7, w, h)
+	bx, by = zoomed.Map(20, 20, w, h)
+	ax, ay = panned.Map(25, 13, w, h)
+	if !approxEqual(ax, bx) || !approxEqual(ay, by) {
+		t.Fatalf("pan did not follow the drag: (%v, %v) vs (%v, %v)", bx, by, ax, ay)
+	}
+}
+
+func TestRenderViewport(t *testing.T) {
+	g := neat.Genome{
+		Nodes: []neat.NodeGene{
+			{ID: 1, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 2, Kind: neat.NodeOutput, Activation: neat.ActivationLinear},
+		},
+		Connections: []neat.ConnectionGene{
+			{Innovation: 1, In: 1, Out: 2, Weight: 1, Enabled: true},
+		},
+	}
+	plan, err := neat.BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	// Showing x in [0,1] maps the row straight onto 0..255.
+	cfg := RenderConfig{Viewport: Viewport{CenterX: 0.5, Scale: 0.5}}
+	pixels, err := Render(plan, 5, 1, InputSpec{UseX: true}, cfg)
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	for i, want := range []byte{0, 64, 128, 191, 255} {
+		if pixels[i*4] != want {
+			t.Fatalf("pixel %d is %d, want %d", i, pixels[i*4], want)
+		}
+	}
+}
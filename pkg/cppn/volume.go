@@ -0,0 +1,284 @@
+package cppn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+// DefaultIso is the density at which volumes are considered solid.
+const DefaultIso = 0.5
+
+// InputSpec3D controls which inputs a volume CPPN receives, in order.
+type InputSpec3D struct {
+	UseX bool `json:"use_x"`
+	UseY bool `json:"use_y"`
+	UseZ bool `json:"use_z"`
+	// UseDistance adds the distance from the centre of the volume.
+	UseDistance bool `json:"use_distance"`
+	UseBias     bool `json:"use_bias"`
+}
+
+// DefaultInputSpec3D returns x, y, z, distance and bias.
+func DefaultInputSpec3D() InputSpec3D {
+	return InputSpec3D{
+		UseX:        true,
+		UseY:        true,
+		UseZ:        true,
+		UseDistance: true,
+		UseBias:     true,
+	}
+}
+
+// Count returns the number of active inputs in the spec.
+func (s InputSpec3D) Count() int {
+	return len(s.Names())
+}
+
+// Names returns the name of each input in order.
+func (s InputSpec3D) Names() []string {
+	var names []string
+	for _, in := range []struct {
+		use  bool
+		name string
+	}{
+		{s.UseX, inputX},
+		{s.UseY, inputY},
+		{s.UseZ, inputZ},
+		{s.UseDistance, inputDist},
+		{s.UseBias, inputBias},
+	} {
+		if in.use {
+			names = append(names, in.name)
+		}
+	}
+	return names
+}
+
+// Label stores the input names on g's input nodes.
+func (s InputSpec3D) Label(g *neat.Genome) error {
+	return g.SetInputLabels(s.Names())
+}
+
+// Check reports whether plan takes the inputs of s.
+func (s InputSpec3D) Check(plan *neat.Plan) error {
+	return checkInputs(s.Names(), plan)
+}
+
+// Fill populates dst with inputs derived from x, y and z.
+func (s InputSpec3D) Fill(dst []float64, x, y, z float64) error {
+	if len(dst) != s.Count() {
+		return fmt.Errorf("input length %d does not match spec %d", len(dst), s.Count())
+	}
+	idx := 0
+	for _, in := range []struct {
+		use   bool
+		value float64
+	}{
+		{s.UseX, x},
+		{s.UseY, y},
+		{s.UseZ, z},
+		{s.UseDistance, math.Sqrt(x*x + y*y + z*z)},
+		{s.UseBias, 1},
+	} {
+		if in.use {
+			dst[idx] = in.value
+			idx++
+		}
+	}
+	return nil
+}
+
+// Volume is a cubic density grid over [-1,1]³. Sample (i, j, k) is at index
+// (k*Size+j)*Size+i.
+type Volume struct {
+	Size int
+	// Density holds the first output of each sample, mapped into [0,1].
+	Density []float64
+	// Colors holds red, green and blue in [0,1] per sample, or nil when the
+	// network has no colour outputs.
+	Colors []float64
+}
+
+// SampleVolume evaluates a CPPN on a size³ grid. The first output is
+// density; any further outputs are coloured with cfg's colour model as a
+// 2D render would colour them. Only cfg's Precision, ColorModel and Palette
+// apply.
+func SampleVolume(plan *neat.Plan, size int, spec InputSpec3D, cfg RenderConfig) (*Volume, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("plan is nil")
+	}
+	if size < 2 {
+		return nil, fmt.Errorf("volume size must be >= 2")
+	}
+	if err := spec.Check(plan); err != nil {
+		return nil, err
+	}
+	v := &Volume{Size: size, Density: make([]float64, size*size*size)}
+	var paint painter
+	if extra := len(plan.Outputs) - 1; extra > 0 {
+		paint = cfg.painter(extra)
+		v.Colors = make([]float64, 3*len(v.Density))
+	}
+	s := newSampler(plan, cfg)
+	inputs := make([]float64, spec.Count())
+	for k := 0; k < size; k++ {
+		z := Coord(k, size)
+		for j := 0; j < size; j++ {
+			y := Coord(j, size)
+			for i := 0; i < size; i++ {
+				if err := spec.Fill(inputs, Coord(i, size), y, z); err != nil {
+					return nil, err
+				}
+				out, err := s.eval(inputs)
+				if err != nil {
+					return nil, err
+				}
+				idx := (k*size+j)*size + i
+				v.Density[idx] = unit(out[0])
+				if paint != nil {
+					c := paint(out[1:])
+					copy(v.Colors[3*idx:3*idx+3], c[:])
+				}
+			}
+		}
+	}
+	return v, nil
+}
+
+// at returns the density at (i, j, k); the volume is empty outside the grid.
+func (v *Volume) at(i, j, k int) float64 {
+	if i < 0 || j < 0 || k < 0 || i >= v.Size || j >= v.Size || k >= v.Size {
+		return 0
+	}
+	return v.Density[(k*v.Size+j)*v.Size+i]
+}
+
+// color returns the colour at (i, j, k), clamping to the grid.
+func (v *Volume) color(i, j, k int) [3]float64 {
+	clampIdx := func(n int) int { return max(0, min(v.Size-1, n)) }
+	idx := 3 * ((clampIdx(k)*v.Size+clampIdx(j))*v.Size + clampIdx(i))
+	return [3]float64{v.Colors[idx], v.Colors[idx+1], v.Colors[idx+2]}
+}
+
+// VolumeMetrics summarises the solid part of a volume.
+type VolumeMetrics struct {
+	// Fraction is the share of samples at or above the iso level.
+	Fraction float64
+	// SurfaceArea is the area of the iso-surface in [-1,1]³ units.
+	SurfaceArea float64
+	// Sphericity is the area of a sphere of the same volume divided by
+	// SurfaceArea: 1 for a ball, lower for detailed shapes.
+	Sphericity float64
+	// Components counts 6-connected solid pieces.
+	Components int
+	// Connectivity is the share of solid samples in the largest piece.
+	Connectivity float64
+}
+
+// ComputeVolumeMetrics measures v at the iso level.
+func ComputeVolumeMetrics(v *Volume, iso float64) VolumeMetrics {
+	var m VolumeMetrics
+	solid := 0
+	for _, d := range v.Density {
+		if d >= iso {
+			solid++
+		}
+	}
+	if solid == 0 {
+		return m
+	}
+	m.Fraction = float64(solid) / float64(len(v.Density))
+	m.SurfaceArea = MarchingCubes(v, iso).Area()
+	if m.SurfaceArea > 0 {
+		volume := 8 * m.Fraction
+		m.Sphericity = math.Cbrt(36*math.Pi*volume*volume) / m.SurfaceArea
+	}
+	var largest int
+	m.Components, largest = solidComponents(v, iso)
+	m.Connectivity = float64(largest) / float64(solid)
+	return m
+}
+
+// solidComponents returns the number of 6-connected solid components and
+// the size of the largest.
+func solidComponents(v *Volume, iso float64) (int, int) {
+	n := v.Size
+	seen := make([]bool, len(v.Density))
+	var stack []int
+	count, largest := 0, 0
+	for start, d := range v.Density {
+		if seen[start] || d < iso {
+			continue
+		}
+		count++
+		size := 0
+		seen[start] = true
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			idx := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			size++
+			i, j, k := idx%n, (idx/n)%n, idx/(n*n)
+			for _, off := range [6][3]int{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}} {
+				ni, nj, nk := i+off[0], j+off[1], k+off[2]
+				if ni < 0 || nj < 0 || nk < 0 || ni >= n || nj >= n || nk >= n {
+					continue
+				}
+				next := (nk*n+nj)*n + ni
+				if !seen[next] && v.Density[next] >= iso {
+					seen[next] = true
+					stack = append(stack, next)
+				}
+			}
+		}
+		largest = max(largest, size)
+	}
+	return count, largest
+}
+
+// VolumeWeights controls how volume metrics combine into a fitness.
+type VolumeWeights struct {
+	// Fraction rewards filling about 20% of the volume.
+	Fraction float64
+	// Detail rewards surfaces moderately more intricate than a ball.
+	Detail float64
+	// Connectivity rewards shapes that hold together in one piece.
+	Connectivity float64
+}
+
+// DefaultVolumeWeights favours connected, moderately detailed sculptures.
+func DefaultVolumeWeights() VolumeWeights {
+	return VolumeWeights{
+		Fraction:     0.3,
+		Detail:       0.3,
+		Connectivity: 0.4,
+	}
+}
+
+// ScoreVolume converts volume metrics to a fitness. Empty volumes score 0.
+func ScoreVolume(m VolumeMetrics, w VolumeWeights) float64 {
+	if m.Fraction == 0 {
+		return 0
+	}
+	return w.Fraction*targetScore(m.Fraction, 0.2, 0.15) +
+		w.Detail*targetScore(1-m.Sphericity, 0.5, 0.35) +
+		w.Connectivity*m.Connectivity
+}
+
+// VolumeFitness scores genomes by sampling a size³ volume and scoring it at
+// DefaultIso.
+func VolumeFitness(spec InputSpec3D, size int, cfg RenderConfig, weights VolumeWeights) neat.FitnessFunc {
+	return func(g *neat.Genome) (float64, error) {
+		plan, err := neat.BuildAcyclicPlan(*g, nil, nil)
+		if err != nil {
+			return 0, err
+		}
+		v, err := SampleVolume(plan, size, spec, cfg)
+		if err != nil {
+			return 0, err
+		}
+		return ScoreVolume(ComputeVolumeMetrics(v, DefaultIso), weights), nil
+	}
+}
@@ -0,0 +1,116 @@
+package cppn
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/zacharyburkett/image-zoo/pkg/neat"
+)
+
+func TestInputSpec3D(t *testing.T) {
+	spec := DefaultInputSpec3D()
+	if want := []string{"x", "y", "z", "distance", "bias"}; !reflect.DeepEqual(spec.Names(), want) {
+		t.Fatalf("expected names %v, got %v", want, spec.Names())
+	}
+	in := make([]float64, spec.Count())
+	if err := spec.Fill(in, 0.6, 0, -0.8); err != nil {
+		t.Fatalf("Fill error: %v", err)
+	}
+	if in[0] != 0.6 || in[2] != -0.8 || math.Abs(in[3]-1) > 1e-12 || in[4] != 1 {
+		t.Fatalf("unexpected inputs %v", in)
+	}
+	if err := spec.Fill(in[:2], 0, 0, 0); err == nil {
+		t.Fatalf("expected error for short input slice")
+	}
+}
+
+// ballGenome outputs density 1-distance and a constant red colour.
+func ballGenome() (neat.Genome, InputSpec3D) {
+	spec := InputSpec3D{UseDistance: true, UseBias: true}
+	g := neat.Genome{
+		Nodes: []neat.NodeGene{
+			{ID: 1, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 2, Kind: neat.NodeInput, Activation: neat.ActivationLinear},
+			{ID: 3, Kind: neat.NodeOutput, Activation: neat.ActivationLinear},
+			{ID: 4, Kind: neat.NodeOutput, Activation: neat.ActivationLinear},
+			{ID: 5, Kind: neat.NodeOutput, Activation: neat.ActivationLinear},
+			{ID: 6, Kind: neat.NodeOutput, Activation: neat.ActivationLinear},
+		},
+		Connections: []neat.ConnectionGene{
+			{Innovation: 1, In: 1, Out: 3, Weight: -1, Enabled: true},
+			{Innovation: 2, In: 2, Out: 3, Weight: 1, Enabled: true},
+			{Innovation: 3, In: 2, Out: 4, Weight: 1, Enabled: true},
+		},
+	}
+	return g, spec
+}
+
+func TestSampleVolume(t *testing.T) {
+	g, spec := ballGenome()
+	if err := spec.Label(&g); err != nil {
+		t.Fatalf("Label error: %v", err)
+	}
+	plan, err := neat.BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	v, err := SampleVolume(plan, 17, spec, DefaultRenderConfig())
+	if err != nil {
+		t.Fatalf("SampleVolume error: %v", err)
+	}
+	if d := v.at(8, 8, 8); d != 1 {
+		t.Fatalf("expected full density at the centre, got %v", d)
+	}
+	if d := v.at(0, 0, 0); d >= DefaultIso {
+		t.Fatalf("expected an empty corner, got %v", d)
+	}
+	if c := v.color(8, 8, 8); c != [3]float64{1, 0, 0} {
+		t.Fatalf("expected red, got %v", c)
+	}
+	if _, err := SampleVolume(plan, 17, DefaultInputSpec3D(), DefaultRenderConfig()); err == nil {
+		t.Fatalf("expected error for mismatched input spec")
+	}
+}
+
+func TestVolumeMetrics(t *testing.T) {
+	ball := ComputeVolumeMetrics(densityVolume(32, ballDensity(0, 0, 0, 0.5)), DefaultIso)
+	if want := 4.0 / 3 * math.Pi * 0.125 / 8; math.Abs(ball.Fraction-want) > 0.01 {
+		t.Fatalf("expected fraction near %v, got %v", want, ball.Fraction)
+	}
+	if ball.Sphericity < 0.9 || ball.Sphericity > 1.05 {
+		t.Fatalf("expected sphericity near 1, got %v", ball.Sphericity)
+	}
+	if ball.Components != 1 || ball.Connectivity != 1 {
+		t.Fatalf("expected one piece, got %d (%v)", ball.Components, ball.Connectivity)
+	}
+
+	left, right := ballDensity(-0.5, 0, 0, 0.3), ballDensity(0.5, 0, 0, 0.3)
+	pair := ComputeVolumeMetrics(densityVolume(32, func(x, y, z float64) float64 {
+		return math.Max(left(x, y, z), right(x, y, z))
+	}), DefaultIso)
+	if pair.Components != 2 || math.Abs(pair.Connectivity-0.5) > 1e-9 {
+		t.Fatalf("expected two equal pieces, got %d (%v)", pair.Components, pair.Connectivity)
+	}
+
+	w := VolumeWeights{Connectivity: 1}
+	if ScoreVolume(ball, w) <= ScoreVolume(pair, w) {
+		t.Fatalf("expected the connected shape to score higher")
+	}
+	empty := ComputeVolumeMetrics(densityVolume(8, func(x, y, z float64) float64 { return 0 }), DefaultIso)
+	if empty != (VolumeMetrics{}) || ScoreVolume(empty, DefaultVolumeWeights()) != 0 {
+		t.Fatalf("expected zero metrics and score for an empty volume, got %+v", empty)
+	}
+}
+
+func TestVolumeFitness(t *testing.T) {
+	g, spec := ballGenome()
+	fitness := VolumeFitness(spec, 12, DefaultRenderConfig(), DefaultVolumeWeights())
+	score, err := fitness(&g)
+	if err != nil {
+		t.Fatalf("fitness error: %v", err)
+	}
+	if score <= 0 {
+		t.Fatalf("expected a positive score, got %v", score)
+	}
+}
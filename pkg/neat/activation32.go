@@ -0,0 +1,132 @@
+package neat
+
+import "math"
+
+// Apply32 evaluates the activation function on x in single precision.
+// Transcendental functions use float32 polynomial approximations rather than
+// widening to float64; see docs/float32.md for the measured error bounds.
+func (a ActivationType) Apply32(x float32) float32 {
+	switch a {
+	case ActivationLinear:
+		return x
+	case ActivationSigmoid:
+		return 1 / (1 + exp32(-4.9*x))
+	case ActivationTanh:
+		return tanh32(x)
+	case ActivationRelu:
+		if x > 0 {
+			return x
+		}
+		return 0
+	case ActivationSin:
+		return sin32(x)
+	case ActivationCos:
+		return cos32(x)
+	case ActivationGaussian:
+		return exp32(-x * x)
+	case ActivationAbs:
+		if x < 0 {
+			return -x
+		}
+		return x
+	case ActivationSquare:
+		return x * x
+	default:
+		return x
+	}
+}
+
+const (
+	log2e32     float32 = 1.44269504088896341
+	ln2Hi32     float32 = 0.693145751953125
+	ln2Lo32     float32 = 1.428606765330187045e-06
+	pio2Hi32    float32 = 1.5707963705062866
+	pio2Lo32    float32 = -4.371139000186241e-08
+	twoOverPi32 float32 = 0.63661977236758134
+)
+
+// exp32 computes e^x using range reduction to [-ln2/2, ln2/2] and a
+// degree-6 Taylor polynomial.
+func exp32(x float32) float32 {
+	if x > 88 {
+		return float32(math.Inf(1))
+	}
+	if x < -87 {
+		return 0
+	}
+	k := round32(x * log2e32)
+	r := x - k*ln2Hi32 - k*ln2Lo32
+	p := 1 + r*(1+r*(1.0/2+r*(1.0/6+r*(1.0/24+r*(1.0/120+r*(1.0/720))))))
+	return p * math.Float32frombits(uint32(int32(k)+127)<<23)
+}
+
+// tanh32 computes tanh(x) via exp32, saturating where float32 cannot
+// distinguish the result from ±1.
+func tanh32(x float32) float32 {
+	if x > 9 {
+		return 1
+	}
+	if x < -9 {
+		return -1
+	}
+	if x > -0.125 && x < 0.125 {
+		x2 := x * x
+		return x * (1 + x2*(-1.0/3+x2*(2.0/15+x2*(-17.0/315))))
+	}
+	e := exp32(2 * x)
+	return (e - 1) / (e + 1)
+}
+
+func sin32(x float32) float32 {
+	r, q := reducePiOver2(x)
+	switch q {
+	case 0:
+		return sinKernel32(r)
+	case 1:
+		return cosKernel32(r)
+	case 2:
+		return -sinKernel32(r)
+	default:
+		return -cosKernel32(r)
+	}
+}
+
+func cos32(x float32) float32 {
+	r, q := reducePiOver2(x)
+	switch q {
+	case 0:
+		return cosKernel32(r)
+	case 1:
+		return -sinKernel32(r)
+	case 2:
+		return -cosKernel32(r)
+	default:
+		return sinKernel32(r)
+	}
+}
+
+// reducePiOver2 returns r in [-pi/4, pi/4] and quadrant q such that
+// x = r + (k*pi/2) with q = k mod 4.
+func reducePiOver2(x float32) (float32, int) {
+	k := round32(x * twoOverPi32)
+	r := x - k*pio2Hi32 - k*pio2Lo32
+	q := int(int64(k) & 3)
+	return r, q
+}
+
+func sinKernel32(r float32) float32 {
+	r2 := r * r
+	return r * (1 + r2*(-1.0/6+r2*(1.0/120+r2*(-1.0/5040))))
+}
+
+func cosKernel32(r float32) float32 {
+	r2 := r * r
+	return 1 + r2*(-1.0/2+r2*(1.0/24+r2*(-1.0/720+r2*(1.0/40320))))
+}
+
+func round32(x float32) float32 {
+	if x < 0 {
+		return float32(int64(x - 0.5))
+	}
+	return float32(int64(x + 0.5))
+}
@@ -0,0 +1,51 @@
+package neat
+
+import (
+	"math"
+	"testing"
+)
+
+func TestApply32MatchesApply(t *testing.T) {
+	activations := []ActivationType{
+		ActivationLinear,
+		ActivationSigmoid,
+		ActivationTanh,
+		ActivationRelu,
+		ActivationSin,
+		ActivationCos,
+		ActivationGaussian,
+		ActivationAbs,
+		ActivationSquare,
+	}
+	// Absolute tolerances over [-4, 4]; see docs/float32.md.
+	const n = 4001
+	for _, a := range activations {
+		tol := 1e-6
+		if a == ActivationSquare {
+			tol = 2e-6
+		}
+		for i := 0; i < n; i++ {
+			x := float32(-4 + 8*float64(i)/float64(n-1))
+			want := a.Apply(float64(x))
+			got := float64(a.Apply32(x))
+			if math.Abs(got-want) > tol {
+				t.Fatalf("%s(%v): got %v want %v", a, x, got, want)
+			}
+		}
+	}
+}
+
+func TestApply32Saturates(t *testing.T) {
+	if v := ActivationSigmoid.Apply32(-100); v != 0 {
+		t.Fatalf("expected sigmoid(-100) = 0, got %v", v)
+	}
+	if v := ActivationSigmoid.Apply32(100); v != 1 {
+		t.Fatalf("expected sigmoid(100) = 1, got %v", v)
+	}
+	if v := ActivationTanh.Apply32(20); v != 1 {
+		t.Fatalf("expected tanh(20) = 1, got %v", v)
+	}
+	if v := ActivationGaussian.Apply32(20); v != 0 {
+		t.Fatalf("expected gaussian(20) = 0, got %v", v)
+	}
+}
@@ -0,0 +1,305 @@
+package neat
+
+import (
+	"context"
+	"fmt"
+)
+
+// MatchFunc plays genome a against genome b and returns each side's score.
+// Higher is better; the side with the higher score is counted as the winner
+// for shared sampling.
+type MatchFunc func(ctx context.Context, a, b *Genome) (scoreA, scoreB float64, err error)
+
+// OpponentSampling chooses the opponents each genome plays.
+type OpponentSampling uint8
+
+const (
+	// SampleRandom plays random genomes of the opposing population
+	// (host/parasite sampling). Both genomes score from every match.
+	SampleRandom OpponentSampling = iota
+	// SampleShared plays a shared sample of the previous generation's
+	// opponents, picked greedily so that it covers the genomes those
+	// opponents beat (Rosin & Belew). The first generation samples randomly.
+	SampleShared
+)
+
+// CoevolutionConfig controls how matches are scheduled.
+type CoevolutionConfig struct {
+	Sampling OpponentSampling
+	// Opponents is the number of sampled opponents per genome.
+	Opponents int
+	// ArchiveOpponents is the number of extra opponents drawn from the
+	// opposing side's hall of fame, if it has one.
+	ArchiveOpponents int
+}
+
+// DefaultCoevolutionConfig returns random sampling of five opponents plus two
+// archived ones.
+func DefaultCoevolutionConfig() CoevolutionConfig {
+	return CoevolutionConfig{
+		Sampling:         SampleRandom,
+		Opponents:        5,
+		ArchiveOpponents: 2,
+	}
+}
+
+// CoevolutionRunner evolves genomes whose fitness is their mean score over
+// matches against other genomes. Hosts and Parasites are Runners supplying
+// each side's population, operators, reporters and hall of fame; their
+// fitness fields are ignored. With Parasites nil, hosts play each other.
+// Each side's HallOfFame doubles as the opponent archive for the other side.
+type CoevolutionRunner struct {
+	Hosts     *Runner
+	Parasites *Runner
+	Match     MatchFunc
+	Config    CoevolutionConfig
+
+	previous [][]Genome
+	beaten   [][][]opponentKey
+}
+
+// opponentKey identifies a genome across both sides.
+type opponentKey struct {
+	side int
+	id   GenomeID
+}
+
+type opponent struct {
+	genome *Genome
+	side   int
+	// index is the position in the side's current population, or -1 for
+	// archived and previous-generation opponents, which do not score.
+	index int
+}
+
+func (cr *CoevolutionRunner) sides() []*Runner {
+	if cr.Parasites == nil {
+		return []*Runner{cr.Hosts}
+	}
+	return []*Runner{cr.Hosts, cr.Parasites}
+}
+
+func (cr *CoevolutionRunner) otherSide(s int) int {
+	if cr.Parasites == nil {
+		return 0
+	}
+	return 1 - s
+}
+
+func (cr *CoevolutionRunner) validate() error {
+	if cr == nil {
+		return fmt.Errorf("coevolution runner is nil")
+	}
+	if cr.Match == nil {
+		return fmt.Errorf("match function is nil")
+	}
+	if cr.Config.Opponents <= 0 && cr.Config.ArchiveOpponents <= 0 {
+		return fmt.Errorf("at least one opponent per genome is required")
+	}
+	if cr.Config.Opponents < 0 || cr.Config.ArchiveOpponents < 0 {
+		return fmt.Errorf("opponent counts must be >= 0")
+	}
+	if cr.Config.Sampling != SampleRandom && cr.Config.Sampling != SampleShared {
+		return fmt.Errorf("unknown opponent sampling %d", cr.Config.Sampling)
+	}
+	for i, r := range cr.sides() {
+		if r == nil || r.Population == nil || len(r.Population.Genomes) == 0 {
+			return fmt.Errorf("side %d has no population", i)
+		}
+	}
+	if cr.Parasites == nil && len(cr.Hosts.Population.Genomes) < 2 && cr.Config.Opponents > 0 {
+		return fmt.Errorf("a single population needs at least two genomes")
+	}
+	return nil
+}
+
+// EvaluateContext plays this generation's matches and sets every genome's
+// fitness to its mean score. It returns the best host.
+func (cr *CoevolutionRunner) EvaluateContext(ctx context.Context) (Genome, error) {
+	if err := cr.validate(); err != nil {
+		return Genome{}, err
+	}
+	sides := cr.sides()
+	scores := make([][]float64, len(sides))
+	counts := make([][]int, len(sides))
+	beaten := make([][][]opponentKey, len(sides))
+	for s, r := range sides {
+		scores[s] = make([]float64, len(r.Population.Genomes))
+		counts[s] = make([]int, len(r.Population.Genomes))
+		beaten[s] = make([][]opponentKey, len(r.Population.Genomes))
+	}
+
+	for s, r := range sides {
+		for i := range r.Population.Genomes {
+			for _, opp := range cr.opponents(s, i) {
+				if err := ctx.Err(); err != nil {
+					return Genome{}, err
+				}
+				self := &r.Population.Genomes[i]
+				a, b, err := cr.Match(ctx, self, opp.genome)
+				if err != nil {
+					return Genome{}, err
+				}
+				scores[s][i] += a
+				counts[s][i]++
+				if a > b {
+					beaten[s][i] = append(beaten[s][i], opponentKey{side: opp.side, id: opp.genome.ID})
+				}
+				if opp.index >= 0 {
+					scores[opp.side][opp.index] += b
+					counts[opp.side][opp.index]++
+					if b > a {
+						beaten[opp.side][opp.index] = append(beaten[opp.side][opp.index], opponentKey{side: s, id: self.ID})
+					}
+				}
+			}
+		}
+	}
+
+	cr.previous = make([][]Genome, len(sides))
+	for s, r := range sides {
+		cr.previous[s] = make([]Genome, len(r.Population.Genomes))
+		for i := range r.Population.Genomes {
+			g := &r.Population.Genomes[i]
+			g.Fitness = 0
+			if counts[s][i] > 0 {
+				g.Fitness = scores[s][i] / float64(counts[s][i])
+			}
+			cr.previous[s][i] = cloneGenome(*g)
+		}
+	}
+	cr.beaten = beaten
+	return topGenomes(cr.Hosts.Population.Genomes, 1)[0], nil
+}
+
+// opponents samples the opponents genome i of side s plays this generation.
+func (cr *CoevolutionRunner) opponents(s, i int) []opponent {
+	other := cr.otherSide(s)
+	r := cr.sides()[s]
+	rng := r.Population.RNG
+	var out []opponent
+
+	if cr.Config.Sampling == SampleShared && cr.previous != nil {
+		for _, idx := range sharedSample(cr.beaten[other], cr.Config.Opponents) {
+			out = append(out, opponent{genome: &cr.previous[other][idx], side: other, index: -1})
+		}
+	} else {
+		pool := cr.sides()[other].Population.Genomes
+		candidates := make([]int, 0, len(pool))
+		for j := range pool {
+			if other == s && j == i {
+				continue
+			}
+			candidates = append(candidates, j)
+		}
+		for k := 0; k < cr.Config.Opponents && len(candidates) > 0; k++ {
+			pick := rng.Intn(len(candidates))
+			out = append(out, opponent{genome: &pool[candidates[pick]], side: other, index: candidates[pick]})
+			candidates = append(candidates[:pick], candidates[pick+1:]...)
+		}
+	}
+
+	if hof := cr.sides()[other].HallOfFame; hof != nil && hof.Len() > 0 {
+		archive := hof.Genomes()
+		for k := 0; k < cr.Config.ArchiveOpponents; k++ {
+			g := archive[rng.Intn(len(archive))]
+			out = append(out, opponent{genome: &g, side: other, index: -1})
+		}
+	}
+	return out
+}
+
+// sharedSample greedily picks up to n genomes, each time taking the one whose
+// victories are least covered by the genomes already picked: a victory over
+// h is worth 1/(1+number of picked genomes that also beat h).
+func sharedSample(beaten [][]opponentKey, n int) []int {
+	covered := map[opponentKey]int{}
+	picked := make([]bool, len(beaten))
+	var out []int
+	for len(out) < n && len(out) < len(beaten) {
+		best, bestScore := -1, -1.0
+		for j, wins := range beaten {
+			if picked[j] {
+				continue
+			}
+			score := 0.0
+			for _, h := range wins {
+				score += 1 / float64(1+covered[h])
+			}
+			if score > bestScore {
+				best, bestScore = j, score
+			}
+		}
+		picked[best] = true
+		out = append(out, best)
+		for _, h := range beaten[best] {
+			covered[h]++
+		}
+	}
+	return out
+}
+
+// Run coevolves for up to maxGenerations and stops early once the best host
+// reaches targetFitness. It returns the best host and the generation reached.
+func (cr *CoevolutionRunner) Run(maxGenerations int, targetFitness float64) (Genome, int, error) {
+	return cr.RunContext(context.Background(), maxGenerations, targetFitness)
+}
+
+// RunContext is Run with cancellation. Because fitness is relative to the
+// current opponents, the returned host is the best of the final generation
+// evaluated, not of the whole run.
+func (cr *CoevolutionRunner) RunContext(ctx context.Context, maxGenerations int, targetFitness float64) (Genome, int, error) {
+	if maxGenerations <= 0 {
+		return Genome{}, 0, fmt.Errorf("maxGenerations must be > 0")
+	}
+	if err := cr.validate(); err != nil {
+		return Genome{}, 0, err
+	}
+	best := Genome{}
+	for gen := 0; gen < maxGenerations; gen++ {
+		if err := ctx.Err(); err != nil {
+			return best, gen, err
+		}
+		for _, r := range cr.sides() {
+			if err := r.reportStart(); err != nil {
+				return Genome{}, gen, err
+			}
+		}
+		current, err := cr.EvaluateContext(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return best, gen, err
+			}
+			return Genome{}, gen, err
+		}
+		best = current
+
+		sides := cr.sides()
+		stats := make([]GenerationStats, len(sides))
+		for s, r := range sides {
+			if r.HallOfFame != nil {
+				r.HallOfFame.Update(r.Population.Genomes)
+			}
+			if stats[s], err = r.speciateAndReport(); err != nil {
+				return Genome{}, gen, err
+			}
+		}
+		if best.Fitness >= targetFitness || gen == maxGenerations-1 {
+			for s, r := range sides {
+				if err := r.reportEnd(stats[s]); err != nil {
+					return Genome{}, gen, err
+				}
+			}
+			return best, gen, nil
+		}
+		for s, r := range sides {
+			if err := r.reproduce(); err != nil {
+				return Genome{}, gen, err
+			}
+			if err := r.reportEnd(stats[s]); err != nil {
+				return Genome{}, gen, err
+			}
+		}
+	}
+	return best, maxGenerations - 1, nil
+}
@@ -0,0 +1,131 @@
+package neat
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func coevolutionRunner(t *testing.T, inputs, size int, seed int64) *Runner {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Inputs = inputs
+	cfg.Outputs = 1
+	cfg.PopulationSize = size
+	pop, err := cfg.NewPopulation(NewRand(seed))
+	if err != nil {
+		t.Fatalf("NewPopulation error: %v", err)
+	}
+	return &Runner{Population: pop, Mutation: cfg.Mutation, Reproduction: cfg.Reproduction}
+}
+
+func weightSum(g *Genome) float64 {
+	sum := 0.0
+	for _, c := range g.Connections {
+		sum += c.Weight
+	}
+	return sum
+}
+
+func TestCoevolutionHostParasiteScores(t *testing.T) {
+	hosts := coevolutionRunner(t, 1, 6, 1)
+	parasites := coevolutionRunner(t, 2, 5, 2)
+	matches := 0
+	cr := CoevolutionRunner{
+		Hosts:     hosts,
+		Parasites: parasites,
+		Config:    CoevolutionConfig{Sampling: SampleRandom, Opponents: 2},
+		Match: func(_ context.Context, a, b *Genome) (float64, float64, error) {
+			if len(a.Nodes) == len(b.Nodes) {
+				t.Fatalf("match between genomes of the same side")
+			}
+			matches++
+			return weightSum(a), weightSum(b), nil
+		},
+	}
+	best, err := cr.EvaluateContext(context.Background())
+	if err != nil {
+		t.Fatalf("EvaluateContext error: %v", err)
+	}
+	if matches != 2*(6+5) {
+		t.Fatalf("expected 22 matches, got %d", matches)
+	}
+	for _, side := range []*Runner{hosts, parasites} {
+		for i := range side.Population.Genomes {
+			g := &side.Population.Genomes[i]
+			if math.Abs(g.Fitness-weightSum(g)) > 1e-12 {
+				t.Fatalf("fitness %v is not the mean score %v", g.Fitness, weightSum(g))
+			}
+		}
+	}
+	if best.Fitness != topGenomes(hosts.Population.Genomes, 1)[0].Fitness {
+		t.Fatalf("best host not returned")
+	}
+}
+
+func TestSharedSampleCoversDistinctWins(t *testing.T) {
+	h := func(id GenomeID) opponentKey { return opponentKey{id: id} }
+	beaten := [][]opponentKey{
+		{h(1), h(2)},
+		{h(1), h(2)},
+		{h(3), h(4)},
+		{},
+	}
+	got := sharedSample(beaten, 2)
+	if len(got) != 2 || got[0] != 0 || got[1] != 2 {
+		t.Fatalf("expected sample [0 2], got %v", got)
+	}
+	if got := sharedSample(beaten, 10); len(got) != 4 {
+		t.Fatalf("sample should be capped at the population, got %v", got)
+	}
+}
+
+func TestCoevolutionSharedSamplingAndArchive(t *testing.T) {
+	hosts := coevolutionRunner(t, 2, 8, 3)
+	hosts.HallOfFame = NewHallOfFame(4)
+	var archived int
+	cr := CoevolutionRunner{
+		Hosts:  hosts,
+		Config: CoevolutionConfig{Sampling: SampleShared, Opponents: 3, ArchiveOpponents: 1},
+		Match: func(_ context.Context, a, b *Genome) (float64, float64, error) {
+			if a == b {
+				t.Fatalf("genome played itself")
+			}
+			for _, g := range hosts.Population.Genomes {
+				if g.ID == b.ID {
+					return weightSum(a), weightSum(b), nil
+				}
+			}
+			archived++
+			return weightSum(a), weightSum(b), nil
+		},
+	}
+	_, gen, err := cr.Run(4, math.Inf(1))
+	if err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	if gen != 3 {
+		t.Fatalf("expected 4 generations, stopped at %d", gen)
+	}
+	if hosts.HallOfFame.Len() == 0 {
+		t.Fatalf("archive was not filled")
+	}
+	if archived == 0 {
+		t.Fatalf("no matches against archived or previous-generation opponents")
+	}
+	if len(cr.previous) != 1 || len(cr.previous[0]) != 8 {
+		t.Fatalf("previous generation not recorded")
+	}
+}
+
+func TestCoevolutionValidate(t *testing.T) {
+	cr := CoevolutionRunner{Hosts: coevolutionRunner(t, 1, 4, 1), Config: DefaultCoevolutionConfig()}
+	if _, err := cr.EvaluateContext(context.Background()); err == nil {
+		t.Fatalf("expected error without match function")
+	}
+	cr.Match = func(context.Context, *Genome, *Genome) (float64, float64, error) { return 0, 0, nil }
+	cr.Config.Sampling = 7
+	if _, err := cr.EvaluateContext(context.Background()); err == nil {
+		t.Fatalf("expected error for unknown sampling")
+	}
+}
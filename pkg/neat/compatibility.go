@@ -4,10 +4,10 @@ import "sort"
 
 // DistanceConfig controls compatibility distance calculation.
 type DistanceConfig struct {
-	ExcessCoeff            float64
-	DisjointCoeff          float64
-	WeightCoeff            float64
-	NormalizationThreshold int
+	ExcessCoeff            float64 `json:"excess_coeff"`
+	DisjointCoeff          float64 `json:"disjoint_coeff"`
+	WeightCoeff            float64 `json:"weight_coeff"`
+	NormalizationThreshold int     `json:"normalization_threshold"`
 }
 
 // DefaultDistanceConfig returns common NEAT coefficients.
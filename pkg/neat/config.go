@@ -0,0 +1,193 @@
+package neat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Config describes a complete experiment: network shape, run limits and the
+// population, mutation and reproduction settings. It is the native Image Zoo
+// config file format (JSON); omitted fields keep their defaults.
+type Config struct {
+	Seed             int64              `json:"seed"`
+	PopulationSize   int                `json:"population_size"`
+	Inputs           int                `json:"inputs"`
+	Outputs          int                `json:"outputs"`
+	OutputActivation ActivationType     `json:"output_activation"`
+	MaxGenerations   int                `json:"max_generations"`
+	FitnessThreshold float64            `json:"fitness_threshold"`
+	Population       PopulationConfig   `json:"population"`
+	Mutation         MutationConfig     `json:"mutation"`
+	Reproduction     ReproductionConfig `json:"reproduction"`
+}
+
+// DefaultConfig returns default run settings. Inputs and Outputs are left at
+// zero and must be set by the experiment.
+func DefaultConfig() Config {
+	return Config{
+		Seed:             1,
+		PopulationSize:   150,
+		OutputActivation: ActivationSigmoid,
+		MaxGenerations:   100,
+		FitnessThreshold: 1.0,
+		Population:       DefaultPopulationConfig(),
+		Mutation:         DefaultMutationConfig(),
+		Reproduction:     DefaultReproductionConfig(),
+	}
+}
+
+// Validate reports the first setting that cannot produce a working run.
+func (c Config) Validate() error {
+	if c.PopulationSize <= 0 {
+		return fmt.Errorf("population_size must be > 0")
+	}
+	if c.Inputs <= 0 {
+		return fmt.Errorf("inputs must be > 0")
+	}
+	if c.Outputs <= 0 {
+		return fmt.Errorf("outputs must be > 0")
+	}
+	if c.MaxGenerations <= 0 {
+		return fmt.Errorf("max_generations must be > 0")
+	}
+	if c.Population.CompatibilityThreshold <= 0 {
+		return fmt.Errorf("population.compatibility_threshold must be > 0")
+	}
+	if err := validateProbabilities("mutation", []probability{
+		{"add_connection_prob", c.Mutation.AddConnectionProb},
+		{"add_node_prob", c.Mutation.AddNodeProb},
+		{"weight_mutate_prob", c.Mutation.WeightMutateProb},
+		{"weight_perturb_prob", c.Mutation.WeightPerturbProb},
+		{"bias_mutate_prob", c.Mutation.BiasMutateProb},
+		{"bias_perturb_prob", c.Mutation.BiasPerturbProb},
+		{"toggle_enable_prob", c.Mutation.ToggleEnableProb},
+		{"activation_mutate_prob", c.Mutation.ActivationMutateProb},
+	}); err != nil {
+		return err
+	}
+	if err := validateProbabilities("reproduction", []probability{
+		{"survival_threshold", c.Reproduction.SurvivalThreshold},
+		{"crossover_prob", c.Reproduction.CrossoverProb},
+		{"interspecies_mate_prob", c.Reproduction.InterspeciesMateProb},
+	}); err != nil {
+		return err
+	}
+	if c.Mutation.WeightInitRange < 0 || c.Mutation.WeightPerturbScale < 0 || c.Mutation.WeightResetScale < 0 {
+		return fmt.Errorf("mutation weight ranges must be >= 0")
+	}
+	if c.Mutation.BiasPerturbScale < 0 || c.Mutation.BiasResetScale < 0 {
+		return fmt.Errorf("mutation bias ranges must be >= 0")
+	}
+	if c.Reproduction.Elitism < 0 {
+		return fmt.Errorf("reproduction.elitism must be >= 0")
+	}
+	for _, a := range c.Mutation.AllowedActivations {
+		if _, ok := activationNames[a]; !ok {
+			return fmt.Errorf("mutation.allowed_activations contains unknown %s", a)
+		}
+	}
+	if _, ok := activationNames[c.OutputActivation]; !ok {
+		return fmt.Errorf("unknown output_activation %s", c.OutputActivation)
+	}
+	return nil
+}
+
+// probability names a setting that must lie in [0, 1].
+type probability struct {
+	name  string
+	value float64
+}
+
+// validateProbabilities reports the first value outside [0, 1], in order.
+func validateProbabilities(section string, values []probability) error {
+	for _, p := range values {
+		if p.value < 0 || p.value > 1 {
+			return fmt.Errorf("%s.%s must be within [0, 1], got %v", section, p.name, p.value)
+		}
+	}
+	return nil
+}
+
+// LoadConfig reads a native JSON config on top of DefaultConfig and validates it.
+// Unknown fields are rejected so typos do not silently fall back to defaults.
+func LoadConfig(r io.Reader) (Config, error) {
+	cfg := DefaultConfig()
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// LoadConfigFile loads a config by file extension: .json is the native
+// format, .ini and .cfg are read as neat-python configs. Warnings list
+// neat-python settings that were ignored or approximated.
+func LoadConfigFile(path string) (Config, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		cfg, err := LoadConfig(bytes.NewReader(data))
+		return cfg, nil, err
+	case ".ini", ".cfg", "":
+		return LoadNEATPythonConfig(bytes.NewReader(data))
+	default:
+		return Config{}, nil, fmt.Errorf("unsupported config extension %q", filepath.Ext(path))
+	}
+}
+
+// WriteConfig writes cfg in the native JSON format.
+func WriteConfig(w io.Writer, cfg Config) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cfg)
+}
+
+// NewPopulation creates a population of minimal genomes described by the config.
+func (c Config) NewPopulation(rng RNG) (*Population, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	tracker, err := NewInnovationTracker(nil)
+	if err != nil {
+		return nil, err
+	}
+	genomes := make([]Genome, 0, c.PopulationSize)
+	for i := 0; i < c.PopulationSize; i++ {
+		g, err := NewMinimalGenome(c.Inputs, c.Outputs, c.OutputActivation, rng, tracker, c.Mutation.WeightInitRange)
+		if err != nil {
+			return nil, err
+		}
+		genomes = append(genomes, g)
+	}
+	return NewPopulation(rng, c.Population, genomes)
+}
+
+// NewIslands creates n populations for an IslandRunner. Each island gets its
+// own RNG stream derived from Seed.
+func (c Config) NewIslands(n int) ([]*Population, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("island count must be > 0")
+	}
+	seeds := NewRand(c.Seed)
+	pops := make([]*Population, n)
+	for i := range pops {
+		pop, err := c.NewPopulation(NewRand(seeds.Int63()))
+		if err != nil {
+			return nil, err
+		}
+		pops[i] = pop
+	}
+	return pops, nil
+}
@@ -0,0 +1,419 @@
+package neat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// neatPythonActivations maps neat-python activation names onto ours.
+// neat-python's sigmoid is 1/(1+exp(-5x)); ours uses the NEAT paper's 4.9.
+var neatPythonActivations = map[string]ActivationType{
+	"identity": ActivationLinear,
+	"sigmoid":  ActivationSigmoid,
+	"tanh":     ActivationTanh,
+	"relu":     ActivationRelu,
+	"sin":      ActivationSin,
+	"gauss":    ActivationGaussian,
+	"abs":      ActivationAbs,
+	"square":   ActivationSquare,
+}
+
+var neatPythonActivationNames = func() map[ActivationType]string {
+	out := make(map[ActivationType]string, len(neatPythonActivations))
+	for k, v := range neatPythonActivations {
+		out[v] = k
+	}
+	return out
+}()
+
+// iniFile holds parsed INI sections as lower-cased key/value maps.
+type iniFile map[string]map[string]string
+
+// parseINI reads the configparser subset used by neat-python: [sections],
+// key = value or key: value pairs, '#'/';' comments and indented
+// continuation lines.
+func parseINI(r io.Reader) (iniFile, error) {
+	out := iniFile{}
+	scanner := bufio.NewScanner(r)
+	section := ""
+	lastKey := ""
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := scanner.Text()
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			if !strings.HasSuffix(trimmed, "]") {
+				return nil, fmt.Errorf("line %d: malformed section header %q", line, trimmed)
+			}
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			if _, ok := out[section]; !ok {
+				out[section] = map[string]string{}
+			}
+			lastKey = ""
+			continue
+		}
+		if section == "" {
+			return nil, fmt.Errorf("line %d: key outside of a section", line)
+		}
+		if (raw[0] == ' ' || raw[0] == '\t') && lastKey != "" {
+			out[section][lastKey] += " " + trimmed
+			continue
+		}
+		idx := strings.IndexAny(trimmed, "=:")
+		if idx <= 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", line)
+		}
+		key := strings.ToLower(strings.TrimSpace(trimmed[:idx]))
+		out[section][key] = strings.TrimSpace(trimmed[idx+1:])
+		lastKey = key
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// neatPythonLoader applies neat-python settings onto a Config.
+type neatPythonLoader struct {
+	cfg      Config
+	warnings []string
+	section  string
+	key      string
+	value    string
+}
+
+func (l *neatPythonLoader) warnf(format string, args ...any) {
+	l.warnings = append(l.warnings, fmt.Sprintf("[%s] %s: ", l.section, l.key)+fmt.Sprintf(format, args...))
+}
+
+func (l *neatPythonLoader) parseFloat() (float64, error) {
+	v, err := strconv.ParseFloat(l.value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("[%s] %s: invalid number %q", l.section, l.key, l.value)
+	}
+	return v, nil
+}
+
+func (l *neatPythonLoader) parseInt() (int, error) {
+	v, err := strconv.Atoi(l.value)
+	if err != nil {
+		return 0, fmt.Errorf("[%s] %s: invalid integer %q", l.section, l.key, l.value)
+	}
+	return v, nil
+}
+
+func (l *neatPythonLoader) parseBool() (bool, error) {
+	switch strings.ToLower(l.value) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("[%s] %s: invalid boolean %q", l.section, l.key, l.value)
+}
+
+// ignoreUnless warns when a setting we cannot honour differs from the value
+// that matches our behaviour.
+func (l *neatPythonLoader) ignoreUnless(neutral ...string) {
+	for _, n := range neutral {
+		if strings.EqualFold(l.value, n) {
+			return
+		}
+		if a, errA := strconv.ParseFloat(l.value, 64); errA == nil {
+			if b, errB := strconv.ParseFloat(n, 64); errB == nil && a == b {
+				return
+			}
+		}
+	}
+	l.warnf("not supported, ignoring value %q", l.value)
+}
+
+// uniformScale converts a Gaussian standard deviation into the half-width of
+// a uniform distribution with the same variance.
+func uniformScale(stdev float64) float64 {
+	return stdev * math.Sqrt(3)
+}
+
+// LoadNEATPythonConfig reads a neat-python INI config and maps it onto a
+// Config. Gaussian init/mutation powers are converted to uniform ranges with
+// the same variance. Settings with no equivalent are reported as warnings;
+// settings that would change the network semantics (recurrent networks,
+// unknown activations only) are errors.
+func LoadNEATPythonConfig(r io.Reader) (Config, []string, error) {
+	ini, err := parseINI(r)
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	l := &neatPythonLoader{cfg: DefaultConfig()}
+	// neat-python always normalises distance by the larger genome.
+	l.cfg.Population.NormalizationThreshold = 0
+
+	handlers := map[string]map[string]func() error{
+		"NEAT":                neatPythonNEATSection(l),
+		"DefaultGenome":       neatPythonGenomeSection(l),
+		"DefaultSpeciesSet":   neatPythonSpeciesSection(l),
+		"DefaultStagnation":   neatPythonStagnationSection(l),
+		"DefaultReproduction": neatPythonReproductionSection(l),
+	}
+
+	// neat-python separates "rate" and "replace rate"; collect both before
+	// deriving our combined probabilities.
+	weightRates := [2]float64{0.8, 0.1}
+	biasRates := [2]float64{0.7, 0.1}
+	handlers["DefaultGenome"]["weight_mutate_rate"] = func() error { v, err := l.parseFloat(); weightRates[0] = v; return err }
+	handlers["DefaultGenome"]["weight_replace_rate"] = func() error { v, err := l.parseFloat(); weightRates[1] = v; return err }
+	handlers["DefaultGenome"]["bias_mutate_rate"] = func() error { v, err := l.parseFloat(); biasRates[0] = v; return err }
+	handlers["DefaultGenome"]["bias_replace_rate"] = func() error { v, err := l.parseFloat(); biasRates[1] = v; return err }
+
+	if _, ok := ini["NEAT"]; !ok {
+		return Config{}, nil, fmt.Errorf("missing [NEAT] section")
+	}
+	if _, ok := ini["DefaultGenome"]; !ok {
+		return Config{}, nil, fmt.Errorf("missing [DefaultGenome] section")
+	}
+
+	sections := make([]string, 0, len(ini))
+	for name := range ini {
+		sections = append(sections, name)
+	}
+	sort.Strings(sections)
+	for _, name := range sections {
+		l.section = name
+		section, known := handlers[name]
+		keys := make([]string, 0, len(ini[name]))
+		for k := range ini[name] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		if !known {
+			l.key = "*"
+			l.warnf("unknown section, ignoring %d keys", len(keys))
+			continue
+		}
+		for _, key := range keys {
+			l.key = key
+			l.value = ini[name][key]
+			handler, ok := section[key]
+			if !ok {
+				l.warnf("unknown key, ignoring")
+				continue
+			}
+			if err := handler(); err != nil {
+				return Config{}, nil, err
+			}
+		}
+	}
+
+	l.cfg.Mutation.WeightMutateProb, l.cfg.Mutation.WeightPerturbProb = combineRates(weightRates)
+	l.cfg.Mutation.BiasMutateProb, l.cfg.Mutation.BiasPerturbProb = combineRates(biasRates)
+
+	if err := l.cfg.Validate(); err != nil {
+		return Config{}, l.warnings, err
+	}
+	return l.cfg, l.warnings, nil
+}
+
+// combineRates converts neat-python's independent perturb and replace rates
+// into a mutate probability and the share of mutations that perturb.
+func combineRates(rates [2]float64) (mutate, perturb float64) {
+	mutate = math.Min(1, rates[0]+rates[1])
+	if mutate == 0 {
+		return 0, 1
+	}
+	return mutate, math.Min(1, rates[0]/(rates[0]+rates[1]))
+}
+
+func neatPythonNEATSection(l *neatPythonLoader) map[string]func() error {
+	return map[string]func() error{
+		"pop_size": func() error {
+			v, err := l.parseInt()
+			l.cfg.PopulationSize = v
+			return err
+		},
+		"fitness_threshold": func() error {
+			v, err := l.parseFloat()
+			l.cfg.FitnessThreshold = v
+			return err
+		},
+		"fitness_criterion":      func() error { l.ignoreUnless("max"); return nil },
+		"no_fitness_termination": func() error { l.ignoreUnless("false"); return nil },
+		"reset_on_extinction":    func() error { l.ignoreUnless("false"); return nil },
+	}
+}
+
+func neatPythonGenomeSection(l *neatPythonLoader) map[string]func() error {
+	unsupported := func(neutral ...string) func() error {
+		return func() error { l.ignoreUnless(neutral...); return nil }
+	}
+	return map[string]func() error{
+		"num_inputs": func() error {
+			v, err := l.parseInt()
+			l.cfg.Inputs = v
+			return err
+		},
+		"num_outputs": func() error {
+			v, err := l.parseInt()
+			l.cfg.Outputs = v
+			return err
+		},
+		"num_hidden": unsupported("0"),
+		"feed_forward": func() error {
+			v, err := l.parseBool()
+			if err != nil {
+				return err
+			}
+			if !v {
+				return fmt.Errorf("[%s] %s: recurrent networks are not supported", l.section, l.key)
+			}
+			return nil
+		},
+		"initial_connection": unsupported("full", "full_direct", "full_nodirect"),
+		"activation_default": func() error {
+			act, ok := neatPythonActivations[strings.ToLower(l.value)]
+			if !ok {
+				return fmt.Errorf("[%s] %s: unsupported activation %q", l.section, l.key, l.value)
+			}
+			l.cfg.OutputActivation = act
+			return nil
+		},
+		"activation_mutate_rate": func() error {
+			v, err := l.parseFloat()
+			l.cfg.Mutation.ActivationMutateProb = v
+			return err
+		},
+		"activation_options": func() error {
+			var allowed []ActivationType
+			for _, name := range strings.Fields(l.value) {
+				act, ok := neatPythonActivations[strings.ToLower(name)]
+				if !ok {
+					l.warnf("unsupported activation %q dropped", name)
+					continue
+				}
+				allowed = append(allowed, act)
+			}
+			if len(allowed) == 0 {
+				return fmt.Errorf("[%s] %s: no supported activations in %q", l.section, l.key, l.value)
+			}
+			l.cfg.Mutation.AllowedActivations = allowed
+			return nil
+		},
+		"aggregation_default":     unsupported("sum"),
+		"aggregation_mutate_rate": unsupported("0"),
+		"aggregation_options":     unsupported("sum"),
+		"bias_init_mean":          unsupported("0"),
+		"bias_init_stdev": func() error {
+			v, err := l.parseFloat()
+			l.cfg.Mutation.BiasResetScale = uniformScale(v)
+			return err
+		},
+		"bias_init_type": unsupported("gaussian", "uniform"),
+		"bias_max_value": unsupported(),
+		"bias_min_value": unsupported(),
+		"bias_mutate_power": func() error {
+			v, err := l.parseFloat()
+			l.cfg.Mutation.BiasPerturbScale = uniformScale(v)
+			return err
+		},
+		"compatibility_disjoint_coefficient": func() error {
+			v, err := l.parseFloat()
+			l.cfg.Population.ExcessCoeff = v
+			l.cfg.Population.DisjointCoeff = v
+			return err
+		},
+		"compatibility_weight_coefficient": func() error {
+			v, err := l.parseFloat()
+			l.cfg.Population.WeightCoeff = v
+			return err
+		},
+		"conn_add_prob": func() error {
+			v, err := l.parseFloat()
+			l.cfg.Mutation.AddConnectionProb = v
+			return err
+		},
+		"conn_delete_prob": unsupported("0"),
+		"node_add_prob": func() error {
+			v, err := l.parseFloat()
+			l.cfg.Mutation.AddNodeProb = v
+			return err
+		},
+		"node_delete_prob":          unsupported("0"),
+		"enabled_default":           unsupported("true"),
+		"enabled_rate_to_true_add":  unsupported("0"),
+		"enabled_rate_to_false_add": unsupported("0"),
+		"enabled_mutate_rate": func() error {
+			v, err := l.parseFloat()
+			l.cfg.Mutation.ToggleEnableProb = v
+			return err
+		},
+		"response_init_mean":         unsupported("1"),
+		"response_init_stdev":        unsupported("0"),
+		"response_init_type":         unsupported("gaussian", "uniform"),
+		"response_max_value":         unsupported(),
+		"response_min_value":         unsupported(),
+		"response_mutate_power":      unsupported("0"),
+		"response_mutate_rate":       unsupported("0"),
+		"response_replace_rate":      unsupported("0"),
+		"single_structural_mutation": unsupported("false"),
+		"structural_mutation_surer":  unsupported("default", "false"),
+		"weight_init_mean":           unsupported("0"),
+		"weight_init_stdev": func() error {
+			v, err := l.parseFloat()
+			l.cfg.Mutation.WeightInitRange = uniformScale(v)
+			l.cfg.Mutation.WeightResetScale = uniformScale(v)
+			return err
+		},
+		"weight_init_type": unsupported("gaussian", "uniform"),
+		"weight_max_value": unsupported(),
+		"weight_min_value": unsupported(),
+		"weight_mutate_power": func() error {
+			v, err := l.parseFloat()
+			l.cfg.Mutation.WeightPerturbScale = uniformScale(v)
+			return err
+		},
+	}
+}
+
+func neatPythonSpeciesSection(l *neatPythonLoader) map[string]func() error {
+	return map[string]func() error{
+		"compatibility_threshold": func() error {
+			v, err := l.parseFloat()
+			l.cfg.Population.CompatibilityThreshold = v
+			return err
+		},
+	}
+}
+
+func neatPythonStagnationSection(l *neatPythonLoader) map[string]func() error {
+	unsupported := func() error { l.warnf("species stagnation is not supported, ignoring value %q", l.value); return nil }
+	return map[string]func() error{
+		"species_fitness_func": unsupported,
+		"max_stagnation":       unsupported,
+		"species_elitism":      unsupported,
+	}
+}
+
+func neatPythonReproductionSection(l *neatPythonLoader) map[string]func() error {
+	return map[string]func() error{
+		"elitism": func() error {
+			v, err := l.parseInt()
+			l.cfg.Reproduction.Elitism = v
+			return err
+		},
+		"survival_threshold": func() error {
+			v, err := l.parseFloat()
+			l.cfg.Reproduction.SurvivalThreshold = v
+			return err
+		},
+		"min_species_size": func() error { l.ignoreUnless("1", "2"); return nil },
+	}
+}
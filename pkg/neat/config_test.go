@@ -0,0 +1,145 @@
+package neat
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigAppliesDefaults(t *testing.T) {
+	cfg, err := LoadConfig(strings.NewReader(`{
+		"inputs": 3,
+		"outputs": 1,
+		"population_size": 40,
+		"mutation": {"add_node_prob": 0.2, "allowed_activations": ["sin", "gaussian"]},
+		"population": {"compatibility_threshold": 2.5}
+	}`))
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.PopulationSize != 40 || cfg.Inputs != 3 || cfg.Outputs != 1 {
+		t.Fatalf("unexpected run settings %+v", cfg)
+	}
+	if cfg.Mutation.AddNodeProb != 0.2 || cfg.Mutation.WeightMutateProb != DefaultMutationConfig().WeightMutateProb {
+		t.Fatalf("expected overrides on top of defaults, got %+v", cfg.Mutation)
+	}
+	if len(cfg.Mutation.AllowedActivations) != 2 || cfg.Mutation.AllowedActivations[1] != ActivationGaussian {
+		t.Fatalf("unexpected activations %v", cfg.Mutation.AllowedActivations)
+	}
+	if cfg.Population.CompatibilityThreshold != 2.5 || cfg.Population.ExcessCoeff != 1.0 {
+		t.Fatalf("unexpected population config %+v", cfg.Population)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteConfig(buf, cfg); err != nil {
+		t.Fatalf("WriteConfig error: %v", err)
+	}
+	again, err := LoadConfig(buf)
+	if err != nil {
+		t.Fatalf("LoadConfig round trip error: %v", err)
+	}
+	if again.Population.CompatibilityThreshold != 2.5 || again.Mutation.AddNodeProb != 0.2 {
+		t.Fatalf("round trip lost settings: %+v", again)
+	}
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	cases := map[string]string{
+		"unknown field":   `{"inputs": 1, "outputs": 1, "popsize": 3}`,
+		"missing inputs":  `{"outputs": 1}`,
+		"bad probability": `{"inputs": 1, "outputs": 1, "mutation": {"add_node_prob": 1.5}}`,
+	}
+	for name, body := range cases {
+		if _, err := LoadConfig(strings.NewReader(body)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	// With several bad settings the first one in declaration order wins.
+	body := `{"inputs": 1, "outputs": 1, "mutation": {"add_connection_prob": 2, "toggle_enable_prob": -1}}`
+	for i := 0; i < 20; i++ {
+		_, err := LoadConfig(strings.NewReader(body))
+		if err == nil || !strings.Contains(err.Error(), "add_connection_prob") {
+			t.Fatalf("expected add_connection_prob error, got %v", err)
+		}
+	}
+}
+
+func TestLoadNEATPythonConfig(t *testing.T) {
+	f, err := os.Open("testdata/neat-python-xor.ini")
+	if err != nil {
+		t.Fatalf("open error: %v", err)
+	}
+	defer f.Close()
+
+	cfg, warnings, err := LoadNEATPythonConfig(f)
+	if err != nil {
+		t.Fatalf("LoadNEATPythonConfig error: %v", err)
+	}
+	if cfg.PopulationSize != 150 || cfg.Inputs != 2 || cfg.Outputs != 1 || cfg.FitnessThreshold != 3.9 {
+		t.Fatalf("unexpected run settings %+v", cfg)
+	}
+	if cfg.Population.CompatibilityThreshold != 3.0 || cfg.Population.WeightCoeff != 0.5 {
+		t.Fatalf("unexpected speciation settings %+v", cfg.Population)
+	}
+	if cfg.Reproduction.Elitism != 2 || cfg.Mutation.AddConnectionProb != 0.5 || cfg.Mutation.AddNodeProb != 0.2 {
+		t.Fatalf("unexpected mutation/reproduction settings %+v %+v", cfg.Mutation, cfg.Reproduction)
+	}
+	if math.Abs(cfg.Mutation.WeightMutateProb-0.9) > 1e-9 || math.Abs(cfg.Mutation.WeightPerturbProb-0.8/0.9) > 1e-9 {
+		t.Fatalf("unexpected weight rates %v %v", cfg.Mutation.WeightMutateProb, cfg.Mutation.WeightPerturbProb)
+	}
+	if len(cfg.Mutation.AllowedActivations) != 1 || cfg.Mutation.AllowedActivations[0] != ActivationSigmoid {
+		t.Fatalf("unexpected activations %v", cfg.Mutation.AllowedActivations)
+	}
+
+	joined := strings.Join(warnings, "\n")
+	for _, want := range []string{"conn_delete_prob", "node_delete_prob", "max_stagnation", "weight_max_value"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("expected warning for %s, got:\n%s", want, joined)
+		}
+	}
+	for _, quiet := range []string{"aggregation_default", "response_mutate_rate", "num_hidden"} {
+		if strings.Contains(joined, quiet) {
+			t.Fatalf("unexpected warning for neutral %s:\n%s", quiet, joined)
+		}
+	}
+}
+
+func TestLoadNEATPythonConfigErrors(t *testing.T) {
+	base := "[NEAT]\npop_size = 10\n[DefaultGenome]\nnum_inputs = 2\nnum_outputs = 1\n"
+	cases := map[string]string{
+		"recurrent":   base + "feed_forward = False\n",
+		"activations": base + "activation_options = cube hat\n",
+		"bad number":  base + "conn_add_prob = often\n",
+		"no genome":   "[NEAT]\npop_size = 10\n",
+	}
+	for name, body := range cases {
+		if _, _, err := LoadNEATPythonConfig(strings.NewReader(body)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	_, warnings, err := LoadNEATPythonConfig(strings.NewReader(base + "mystery_key = 1\n[Extra]\nfoo = bar\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", warnings)
+	}
+}
+
+func TestConfigNewPopulation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Inputs = 3
+	cfg.Outputs = 2
+	cfg.PopulationSize = 5
+	pop, err := cfg.NewPopulation(NewRand(1))
+	if err != nil {
+		t.Fatalf("NewPopulation error: %v", err)
+	}
+	if len(pop.Genomes) != 5 || len(pop.Genomes[0].Connections) != 6 {
+		t.Fatalf("unexpected population shape")
+	}
+}
@@ -8,18 +8,31 @@ import (
 const disabledInheritProb = 0.75
 
 // Crossover produces a child genome from two parents using NEAT alignment rules.
+// The child records both parent ids; it has no id of its own until a
+// population assigns one.
 func Crossover(rng RNG, a, b Genome) (Genome, error) {
 	if rng == nil {
 		return Genome{}, fmt.Errorf("rng is nil")
 	}
+	var child Genome
+	var err error
 	fitterA, equalFitness := fitnessOrder(a, b)
-	if !equalFitness {
-		if fitterA {
-			return crossoverFrom(rng, a, b, false)
-		}
-		return crossoverFrom(rng, b, a, false)
+	switch {
+	case equalFitness:
+		child, err = crossoverFrom(rng, a, b, true)
+	case fitterA:
+		child, err = crossoverFrom(rng, a, b, false)
+	default:
+		child, err = crossoverFrom(rng, b, a, false)
+	}
+	if err != nil {
+		return Genome{}, err
+	}
+	child.Parents = []GenomeID{a.ID, b.ID}
+	if a.ID == b.ID {
+		child.Parents = child.Parents[:1]
 	}
-	return crossoverFrom(rng, a, b, true)
+	return child, nil
 }
 
 func crossoverFrom(rng RNG, primary, secondary Genome, equalFitness bool) (Genome, error) {
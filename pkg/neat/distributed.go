@@ -0,0 +1,495 @@
+package neat
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	fitnessRegistryMu sync.RWMutex
+	fitnessRegistry   = map[string]ContextFitnessFunc{}
+)
+
+// RegisterFitness makes fn available to RunWorker under name.
+func RegisterFitness(name string, fn ContextFitnessFunc) error {
+	if name == "" {
+		return fmt.Errorf("fitness name is empty")
+	}
+	if fn == nil {
+		return fmt.Errorf("fitness function is nil")
+	}
+	fitnessRegistryMu.Lock()
+	defer fitnessRegistryMu.Unlock()
+	if _, exists := fitnessRegistry[name]; exists {
+		return fmt.Errorf("fitness %q already registered", name)
+	}
+	fitnessRegistry[name] = fn
+	return nil
+}
+
+func lookupFitness(name string) (ContextFitnessFunc, bool) {
+	fitnessRegistryMu.RLock()
+	defer fitnessRegistryMu.RUnlock()
+	fn, ok := fitnessRegistry[name]
+	return fn, ok
+}
+
+// workerHello is the first line a worker sends after connecting.
+type workerHello struct {
+	Fitness string `json:"fitness"`
+}
+
+// coordinatorWelcome answers the hello; a non-empty Error rejects the worker.
+type coordinatorWelcome struct {
+	Error string `json:"error,omitempty"`
+}
+
+// RunWorker connects to a coordinator at addr and evaluates genomes with the
+// fitness registered under name until ctx is done or the coordinator closes
+// the connection. Requests are evaluated one at a time; run several workers
+// to use more cores.
+func RunWorker(ctx context.Context, addr, name string) error {
+	fitness, ok := lookupFitness(name)
+	if !ok {
+		return fmt.Errorf("fitness %q is not registered", name)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	enc := json.NewEncoder(conn)
+	dec := json.NewDecoder(conn)
+	if err := enc.Encode(workerHello{Fitness: name}); err != nil {
+		return err
+	}
+	var welcome coordinatorWelcome
+	if err := dec.Decode(&welcome); err != nil {
+		return fmt.Errorf("read welcome: %w", err)
+	}
+	if welcome.Error != "" {
+		return fmt.Errorf("coordinator rejected worker: %s", welcome.Error)
+	}
+
+	for {
+		var req processRequest
+		if err := dec.Decode(&req); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		resp := processResponse{ID: req.ID}
+		if req.Genome == nil {
+			resp.Error = "request has no genome"
+		} else if f, err := fitness(ctx, req.Genome); err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Fitness = f
+		}
+		if err := enc.Encode(resp); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+	}
+}
+
+// CoordinatorConfig configures distributed evaluation.
+type CoordinatorConfig struct {
+	// Fitness is the registered fitness name workers must serve.
+	Fitness   string
+	BatchSize int
+	// Timeout bounds the wait for each response; a worker that misses it is
+	// treated as lost. Zero disables timeouts.
+	Timeout time.Duration
+	// MaxAttempts is how many workers a batch may be sent to before the
+	// evaluation fails.
+	MaxAttempts int
+	// HandshakeTimeout bounds the hello exchange with a new worker.
+	HandshakeTimeout time.Duration
+}
+
+// DefaultCoordinatorConfig returns unbatched settings that retry a batch on
+// up to three workers. Fitness must still be set.
+func DefaultCoordinatorConfig() CoordinatorConfig {
+	return CoordinatorConfig{
+		BatchSize:        1,
+		MaxAttempts:      3,
+		HandshakeTimeout: 5 * time.Second,
+	}
+}
+
+// errWorkerLost marks batches that failed because the worker went away;
+// they are reassigned to another worker.
+var errWorkerLost = errors.New("worker lost")
+
+// errWorkerGone marks batches handed to a worker whose connection had
+// already closed; they are reassigned without counting as an attempt.
+var errWorkerGone = errors.New("worker already disconnected")
+
+// Coordinator accepts worker connections and farms genomes out to them. Use
+// EvaluateBatch as a Runner's BatchFitness. Results are stored by genome
+// index, so they do not depend on which worker evaluated what.
+type Coordinator struct {
+	cfg      CoordinatorConfig
+	ln       net.Listener
+	nextID   atomic.Int64
+	wake     chan struct{}
+	mu       sync.Mutex
+	idle     []*remoteWorker
+	all      map[*remoteWorker]struct{}
+	closed   bool
+	acceptWG sync.WaitGroup
+}
+
+// NewCoordinator serves workers on ln until Close.
+func NewCoordinator(ln net.Listener, cfg CoordinatorConfig) (*Coordinator, error) {
+	if cfg.Fitness == "" {
+		return nil, fmt.Errorf("fitness name is empty")
+	}
+	if cfg.BatchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be > 0")
+	}
+	if cfg.MaxAttempts <= 0 {
+		return nil, fmt.Errorf("max attempts must be > 0")
+	}
+	c := &Coordinator{
+		cfg:  cfg,
+		ln:   ln,
+		wake: make(chan struct{}, 1),
+		all:  map[*remoteWorker]struct{}{},
+	}
+	c.acceptWG.Add(1)
+	go c.acceptLoop()
+	return c, nil
+}
+
+// ListenCoordinator listens on a TCP address such as ":7070".
+func ListenCoordinator(addr string, cfg CoordinatorConfig) (*Coordinator, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewCoordinator(ln, cfg)
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Addr returns the address workers should dial.
+func (c *Coordinator) Addr() net.Addr {
+	return c.ln.Addr()
+}
+
+// Workers returns the number of connected workers.
+func (c *Coordinator) Workers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.all)
+}
+
+// Close stops accepting workers and disconnects the connected ones.
+func (c *Coordinator) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	workers := make([]*remoteWorker, 0, len(c.all))
+	for w := range c.all {
+		workers = append(workers, w)
+	}
+	c.mu.Unlock()
+	err := c.ln.Close()
+	c.acceptWG.Wait()
+	for _, w := range workers {
+		w.conn.Close()
+	}
+	return err
+}
+
+func (c *Coordinator) acceptLoop() {
+	defer c.acceptWG.Done()
+	for {
+		conn, err := c.ln.Accept()
+		if err != nil {
+			return
+		}
+		go c.handshake(conn)
+	}
+}
+
+func (c *Coordinator) handshake(conn net.Conn) {
+	if c.cfg.HandshakeTimeout > 0 {
+		conn.SetDeadline(time.Now().Add(c.cfg.HandshakeTimeout))
+	}
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	var hello workerHello
+	if err := dec.Decode(&hello); err != nil {
+		conn.Close()
+		return
+	}
+	if hello.Fitness != c.cfg.Fitness {
+		enc.Encode(coordinatorWelcome{Error: fmt.Sprintf("coordinator evaluates %q, worker serves %q", c.cfg.Fitness, hello.Fitness)})
+		conn.Close()
+		return
+	}
+	if err := enc.Encode(coordinatorWelcome{}); err != nil {
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	w := &remoteWorker{
+		conn:    conn,
+		replies: make(chan processReply, c.cfg.BatchSize),
+		gone:    make(chan struct{}),
+	}
+	w.out = bufio.NewWriter(conn)
+	w.enc = json.NewEncoder(w.out)
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		conn.Close()
+		return
+	}
+	c.all[w] = struct{}{}
+	c.mu.Unlock()
+	go c.readReplies(dec, w)
+	c.release(w)
+}
+
+// readReplies forwards w's responses and drops w once its connection closes,
+// so a disconnected worker is never handed another batch.
+func (c *Coordinator) readReplies(dec *json.Decoder, w *remoteWorker) {
+	defer func() {
+		close(w.gone)
+		close(w.replies)
+		c.drop(w)
+	}()
+	for {
+		var resp processResponse
+		if err := dec.Decode(&resp); err != nil {
+			return
+		}
+		w.replies <- processReply{resp: resp}
+	}
+}
+
+// release returns w to the idle pool and wakes a waiting evaluation.
+// Disconnected workers are not returned.
+func (c *Coordinator) release(w *remoteWorker) {
+	c.mu.Lock()
+	select {
+	case <-w.gone:
+		c.mu.Unlock()
+		return
+	default:
+	}
+	c.idle = append(c.idle, w)
+	c.mu.Unlock()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Coordinator) acquire() *remoteWorker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.idle) == 0 {
+		return nil
+	}
+	w := c.idle[len(c.idle)-1]
+	c.idle = c.idle[:len(c.idle)-1]
+	return w
+}
+
+func (c *Coordinator) drop(w *remoteWorker) {
+	w.conn.Close()
+	c.mu.Lock()
+	delete(c.all, w)
+	for i, idle := range c.idle {
+		if idle == w {
+			c.idle = append(c.idle[:i], c.idle[i+1:]...)
+			break
+		}
+	}
+	c.mu.Unlock()
+}
+
+type batchResult struct {
+	batch  [2]int
+	worker *remoteWorker
+	err    error
+}
+
+// EvaluateBatch spreads genomes across connected workers in batches of
+// BatchSize and returns their fitness values in order. Batches from lost
+// workers are reassigned. If no worker is connected it waits for one until
+// ctx is done.
+func (c *Coordinator) EvaluateBatch(ctx context.Context, genomes []Genome) ([]float64, error) {
+	out := make([]float64, len(genomes))
+	var queue [][2]int
+	for start := 0; start < len(genomes); start += c.cfg.BatchSize {
+		end := start + c.cfg.BatchSize
+		if end > len(genomes) {
+			end = len(genomes)
+		}
+		queue = append(queue, [2]int{start, end})
+	}
+	attempts := make(map[[2]int]int, len(queue))
+	results := make(chan batchResult, len(queue))
+	inflight := 0
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var failure error
+	for (len(queue) > 0 && failure == nil) || inflight > 0 {
+		if failure == nil && len(queue) > 0 {
+			if w := c.acquire(); w != nil {
+				batch := queue[0]
+				queue = queue[1:]
+				attempts[batch]++
+				inflight++
+				go func() {
+					err := c.send(ctx, w, genomes, batch, out)
+					results <- batchResult{batch: batch, worker: w, err: err}
+				}()
+				continue
+			}
+		}
+
+		var wake <-chan struct{}
+		done := ctx.Done()
+		if failure != nil {
+			// Only wait for in-flight batches to come back.
+			done = nil
+		} else {
+			wake = c.wake
+		}
+		select {
+		case <-wake:
+		case r := <-results:
+			inflight--
+			switch {
+			case r.err == nil:
+				c.release(r.worker)
+			case errors.Is(r.err, errWorkerGone):
+				c.drop(r.worker)
+				attempts[r.batch]--
+				if failure == nil {
+					queue = append(queue, r.batch)
+				}
+			case errors.Is(r.err, errWorkerLost):
+				c.drop(r.worker)
+				if failure != nil {
+					break
+				}
+				if attempts[r.batch] >= c.cfg.MaxAttempts {
+					failure = fmt.Errorf("genomes %d-%d failed on %d workers: %w", r.batch[0], r.batch[1]-1, attempts[r.batch], r.err)
+					cancel()
+					break
+				}
+				queue = append(queue, r.batch)
+			default:
+				// The connection is still usable; stale replies are skipped.
+				c.release(r.worker)
+				if failure == nil {
+					failure = r.err
+					cancel()
+				}
+			}
+		case <-done:
+			failure = ctx.Err()
+		}
+	}
+	if failure != nil {
+		return nil, failure
+	}
+	return out, nil
+}
+
+// remoteWorker is one connected worker.
+type remoteWorker struct {
+	conn    net.Conn
+	out     *bufio.Writer
+	enc     *json.Encoder
+	replies chan processReply
+	// gone is closed once the connection has closed.
+	gone chan struct{}
+}
+
+// send evaluates genomes[batch[0]:batch[1]] on w, writing into out.
+func (c *Coordinator) send(ctx context.Context, w *remoteWorker, genomes []Genome, batch [2]int, out []float64) error {
+	select {
+	case <-w.gone:
+		return errWorkerGone
+	default:
+	}
+	ids := make(map[int64]int, batch[1]-batch[0])
+	for idx := batch[0]; idx < batch[1]; idx++ {
+		id := c.nextID.Add(1)
+		ids[id] = idx
+		if err := w.enc.Encode(processRequest{ID: id, Genome: &genomes[idx]}); err != nil {
+			return fmt.Errorf("%w: %v", errWorkerLost, err)
+		}
+	}
+	if err := w.out.Flush(); err != nil {
+		return fmt.Errorf("%w: %v", errWorkerLost, err)
+	}
+
+	var timer *time.Timer
+	var timeout <-chan time.Time
+	if c.cfg.Timeout > 0 {
+		timer = time.NewTimer(c.cfg.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for len(ids) > 0 {
+		select {
+		case reply, ok := <-w.replies:
+			if !ok {
+				return fmt.Errorf("%w: connection closed", errWorkerLost)
+			}
+			idx, known := ids[reply.resp.ID]
+			if !known {
+				continue
+			}
+			if reply.resp.Error != "" {
+				return fmt.Errorf("worker failed on genome %d: %s", genomes[idx].ID, reply.resp.Error)
+			}
+			out[idx] = reply.resp.Fitness
+			delete(ids, reply.resp.ID)
+			if timer != nil {
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(c.cfg.Timeout)
+			}
+		case <-timeout:
+			return fmt.Errorf("%w: no response within %s", errWorkerLost, c.cfg.Timeout)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package neat
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func init() {
+	RegisterFitness("test-connections", func(_ context.Context, g *Genome) (float64, error) {
+		return float64(g.ID) + float64(len(g.Connections))/10, nil
+	})
+}
+
+func startCoordinator(t *testing.T, cfg CoordinatorConfig) *Coordinator {
+	t.Helper()
+	c, err := ListenCoordinator("127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatalf("ListenCoordinator error: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func startWorkers(t *testing.T, c *Coordinator, name string, n int) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			RunWorker(ctx, c.Addr().String(), name)
+		}()
+	}
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+	waitForWorkers(t, c, n)
+}
+
+func waitForWorkers(t *testing.T, c *Coordinator, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Workers() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d of %d workers connected", c.Workers(), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func checkHelperFitness(t *testing.T, out []float64) {
+	t.Helper()
+	for i, f := range out {
+		if want := float64(i+1) + 0.1; f != want {
+			t.Fatalf("genome %d: got %v want %v", i, f, want)
+		}
+	}
+}
+
+func TestCoordinatorEvaluatesOnWorkers(t *testing.T) {
+	cfg := DefaultCoordinatorConfig()
+	cfg.Fitness = "test-connections"
+	cfg.BatchSize = 3
+	c := startCoordinator(t, cfg)
+	startWorkers(t, c, "test-connections", 3)
+
+	out, err := c.EvaluateBatch(context.Background(), helperGenomes(20))
+	if err != nil {
+		t.Fatalf("EvaluateBatch error: %v", err)
+	}
+	checkHelperFitness(t, out)
+
+	runner := Runner{
+		Population:   contextTestPopulation(t, 8),
+		Mutation:     DefaultMutationConfig(),
+		Reproduction: DefaultReproductionConfig(),
+		BatchFitness: c.EvaluateBatch,
+	}
+	if _, _, err := runner.Run(3, 1e9); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+}
+
+// fakeWorker completes the handshake and then misbehaves: it either hangs up
+// after reading the first request or never answers.
+func fakeWorker(t *testing.T, addr, name string, hangUp bool) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	dec := json.NewDecoder(conn)
+	json.NewEncoder(conn).Encode(workerHello{Fitness: name})
+	var welcome coordinatorWelcome
+	if err := dec.Decode(&welcome); err != nil || welcome.Error != "" {
+		t.Fatalf("handshake failed: %v %s", err, welcome.Error)
+	}
+	go func() {
+		var req processRequest
+		dec.Decode(&req)
+		if hangUp {
+			conn.Close()
+		}
+	}()
+}
+
+func TestCoordinatorReassignsLostWork(t *testing.T) {
+	for _, hangUp := range []bool{true, false} {
+		cfg := DefaultCoordinatorConfig()
+		cfg.Fitness = "test-connections"
+		cfg.BatchSize = 4
+		cfg.Timeout = 200 * time.Millisecond
+		c := startCoordinator(t, cfg)
+		fakeWorker(t, c.Addr().String(), "test-connections", hangUp)
+		waitForWorkers(t, c, 1)
+		startWorkers(t, c, "test-connections", 1)
+
+		out, err := c.EvaluateBatch(context.Background(), helperGenomes(12))
+		if err != nil {
+			t.Fatalf("hangUp=%v: EvaluateBatch error: %v", hangUp, err)
+		}
+		checkHelperFitness(t, out)
+		if c.Workers() != 1 {
+			t.Fatalf("hangUp=%v: expected the bad worker to be dropped, have %d workers", hangUp, c.Workers())
+		}
+	}
+}
+
+func TestCoordinatorSkipsDisconnectedWorkers(t *testing.T) {
+	cfg := DefaultCoordinatorConfig()
+	cfg.Fitness = "test-connections"
+	cfg.MaxAttempts = 1
+	c := startCoordinator(t, cfg)
+	var conns []net.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := net.Dial("tcp", c.Addr().String())
+		if err != nil {
+			t.Fatalf("dial error: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		json.NewEncoder(conn).Encode(workerHello{Fitness: "test-connections"})
+		conns = append(conns, conn)
+	}
+	waitForWorkers(t, c, 3)
+	for _, conn := range conns {
+		conn.Close()
+	}
+
+	// The closed connections must leave the pool on their own, before any
+	// batch is sent to them.
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Workers() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected disconnected workers to be dropped, have %d workers", c.Workers())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	startWorkers(t, c, "test-connections", 1)
+	out, err := c.EvaluateBatch(context.Background(), helperGenomes(6))
+	if err != nil {
+		t.Fatalf("EvaluateBatch error: %v", err)
+	}
+	checkHelperFitness(t, out)
+}
+
+func TestCoordinatorErrors(t *testing.T) {
+	if err := RegisterFitness("test-connections", func(context.Context, *Genome) (float64, error) { return 0, nil }); err == nil {
+		t.Fatalf("expected duplicate registration error")
+	}
+
+	cfg := DefaultCoordinatorConfig()
+	cfg.Fitness = "test-connections"
+	c := startCoordinator(t, cfg)
+
+	RegisterFitness("test-other", func(context.Context, *Genome) (float64, error) { return 0, nil })
+	err := RunWorker(context.Background(), c.Addr().String(), "test-other")
+	if err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Fatalf("expected rejection, got %v", err)
+	}
+	if err := RunWorker(context.Background(), c.Addr().String(), "missing"); err == nil {
+		t.Fatalf("expected error for unregistered fitness")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.EvaluateBatch(ctx, helperGenomes(2)); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline with no workers, got %v", err)
+	}
+
+	RegisterFitness("test-fails", func(_ context.Context, g *Genome) (float64, error) {
+		if g.ID == 2 {
+			return 0, context.Canceled
+		}
+		return 1, nil
+	})
+	cfg.Fitness = "test-fails"
+	c = startCoordinator(t, cfg)
+	startWorkers(t, c, "test-fails", 2)
+	if _, err := c.EvaluateBatch(context.Background(), helperGenomes(4)); err == nil || !strings.Contains(err.Error(), "genome 2") {
+		t.Fatalf("expected worker error for genome 2, got %v", err)
+	}
+}
@@ -1,38 +1,94 @@
 package neat
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 // FitnessFunc evaluates a genome and returns its fitness.
 type FitnessFunc func(*Genome) (float64, error)
 
+// ContextFitnessFunc evaluates a genome and should return promptly with
+// ctx.Err() once ctx is done.
+type ContextFitnessFunc func(ctx context.Context, g *Genome) (float64, error)
+
+// BatchFitnessFunc evaluates a whole generation at once and returns one
+// fitness per genome, in order. It must not modify or retain the genomes.
+type BatchFitnessFunc func(ctx context.Context, genomes []Genome) ([]float64, error)
+
 // Runner executes the NEAT evolution loop.
+//
+// Exactly one of Fitness, FitnessContext or BatchFitness must be set;
+// BatchFitness handles its own timeouts. When GenomeTimeout
+// is positive each genome is evaluated under its own deadline; a genome that
+// overruns it is given TimeoutFitness instead of aborting the run. An
+// evaluation that ignores its context keeps running in the background until
+// it returns, but its result is discarded.
+//
+// If HallOfFame is set, every evaluated genome is offered to it, Run returns
+// the best genome seen in any generation, and up to HallOfFameElites kept
+// genomes missing from a generation are copied into the next one.
 type Runner struct {
-	Population  *Population
-	Mutation    MutationConfig
-	Reproduction ReproductionConfig
-	Fitness     FitnessFunc
+	Population     *Population
+	Mutation       MutationConfig
+	Reproduction   ReproductionConfig
+	Fitness        FitnessFunc
+	FitnessContext ContextFitnessFunc
+	BatchFitness   BatchFitnessFunc
+	GenomeTimeout  time.Duration
+	TimeoutFitness float64
+	Reporters      []Reporter
+
+	HallOfFame       *HallOfFame
+	HallOfFameElites int
 }
 
 // Evaluate computes fitness for the current population and returns the best genome.
 func (r *Runner) Evaluate() (Genome, error) {
+	return r.EvaluateContext(context.Background())
+}
+
+// EvaluateContext computes fitness for the current population and returns the
+// best genome. If ctx is cancelled part way through, it returns the best
+// genome evaluated so far together with ctx.Err().
+func (r *Runner) EvaluateContext(ctx context.Context) (Genome, error) {
 	if r == nil {
 		return Genome{}, fmt.Errorf("runner is nil")
 	}
 	if r.Population == nil {
 		return Genome{}, fmt.Errorf("population is nil")
 	}
-	if r.Fitness == nil {
+	set := 0
+	for _, isSet := range []bool{r.Fitness != nil, r.FitnessContext != nil, r.BatchFitness != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set == 0 {
 		return Genome{}, fmt.Errorf("fitness function is nil")
 	}
+	if set > 1 {
+		return Genome{}, fmt.Errorf("only one of Fitness, FitnessContext or BatchFitness may be set")
+	}
 	if len(r.Population.Genomes) == 0 {
 		return Genome{}, fmt.Errorf("population has no genomes")
 	}
+	if r.BatchFitness != nil {
+		return r.evaluateBatch(ctx)
+	}
 
 	var best Genome
 	bestSet := false
 	for i := range r.Population.Genomes {
-		fitness, err := r.Fitness(&r.Population.Genomes[i])
+		if err := ctx.Err(); err != nil {
+			return best, err
+		}
+		fitness, err := r.evaluateGenome(ctx, &r.Population.Genomes[i])
 		if err != nil {
+			if ctx.Err() != nil {
+				return best, ctx.Err()
+			}
 			return Genome{}, err
 		}
 		r.Population.Genomes[i].Fitness = fitness
@@ -44,28 +100,196 @@ func (r *Runner) Evaluate() (Genome, error) {
 	return best, nil
 }
 
+func (r *Runner) evaluateBatch(ctx context.Context) (Genome, error) {
+	genomes := r.Population.Genomes
+	fitness, err := r.BatchFitness(ctx, genomes)
+	if err != nil {
+		return Genome{}, err
+	}
+	if len(fitness) != len(genomes) {
+		return Genome{}, fmt.Errorf("batch fitness returned %d values for %d genomes", len(fitness), len(genomes))
+	}
+	best := 0
+	for i := range genomes {
+		genomes[i].Fitness = fitness[i]
+		if fitness[i] > fitness[best] {
+			best = i
+		}
+	}
+	return cloneGenome(genomes[best]), nil
+}
+
+func (r *Runner) evaluateGenome(ctx context.Context, g *Genome) (float64, error) {
+	fitness := r.FitnessContext
+	if fitness == nil {
+		fitness = func(_ context.Context, g *Genome) (float64, error) {
+			return r.Fitness(g)
+		}
+	}
+	if r.GenomeTimeout <= 0 {
+		return fitness(ctx, g)
+	}
+
+	gctx, cancel := context.WithTimeout(ctx, r.GenomeTimeout)
+	defer cancel()
+
+	type result struct {
+		fitness float64
+		err     error
+	}
+	done := make(chan result, 1)
+	candidate := cloneGenome(*g)
+	go func() {
+		f, err := fitness(gctx, &candidate)
+		done <- result{fitness: f, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil && gctx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			return r.TimeoutFitness, nil
+		}
+		return res.fitness, res.err
+	case <-gctx.Done():
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return r.TimeoutFitness, nil
+	}
+}
+
 // Run evolves for up to maxGenerations and stops early at targetFitness.
 // It returns the best genome and the generation it was found.
 func (r *Runner) Run(maxGenerations int, targetFitness float64) (Genome, int, error) {
+	return r.RunContext(context.Background(), maxGenerations, targetFitness)
+}
+
+// RunContext is Run with cancellation. If ctx is cancelled or its deadline
+// passes, it stops between or during evaluations and returns the best genome
+// seen so far, the generation reached and ctx.Err().
+func (r *Runner) RunContext(ctx context.Context, maxGenerations int, targetFitness float64) (Genome, int, error) {
 	if maxGenerations <= 0 {
 		return Genome{}, 0, fmt.Errorf("maxGenerations must be > 0")
 	}
 	best := Genome{}
 	for gen := 0; gen < maxGenerations; gen++ {
-		currentBest, err := r.Evaluate()
+		if err := ctx.Err(); err != nil {
+			return best, gen, err
+		}
+		if err := r.reportStart(); err != nil {
+			return Genome{}, gen, err
+		}
+		currentBest, err := r.EvaluateContext(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				if len(currentBest.Nodes) > 0 && (gen == 0 || currentBest.Fitness > best.Fitness) {
+					best = currentBest
+				}
+				return best, gen, err
+			}
 			return Genome{}, gen, err
 		}
 		best = currentBest
+		if r.HallOfFame != nil {
+			r.HallOfFame.Update(r.Population.Genomes)
+			if champion, ok := r.HallOfFame.Best(); ok {
+				best = champion
+			}
+		}
+		stats, err := r.speciateAndReport()
+		if err != nil {
+			return Genome{}, gen, err
+		}
 		if best.Fitness >= targetFitness {
-			return best, gen, nil
+			return best, gen, r.reportEnd(stats)
 		}
 		if gen == maxGenerations-1 {
+			if err := r.reportEnd(stats); err != nil {
+				return Genome{}, gen, err
+			}
 			break
 		}
-		if err := r.Population.NextGeneration(r.Mutation, r.Reproduction); err != nil {
+		if err := r.reproduce(); err != nil {
+			return Genome{}, gen, err
+		}
+		if err := r.reportEnd(stats); err != nil {
 			return Genome{}, gen, err
 		}
 	}
 	return best, maxGenerations - 1, nil
 }
+
+// reproduce breeds the next generation. Hall of fame members missing from
+// the evaluated generation take its last HallOfFameElites slots, which are
+// reserved before breeding so no child is created in their place.
+func (r *Runner) reproduce() error {
+	return r.Population.reproduceKeeping(r.Mutation, r.Reproduction, r.hallOfFameReturns())
+}
+
+// hallOfFameReturns lists up to HallOfFameElites hall of fame members that
+// are not in the current generation, best first.
+func (r *Runner) hallOfFameReturns() []Genome {
+	if r.HallOfFame == nil || r.HallOfFameElites <= 0 {
+		return nil
+	}
+	present := make(map[GenomeID]struct{}, len(r.Population.Genomes))
+	for _, g := range r.Population.Genomes {
+		present[g.ID] = struct{}{}
+	}
+	var out []Genome
+	for _, g := range r.HallOfFame.Genomes() {
+		if len(out) >= r.HallOfFameElites || len(out) >= len(r.Population.Genomes) {
+			break
+		}
+		if _, ok := present[g.ID]; ok && g.ID != 0 {
+			continue
+		}
+		out = append(out, g)
+	}
+	return out
+}
+
+func (r *Runner) reportStart() error {
+	for _, rep := range r.Reporters {
+		if err := rep.GenerationStart(r.Population.Generation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// speciateAndReport runs the post-evaluation hooks, speciates the evaluated
+// generation and runs the post-speciation hooks. Species from the previous
+// generation index genomes that no longer exist, so PostEvaluate stats leave
+// the species fields zero.
+func (r *Runner) speciateAndReport() (GenerationStats, error) {
+	if len(r.Reporters) > 0 {
+		stats := ComputeStats(r.Population)
+		stats.SpeciesCount = 0
+		stats.SpeciesSizes = nil
+		for _, rep := range r.Reporters {
+			if err := rep.PostEvaluate(stats); err != nil {
+				return stats, err
+			}
+		}
+	}
+	if err := r.Population.Speciate(); err != nil {
+		return GenerationStats{}, err
+	}
+	stats := ComputeStats(r.Population)
+	for _, rep := range r.Reporters {
+		if err := rep.PostSpeciate(stats); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+func (r *Runner) reportEnd(stats GenerationStats) error {
+	for _, rep := range r.Reporters {
+		if err := rep.GenerationEnd(stats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,6 +1,12 @@
 package neat
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
 
 func TestRunnerRunStopsAtTarget(t *testing.T) {
 	rng := NewRand(11)
@@ -19,8 +25,8 @@ func TestRunnerRunStopsAtTarget(t *testing.T) {
 	}
 
 	runner := Runner{
-		Population:  pop,
-		Mutation:    DefaultMutationConfig(),
+		Population:   pop,
+		Mutation:     DefaultMutationConfig(),
 		Reproduction: DefaultReproductionConfig(),
 		Fitness: func(*Genome) (float64, error) {
 			return 1.0, nil
@@ -38,3 +44,155 @@ func TestRunnerRunStopsAtTarget(t *testing.T) {
 		t.Fatalf("expected fitness 1.0, got %v", best.Fitness)
 	}
 }
+
+func contextTestPopulation(t *testing.T, size int) *Population {
+	t.Helper()
+	rng := NewRand(17)
+	tracker, err := NewInnovationTracker(nil)
+	if err != nil {
+		t.Fatalf("NewInnovationTracker error: %v", err)
+	}
+	genomes := make([]Genome, 0, size)
+	for i := 0; i < size; i++ {
+		g, err := NewMinimalGenome(1, 1, ActivationSigmoid, rng, tracker, 1.0)
+		if err != nil {
+			t.Fatalf("NewMinimalGenome error: %v", err)
+		}
+		genomes = append(genomes, g)
+	}
+	pop, err := NewPopulation(rng, DefaultPopulationConfig(), genomes)
+	if err != nil {
+		t.Fatalf("NewPopulation error: %v", err)
+	}
+	return pop
+}
+
+func TestRunContextCancelReturnsPartialResult(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evaluations := 0
+	runner := Runner{
+		Population:   contextTestPopulation(t, 4),
+		Mutation:     DefaultMutationConfig(),
+		Reproduction: DefaultReproductionConfig(),
+		FitnessContext: func(ctx context.Context, g *Genome) (float64, error) {
+			evaluations++
+			// Cancel half way through the third generation.
+			if evaluations == 10 {
+				cancel()
+				return 0, ctx.Err()
+			}
+			return float64(evaluations), nil
+		},
+	}
+
+	best, gen, err := runner.RunContext(ctx, 10, math.Inf(1))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if gen != 2 {
+		t.Fatalf("expected generation 2, got %d", gen)
+	}
+	if best.Fitness != 9 {
+		t.Fatalf("expected best fitness 9 from partial generation, got %v", best.Fitness)
+	}
+}
+
+func TestRunContextGenomeTimeout(t *testing.T) {
+	runner := Runner{
+		Population:     contextTestPopulation(t, 3),
+		Mutation:       DefaultMutationConfig(),
+		Reproduction:   DefaultReproductionConfig(),
+		GenomeTimeout:  20 * time.Millisecond,
+		TimeoutFitness: -1,
+		FitnessContext: func(ctx context.Context, g *Genome) (float64, error) {
+			if g.ID == 2 {
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			return 1, nil
+		},
+	}
+
+	if _, err := runner.EvaluateContext(context.Background()); err != nil {
+		t.Fatalf("EvaluateContext error: %v", err)
+	}
+	for _, g := range runner.Population.Genomes {
+		want := 1.0
+		if g.ID == 2 {
+			want = -1
+		}
+		if g.Fitness != want {
+			t.Fatalf("genome %d: expected fitness %v, got %v", g.ID, want, g.Fitness)
+		}
+	}
+}
+
+func TestRunContextDeadlineStopsStuckFitness(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	block := make(chan struct{})
+	defer close(block)
+	runner := Runner{
+		Population:    contextTestPopulation(t, 2),
+		Mutation:      DefaultMutationConfig(),
+		Reproduction:  DefaultReproductionConfig(),
+		GenomeTimeout: time.Minute,
+		Fitness: func(*Genome) (float64, error) {
+			<-block
+			return 1, nil
+		},
+	}
+
+	_, gen, err := runner.RunContext(ctx, 5, math.Inf(1))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if gen != 0 {
+		t.Fatalf("expected generation 0, got %d", gen)
+	}
+}
+
+func TestEvaluateBatchFitness(t *testing.T) {
+	pop := contextTestPopulation(t, 5)
+	runner := Runner{
+		Population: pop,
+		BatchFitness: func(_ context.Context, genomes []Genome) ([]float64, error) {
+			out := make([]float64, len(genomes))
+			for i := range out {
+				out[i] = float64(i % 3)
+			}
+			return out, nil
+		},
+	}
+	best, err := runner.Evaluate()
+	if err != nil {
+		t.Fatalf("Evaluate error: %v", err)
+	}
+	if best.Fitness != 2 || best.ID != pop.Genomes[2].ID || pop.Genomes[4].Fitness != 1 {
+		t.Fatalf("unexpected batch results, best=%v", best.Fitness)
+	}
+
+	runner.BatchFitness = func(context.Context, []Genome) ([]float64, error) { return []float64{1}, nil }
+	if _, err := runner.Evaluate(); err == nil {
+		t.Fatalf("expected error for short batch result")
+	}
+}
+
+func TestRunnerRequiresOneFitness(t *testing.T) {
+	runner := Runner{Population: contextTestPopulation(t, 2)}
+	if _, err := runner.Evaluate(); err == nil {
+		t.Fatalf("expected error without a fitness function")
+	}
+	runner.Fitness = func(*Genome) (float64, error) { return 1, nil }
+	runner.FitnessContext = func(context.Context, *Genome) (float64, error) { return 2, nil }
+	if _, err := runner.Evaluate(); err == nil {
+		t.Fatalf("expected error with both Fitness and FitnessContext set")
+	}
+	runner.FitnessContext = nil
+	if _, err := runner.Evaluate(); err != nil {
+		t.Fatalf("Evaluate error: %v", err)
+	}
+}
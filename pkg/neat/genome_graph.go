@@ -0,0 +1,247 @@
+package neat
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+var nodeKindColors = map[NodeKind]string{
+	NodeInput:  "#4c8fd6",
+	NodeHidden: "#b9b3a8",
+	NodeOutput: "#e0883c",
+}
+
+const (
+	edgePositiveColor = "#2c6e6f"
+	edgeNegativeColor = "#c0392b"
+	edgeDisabledColor = "#9a9a9a"
+)
+
+// SVGConfig controls the layout of SVG network diagrams.
+type SVGConfig struct {
+	LayerSpacing float64
+	NodeSpacing  float64
+	NodeRadius   float64
+	Margin       float64
+	MaxEdgeWidth float64
+}
+
+// DefaultSVGConfig returns a compact layout suitable for the detail modal.
+func DefaultSVGConfig() SVGConfig {
+	return SVGConfig{
+		LayerSpacing: 120,
+		NodeSpacing:  56,
+		NodeRadius:   20,
+		Margin:       32,
+		MaxEdgeWidth: 6,
+	}
+}
+
+// graphLayout assigns every node to a layer derived from the topological order.
+type graphLayout struct {
+	layers  [][]NodeGene
+	layerOf map[NodeID]int
+	pos     map[NodeID]int
+}
+
+// layoutGenome places inputs in layer 0, outputs in the last layer and each
+// hidden node one layer after its deepest enabled source.
+func layoutGenome(g Genome) (graphLayout, error) {
+	nodeByID := nodeGeneMap(g.Nodes)
+	order, err := topoOrder(nodeByID, g.Connections)
+	if err != nil {
+		return graphLayout{}, err
+	}
+
+	incoming := make(map[NodeID][]NodeID, len(g.Nodes))
+	for _, c := range g.Connections {
+		if c.Enabled {
+			incoming[c.Out] = append(incoming[c.Out], c.In)
+		}
+	}
+
+	layerOf := make(map[NodeID]int, len(order))
+	depth := 0
+	for _, id := range order {
+		layer := 0
+		if nodeByID[id].Kind != NodeInput {
+			layer = 1
+			for _, src := range incoming[id] {
+				if layerOf[src]+1 > layer {
+					layer = layerOf[src] + 1
+				}
+			}
+		}
+		layerOf[id] = layer
+		if nodeByID[id].Kind != NodeOutput && layer > depth {
+			depth = layer
+		}
+	}
+	for id, n := range nodeByID {
+		if n.Kind == NodeOutput {
+			layerOf[id] = depth + 1
+		}
+	}
+
+	layers := make([][]NodeGene, depth+2)
+	for _, id := range order {
+		l := layerOf[id]
+		layers[l] = append(layers[l], nodeByID[id])
+	}
+	pos := make(map[NodeID]int, len(order))
+	for _, layer := range layers {
+		sort.Slice(layer, func(i, j int) bool { return layer[i].ID < layer[j].ID })
+		for i, n := range layer {
+			pos[n.ID] = i
+		}
+	}
+	return graphLayout{layers: layers, layerOf: layerOf, pos: pos}, nil
+}
+
+// DOT returns a Graphviz description of the genome, or an error if the enabled
+// connections contain a cycle.
+func (g Genome) DOT() (string, error) {
+	var b strings.Builder
+	if err := WriteDOT(&b, g); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// WriteDOT writes the genome as a Graphviz digraph ranked left to right by layer.
+func WriteDOT(w io.Writer, g Genome) error {
+	layout, err := layoutGenome(g)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph genome {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=circle, style=filled, fontname=\"Helvetica\", fontsize=10];\n")
+	for i, layer := range layout.layers {
+		if len(layer) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "  subgraph layer_%d {\n    rank=same;\n", i)
+		for _, n := range layer {
+			fmt.Fprintf(&b, "    n%d [label=\"%s\", fillcolor=\"%s\"];\n", n.ID, dotEscape(nodeLabel(n, "\\n")), nodeKindColors[n.Kind])
+		}
+		b.WriteString("  }\n")
+	}
+	for _, c := range sortedConnections(g.Connections) {
+		style := "solid"
+		if !c.Enabled {
+			style = "dashed"
+		}
+		fmt.Fprintf(&b, "  n%d -> n%d [label=\"%.2f\", color=\"%s\", penwidth=%.2f, style=%s];\n",
+			c.In, c.Out, c.Weight, edgeColor(c), edgeWidth(c.Weight, 4), style)
+	}
+	b.WriteString("}\n")
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// SVG returns a self-contained SVG diagram of the genome.
+func (g Genome) SVG(cfg SVGConfig) (string, error) {
+	var b strings.Builder
+	if err := WriteSVG(&b, g, cfg); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// WriteSVG writes the genome as a layered SVG diagram. Nodes are coloured by
+// kind, edges are coloured by weight sign and scaled by magnitude, and
+// disabled connections are dashed.
+func WriteSVG(w io.Writer, g Genome, cfg SVGConfig) error {
+	layout, err := layoutGenome(g)
+	if err != nil {
+		return err
+	}
+
+	tallest := 1
+	for _, layer := range layout.layers {
+		tallest = maxInt(tallest, len(layer))
+	}
+	width := 2*cfg.Margin + float64(len(layout.layers)-1)*cfg.LayerSpacing
+	height := 2*cfg.Margin + float64(tallest-1)*cfg.NodeSpacing
+
+	position := func(id NodeID) (float64, float64) {
+		l := layout.layerOf[id]
+		offset := float64(tallest-len(layout.layers[l])) * cfg.NodeSpacing / 2
+		return cfg.Margin + float64(l)*cfg.LayerSpacing, cfg.Margin + offset + float64(layout.pos[id])*cfg.NodeSpacing
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" viewBox=\"0 0 %.0f %.0f\" font-family=\"Helvetica, Arial, sans-serif\">\n",
+		width, height, width, height)
+	b.WriteString("  <g class=\"edges\" fill=\"none\">\n")
+	for _, c := range sortedConnections(g.Connections) {
+		if _, ok := layout.layerOf[c.In]; !ok {
+			continue
+		}
+		if _, ok := layout.layerOf[c.Out]; !ok {
+			continue
+		}
+		x1, y1 := position(c.In)
+		x2, y2 := position(c.Out)
+		dash := ""
+		if !c.Enabled {
+			dash = " stroke-dasharray=\"5 4\""
+		}
+		fmt.Fprintf(&b, "    <line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"%s\" stroke-width=\"%.2f\"%s><title>%d: %d-&gt;%d w=%.4f</title></line>\n",
+			x1, y1, x2, y2, edgeColor(c), edgeWidth(c.Weight, cfg.MaxEdgeWidth), dash, c.Innovation, c.In, c.Out, c.Weight)
+	}
+	b.WriteString("  </g>\n")
+	b.WriteString("  <g class=\"nodes\" font-size=\"9\" text-anchor=\"middle\">\n")
+	for _, layer := range layout.layers {
+		for _, n := range layer {
+			x, y := position(n.ID)
+			fmt.Fprintf(&b, "    <g><title>%s</title><circle cx=\"%.1f\" cy=\"%.1f\" r=\"%.1f\" fill=\"%s\" stroke=\"#1b1a16\" stroke-width=\"1\"/>",
+				html.EscapeString(nodeLabel(n, " ")), x, y, cfg.NodeRadius, nodeKindColors[n.Kind])
+			fmt.Fprintf(&b, "<text x=\"%.1f\" y=\"%.1f\">%s</text>", x, y-2, html.EscapeString(n.Activation.String()))
+			fmt.Fprintf(&b, "<text x=\"%.1f\" y=\"%.1f\">%.2f</text></g>\n", x, y+9, n.Bias)
+		}
+	}
+	b.WriteString("  </g>\n</svg>\n")
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+func nodeLabel(n NodeGene, sep string) string {
+	if n.Kind == NodeInput {
+		if n.Label != "" {
+			return fmt.Sprintf("%d%s%s", n.ID, sep, n.Label)
+		}
+		return fmt.Sprintf("%d%s%s", n.ID, sep, n.Kind)
+	}
+	return fmt.Sprintf("%d%s%s%sb=%.2f", n.ID, sep, n.Activation, sep, n.Bias)
+}
+
+func edgeColor(c ConnectionGene) string {
+	switch {
+	case !c.Enabled:
+		return edgeDisabledColor
+	case c.Weight < 0:
+		return edgeNegativeColor
+	default:
+		return edgePositiveColor
+	}
+}
+
+// edgeWidth maps |weight| in [0, 3] onto [0.5, max].
+func edgeWidth(weight, max float64) float64 {
+	mag := math.Min(math.Abs(weight), 3) / 3
+	return 0.5 + mag*(max-0.5)
+}
+
+func dotEscape(s string) string {
+	return strings.ReplaceAll(s, "\"", "\\\"")
+}
@@ -0,0 +1,92 @@
+package neat
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func graphTestGenome() Genome {
+	return Genome{
+		Nodes: []NodeGene{
+			{ID: 1, Kind: NodeInput, Activation: ActivationLinear},
+			{ID: 2, Kind: NodeInput, Activation: ActivationLinear},
+			{ID: 3, Kind: NodeOutput, Activation: ActivationSigmoid},
+			{ID: 4, Kind: NodeHidden, Activation: ActivationSin, Bias: 0.5},
+			{ID: 5, Kind: NodeHidden, Activation: ActivationGaussian},
+		},
+		Connections: []ConnectionGene{
+			{Innovation: 1, In: 1, Out: 3, Weight: 0.5, Enabled: false},
+			{Innovation: 2, In: 1, Out: 4, Weight: 1.0, Enabled: true},
+			{Innovation: 3, In: 4, Out: 5, Weight: -2.0, Enabled: true},
+			{Innovation: 4, In: 5, Out: 3, Weight: 1.5, Enabled: true},
+			{Innovation: 5, In: 2, Out: 3, Weight: -0.3, Enabled: true},
+		},
+	}
+}
+
+func TestLayoutGenomeLayers(t *testing.T) {
+	layout, err := layoutGenome(graphTestGenome())
+	if err != nil {
+		t.Fatalf("layoutGenome error: %v", err)
+	}
+	want := map[NodeID]int{1: 0, 2: 0, 4: 1, 5: 2, 3: 3}
+	for id, layer := range want {
+		if layout.layerOf[id] != layer {
+			t.Fatalf("node %d: expected layer %d, got %d", id, layer, layout.layerOf[id])
+		}
+	}
+}
+
+func TestGenomeDOT(t *testing.T) {
+	dot, err := graphTestGenome().DOT()
+	if err != nil {
+		t.Fatalf("DOT error: %v", err)
+	}
+	if !strings.HasPrefix(dot, "digraph genome {") {
+		t.Fatalf("unexpected DOT header: %q", dot)
+	}
+	if !strings.Contains(dot, "n1 -> n3") || !strings.Contains(dot, "style=dashed") {
+		t.Fatalf("expected dashed disabled edge in DOT output:\n%s", dot)
+	}
+	if !strings.Contains(dot, "4\\nsin\\nb=0.50") {
+		t.Fatalf("expected activation and bias label in DOT output:\n%s", dot)
+	}
+}
+
+func TestGenomeSVGIsWellFormed(t *testing.T) {
+	svg, err := graphTestGenome().SVG(DefaultSVGConfig())
+	if err != nil {
+		t.Fatalf("SVG error: %v", err)
+	}
+	dec := xml.NewDecoder(strings.NewReader(svg))
+	circles, lines := 0, 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			switch start.Name.Local {
+			case "circle":
+				circles++
+			case "line":
+				lines++
+			}
+		}
+	}
+	if circles != 5 || lines != 5 {
+		t.Fatalf("expected 5 nodes and 5 edges, got %d and %d", circles, lines)
+	}
+	if !strings.Contains(svg, edgeNegativeColor) || !strings.Contains(svg, "stroke-dasharray") {
+		t.Fatalf("expected negative and dashed edges in SVG")
+	}
+}
+
+func TestGenomeDOTCycle(t *testing.T) {
+	g := graphTestGenome()
+	g.Connections = append(g.Connections, ConnectionGene{Innovation: 6, In: 5, Out: 4, Weight: 1, Enabled: true})
+	if _, err := g.DOT(); err == nil {
+		t.Fatalf("expected cycle error")
+	}
+}
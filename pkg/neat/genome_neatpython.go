@@ -0,0 +1,231 @@
+package neat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// neatPythonGains converts neat-python activations onto ours:
+// np(z) == ours(gain*z). neat-python also clamps the scaled input of sigmoid,
+// tanh and sin to [-60, 60] and of gauss to [-3.4, 3.4]. The clamp is not
+// reproduced. Sigmoid, tanh and gauss are saturated there, but sin is not,
+// so sin nodes differ once |z| > 12.
+var neatPythonGains = map[ActivationType]float64{
+	ActivationLinear:   1,
+	ActivationSigmoid:  5 / 4.9,
+	ActivationTanh:     2.5,
+	ActivationRelu:     1,
+	ActivationSin:      5,
+	ActivationGaussian: math.Sqrt(5),
+	ActivationAbs:      1,
+	ActivationSquare:   1,
+}
+
+// NEATPythonNode is a neat-python DefaultNodeGene. A missing response
+// decodes as 1, neat-python's default.
+type NEATPythonNode struct {
+	Key         int64   `json:"key"`
+	Bias        float64 `json:"bias"`
+	Response    float64 `json:"response"`
+	Activation  string  `json:"activation"`
+	Aggregation string  `json:"aggregation"`
+}
+
+// UnmarshalJSON defaults Response to 1 so dumps without it keep their weights.
+func (n *NEATPythonNode) UnmarshalJSON(data []byte) error {
+	type plain NEATPythonNode
+	node := plain{Response: 1}
+	if err := json.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	*n = NEATPythonNode(node)
+	return nil
+}
+
+// NEATPythonConnection is a neat-python DefaultConnectionGene; Key is the
+// (input, output) node key pair.
+type NEATPythonConnection struct {
+	Key     [2]int64 `json:"key"`
+	Weight  float64  `json:"weight"`
+	Enabled bool     `json:"enabled"`
+}
+
+// NEATPythonGenome is the JSON form of a neat-python DefaultGenome as
+// produced by the dump script in docs/interop.md. Input nodes are implicit
+// (keys -1..-n); outputs are keys 0..m-1.
+type NEATPythonGenome struct {
+	Key         int64                  `json:"key"`
+	Fitness     *float64               `json:"fitness"`
+	Nodes       []NEATPythonNode       `json:"nodes"`
+	Connections []NEATPythonConnection `json:"connections"`
+}
+
+// ReadNEATPythonGenomes reads a neat-python JSON dump (a single genome object
+// or an array of them) for a network with the given input and output
+// counts. neat-python pickles cannot be read directly; convert them with the
+// script in docs/interop.md first.
+//
+// Activations are rescaled into our conventions, so sigmoid, tanh and gauss
+// nodes evaluate as in neat-python. Sin nodes match only while their input
+// stays within [-12, 12], because neat-python clamps sin(5z) to sin(±60)
+// beyond that and we do not. Node response is folded into incoming
+// weights. Other aggregations than sum, activations we lack and recurrent
+// networks are rejected.
+func ReadNEATPythonGenomes(r io.Reader, inputs, outputs int) ([]Genome, *InnovationTracker, error) {
+	if inputs <= 0 || outputs <= 0 {
+		return nil, nil, fmt.Errorf("inputs and outputs must be > 0")
+	}
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err != nil {
+		return nil, nil, err
+	}
+	var dumps []NEATPythonGenome
+	dec := json.NewDecoder(br)
+	if first == '[' {
+		err = dec.Decode(&dumps)
+	} else {
+		var single NEATPythonGenome
+		err = dec.Decode(&single)
+		dumps = append(dumps, single)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	foreign := make([]foreignGenome, 0, len(dumps))
+	for _, d := range dumps {
+		fg, err := d.foreign(inputs, outputs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("neat-python genome %d: %w", d.Key, err)
+		}
+		foreign = append(foreign, fg)
+	}
+	return finishImport(foreign)
+}
+
+func (d NEATPythonGenome) foreign(inputs, outputs int) (foreignGenome, error) {
+	fg := foreignGenome{id: GenomeID(d.Key)}
+	if d.Fitness != nil {
+		fg.fitness = *d.Fitness
+	}
+	for i := 0; i < inputs; i++ {
+		fg.inputs = append(fg.inputs, int64(-1-i))
+	}
+	for i := 0; i < outputs; i++ {
+		fg.outputs = append(fg.outputs, int64(i))
+	}
+
+	scale := make(map[int64]float64, len(d.Nodes))
+	for _, n := range d.Nodes {
+		if n.Key < 0 {
+			return foreignGenome{}, fmt.Errorf("node %d: input nodes cannot carry genes", n.Key)
+		}
+		if n.Aggregation != "" && n.Aggregation != "sum" {
+			return foreignGenome{}, fmt.Errorf("node %d: aggregation %q is not supported (sum only)", n.Key, n.Aggregation)
+		}
+		act, ok := neatPythonActivations[n.Activation]
+		if !ok {
+			return foreignGenome{}, fmt.Errorf("node %d: activation %q is not supported", n.Key, n.Activation)
+		}
+		kind := NodeHidden
+		if n.Key < int64(outputs) {
+			kind = NodeOutput
+		}
+		gain := neatPythonGains[act]
+		scale[n.Key] = gain * n.Response
+		fg.nodes = append(fg.nodes, foreignNode{key: n.Key, kind: kind, activation: act, bias: gain * n.Bias})
+	}
+
+	for _, c := range d.Connections {
+		s, ok := scale[c.Key[1]]
+		if !ok {
+			return foreignGenome{}, fmt.Errorf("connection %d->%d targets a missing node", c.Key[0], c.Key[1])
+		}
+		if c.Key[0] < int64(-inputs) {
+			return foreignGenome{}, fmt.Errorf("connection %d->%d uses input beyond %d inputs", c.Key[0], c.Key[1], inputs)
+		}
+		fg.conns = append(fg.conns, foreignConn{in: c.Key[0], out: c.Key[1], weight: s * c.Weight, enabled: c.Enabled})
+	}
+	return fg, nil
+}
+
+// WriteNEATPythonGenomes writes genomes as a neat-python JSON dump array.
+// Inputs become keys -1..-n, outputs 0..m-1 and hidden nodes m and up, with
+// the same hidden key for the same node id in every genome. Weights and
+// biases are rescaled for neat-python's activations and response is 1.
+func WriteNEATPythonGenomes(w io.Writer, genomes []Genome) error {
+	layout, err := newExportLayout(genomes)
+	if err != nil {
+		return err
+	}
+	dumps := make([]NEATPythonGenome, 0, len(genomes))
+	for gi, g := range genomes {
+		pos := layout.positions(g)
+		nodes := nodeGeneMap(g.Nodes)
+		key := func(id NodeID) int64 {
+			switch nodes[id].Kind {
+			case NodeInput:
+				return int64(-1 - pos[id])
+			case NodeOutput:
+				return int64(pos[id])
+			default:
+				return int64(layout.outputs + pos[id])
+			}
+		}
+
+		fitness := g.Fitness
+		d := NEATPythonGenome{Key: int64(g.ID), Fitness: &fitness}
+		for _, n := range g.Nodes {
+			if n.Kind == NodeInput {
+				continue
+			}
+			name, ok := neatPythonActivationNames[n.Activation]
+			if !ok {
+				return fmt.Errorf("genome %d: node %d activation %s has no neat-python equivalent", gi, n.ID, n.Activation)
+			}
+			d.Nodes = append(d.Nodes, NEATPythonNode{
+				Key:         key(n.ID),
+				Bias:        n.Bias / neatPythonGains[n.Activation],
+				Response:    1,
+				Activation:  name,
+				Aggregation: "sum",
+			})
+		}
+		sort.Slice(d.Nodes, func(i, j int) bool { return d.Nodes[i].Key < d.Nodes[j].Key })
+		for _, c := range sortedConnections(g.Connections) {
+			_, inOK := nodes[c.In]
+			out, outOK := nodes[c.Out]
+			if !inOK || !outOK {
+				return fmt.Errorf("genome %d: connection %d references a missing node", gi, c.Innovation)
+			}
+			d.Connections = append(d.Connections, NEATPythonConnection{
+				Key:     [2]int64{key(c.In), key(c.Out)},
+				Weight:  c.Weight / neatPythonGains[out.Activation],
+				Enabled: c.Enabled,
+			})
+		}
+		dumps = append(dumps, d)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(dumps)
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.ContainsRune([]byte(" \t\r\n"), rune(b)) {
+			return b, br.UnreadByte()
+		}
+	}
+}
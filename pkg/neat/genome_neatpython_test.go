@@ -0,0 +1,154 @@
+package neat
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+const neatPythonDump = `[
+  {"key": 7, "fitness": 3.5,
+   "nodes": [
+     {"key": 0, "bias": 0.5, "response": 2.0, "activation": "sigmoid", "aggregation": "sum"},
+     {"key": 12, "bias": -0.1, "response": 1.0, "activation": "tanh", "aggregation": "sum"}
+   ],
+   "connections": [
+     {"key": [-1, 12], "weight": 0.7, "enabled": true},
+     {"key": [-2, 0], "weight": -0.3, "enabled": true},
+     {"key": [12, 0], "weight": 1.2, "enabled": true},
+     {"key": [-1, 0], "weight": 0.4, "enabled": false}
+   ]},
+  {"key": 9, "fitness": null,
+   "nodes": [
+     {"key": 0, "bias": 0.0, "response": 1.0, "activation": "sigmoid", "aggregation": "sum"},
+     {"key": 3, "bias": 0.2, "response": 1.0, "activation": "gauss", "aggregation": "sum"},
+     {"key": 12, "bias": 0.0, "response": 1.0, "activation": "sin", "aggregation": "sum"}
+   ],
+   "connections": [
+     {"key": [-1, 12], "weight": 0.5, "enabled": true},
+     {"key": [12, 3], "weight": 1.1, "enabled": true},
+     {"key": [3, 0], "weight": -0.8, "enabled": true}
+   ]}
+]`
+
+func neatPythonReference(x1, x2 float64) float64 {
+	h := math.Tanh(2.5 * (-0.1 + 0.7*x1))
+	return 1 / (1 + math.Exp(-5*(0.5+2*(-0.3*x2+1.2*h))))
+}
+
+func TestReadNEATPythonGenomes(t *testing.T) {
+	genomes, tracker, err := ReadNEATPythonGenomes(strings.NewReader(neatPythonDump), 2, 1)
+	if err != nil {
+		t.Fatalf("ReadNEATPythonGenomes error: %v", err)
+	}
+	if len(genomes) != 2 || genomes[0].ID != 7 || genomes[0].Fitness != 3.5 {
+		t.Fatalf("unexpected genomes %+v", genomes)
+	}
+
+	plan, err := BuildAcyclicPlan(genomes[0], nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	for _, in := range [][2]float64{{0, 0}, {1, 0}, {0.3, -0.7}, {-1, 1}} {
+		out, err := plan.Eval([]float64{in[0], in[1]})
+		if err != nil {
+			t.Fatalf("Eval error: %v", err)
+		}
+		if want := neatPythonReference(in[0], in[1]); math.Abs(out[0]-want) > 1e-12 {
+			t.Fatalf("inputs %v: got %v want %v", in, out[0], want)
+		}
+	}
+
+	// neat-python key 12 is the same hidden node in both genomes.
+	hidden := func(g Genome, act ActivationType) NodeID {
+		for _, n := range g.Nodes {
+			if n.Kind == NodeHidden && n.Activation == act {
+				return n.ID
+			}
+		}
+		t.Fatalf("no %s hidden node", act)
+		return 0
+	}
+	if hidden(genomes[0], ActivationTanh) != hidden(genomes[1], ActivationSin) {
+		t.Fatalf("shared hidden key mapped to different node ids")
+	}
+	if hidden(genomes[1], ActivationGaussian) >= hidden(genomes[1], ActivationSin) {
+		t.Fatalf("hidden nodes should keep neat-python key order")
+	}
+	for _, g := range genomes {
+		for _, c := range g.Connections {
+			if got := tracker.Innovation(c.In, c.Out); got != c.Innovation {
+				t.Fatalf("tracker maps %d->%d to %d, genome has %d", c.In, c.Out, got, c.Innovation)
+			}
+		}
+	}
+	if id := tracker.NextNodeID(); id <= hidden(genomes[1], ActivationSin) {
+		t.Fatalf("tracker would reuse node id %d", id)
+	}
+}
+
+func TestNEATPythonRoundTrip(t *testing.T) {
+	genomes, _, err := ReadNEATPythonGenomes(strings.NewReader(neatPythonDump), 2, 1)
+	if err != nil {
+		t.Fatalf("ReadNEATPythonGenomes error: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteNEATPythonGenomes(buf, genomes); err != nil {
+		t.Fatalf("WriteNEATPythonGenomes error: %v", err)
+	}
+	again, _, err := ReadNEATPythonGenomes(buf, 2, 1)
+	if err != nil {
+		t.Fatalf("re-read error: %v", err)
+	}
+	for i := range genomes {
+		a, _ := BuildAcyclicPlan(genomes[i], nil, nil)
+		b, _ := BuildAcyclicPlan(again[i], nil, nil)
+		outA, _ := a.Eval([]float64{0.25, -0.5})
+		outB, _ := b.Eval([]float64{0.25, -0.5})
+		if math.Abs(outA[0]-outB[0]) > 1e-12 {
+			t.Fatalf("genome %d: round trip changed output %v -> %v", i, outA[0], outB[0])
+		}
+		if len(again[i].Connections) != len(genomes[i].Connections) {
+			t.Fatalf("genome %d: round trip changed connections", i)
+		}
+	}
+}
+
+func TestNEATPythonMissingResponse(t *testing.T) {
+	body := `{"key": 1, "nodes": [{"key": 0, "bias": 0, "activation": "identity", "aggregation": "sum"}],
+		"connections": [{"key": [-1, 0], "weight": 0.75, "enabled": true}]}`
+	genomes, _, err := ReadNEATPythonGenomes(strings.NewReader(body), 2, 1)
+	if err != nil {
+		t.Fatalf("ReadNEATPythonGenomes error: %v", err)
+	}
+	if w := genomes[0].Connections[0].Weight; w != 0.75 {
+		t.Fatalf("expected missing response to default to 1, got weight %v", w)
+	}
+}
+
+func TestNEATPythonErrors(t *testing.T) {
+	cases := map[string]string{
+		"aggregation": `{"key": 1, "nodes": [{"key": 0, "response": 1, "activation": "sigmoid", "aggregation": "product"}], "connections": []}`,
+		"activation":  `{"key": 1, "nodes": [{"key": 0, "response": 1, "activation": "cube", "aggregation": "sum"}], "connections": []}`,
+		"recurrent": `{"key": 1, "nodes": [{"key": 0, "response": 1, "activation": "sigmoid"}, {"key": 4, "response": 1, "activation": "sigmoid"}],
+			"connections": [{"key": [0, 4], "weight": 1, "enabled": true}, {"key": [4, 0], "weight": 1, "enabled": true}]}`,
+		"input range": `{"key": 1, "nodes": [{"key": 0, "response": 1, "activation": "sigmoid"}], "connections": [{"key": [-5, 0], "weight": 1, "enabled": true}]}`,
+	}
+	for name, body := range cases {
+		if _, _, err := ReadNEATPythonGenomes(strings.NewReader(body), 2, 1); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	g := Genome{
+		Nodes: []NodeGene{
+			{ID: 1, Kind: NodeInput},
+			{ID: 2, Kind: NodeOutput, Activation: ActivationCos},
+		},
+		Connections: []ConnectionGene{{Innovation: 1, In: 1, Out: 2, Weight: 1, Enabled: true}},
+	}
+	if err := WriteNEATPythonGenomes(&bytes.Buffer{}, []Genome{g}); err == nil {
+		t.Fatalf("expected error exporting cos activation")
+	}
+}
@@ -0,0 +1,266 @@
+package neat
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// sharpNEATActivations maps SharpNEAT 2 activation function names onto ours
+// with the input gain that makes them equal: sn(x) == ours(gain*x).
+var sharpNEATActivations = map[string]struct {
+	activation ActivationType
+	gain       float64
+}{
+	"Linear":           {ActivationLinear, 1},
+	"SteepenedSigmoid": {ActivationSigmoid, 1},
+	"ReLU":             {ActivationRelu, 1},
+	"Sine":             {ActivationSin, 2},
+	"Absolute":         {ActivationAbs, 1},
+}
+
+var sharpNEATActivationNames = func() map[ActivationType]string {
+	out := make(map[ActivationType]string, len(sharpNEATActivations))
+	for name, fn := range sharpNEATActivations {
+		out[fn.activation] = name
+	}
+	return out
+}()
+
+type sharpNEATRoot struct {
+	XMLName   xml.Name            `xml:"Root"`
+	Functions []sharpNEATFunction `xml:"ActivationFunctions>Fn"`
+	Networks  []sharpNEATNetwork  `xml:"Networks>Network"`
+}
+
+type sharpNEATFunction struct {
+	ID   int     `xml:"id,attr"`
+	Name string  `xml:"name,attr"`
+	Prob float64 `xml:"prob,attr"`
+}
+
+type sharpNEATNetwork struct {
+	ID          int64                 `xml:"id,attr"`
+	BirthGen    int                   `xml:"birthGen,attr"`
+	Fitness     float64               `xml:"fitness,attr"`
+	Nodes       []sharpNEATNode       `xml:"Nodes>Node"`
+	Connections []sharpNEATConnection `xml:"Connections>Con"`
+}
+
+type sharpNEATNode struct {
+	Type string `xml:"type,attr"`
+	ID   int64  `xml:"id,attr"`
+	FnID int    `xml:"fnId,attr"`
+}
+
+type sharpNEATConnection struct {
+	ID     int64   `xml:"id,attr"`
+	Src    int64   `xml:"src,attr"`
+	Tgt    int64   `xml:"tgt,attr"`
+	Weight float64 `xml:"wght,attr"`
+}
+
+// ReadSharpNEATGenomes reads a SharpNEAT 2 genome XML file. The bias node is
+// folded into per-node biases, so imported genomes have only the regular
+// inputs. Activation functions we cannot reproduce exactly are rejected.
+func ReadSharpNEATGenomes(r io.Reader) ([]Genome, *InnovationTracker, error) {
+	var root sharpNEATRoot
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, nil, err
+	}
+	fns := make(map[int]string, len(root.Functions))
+	for _, fn := range root.Functions {
+		fns[fn.ID] = fn.Name
+	}
+
+	foreign := make([]foreignGenome, 0, len(root.Networks))
+	for _, net := range root.Networks {
+		fg, err := net.foreign(fns)
+		if err != nil {
+			return nil, nil, fmt.Errorf("sharpneat network %d: %w", net.ID, err)
+		}
+		foreign = append(foreign, fg)
+	}
+	return finishImport(foreign)
+}
+
+func (net sharpNEATNetwork) foreign(fns map[int]string) (foreignGenome, error) {
+	fg := foreignGenome{id: GenomeID(net.ID), birth: net.BirthGen, fitness: net.Fitness}
+	bias := int64(0)
+	hasBias := false
+	gains := map[int64]float64{}
+	nodeIndex := map[int64]int{}
+	for _, n := range net.Nodes {
+		switch n.Type {
+		case "bias":
+			bias, hasBias = n.ID, true
+			continue
+		case "in":
+			fg.inputs = append(fg.inputs, n.ID)
+			continue
+		}
+		kind := NodeHidden
+		switch n.Type {
+		case "out":
+			kind = NodeOutput
+			fg.outputs = append(fg.outputs, n.ID)
+		case "hid":
+		default:
+			return foreignGenome{}, fmt.Errorf("node %d has unknown type %q", n.ID, n.Type)
+		}
+		name, ok := fns[n.FnID]
+		if !ok {
+			return foreignGenome{}, fmt.Errorf("node %d references unknown activation function %d", n.ID, n.FnID)
+		}
+		fn, ok := sharpNEATActivations[name]
+		if !ok {
+			return foreignGenome{}, fmt.Errorf("node %d: activation %q is not supported", n.ID, name)
+		}
+		gains[n.ID] = fn.gain
+		nodeIndex[n.ID] = len(fg.nodes)
+		fg.nodes = append(fg.nodes, foreignNode{key: n.ID, kind: kind, activation: fn.activation})
+	}
+
+	for _, c := range net.Connections {
+		gain, ok := gains[c.Tgt]
+		if !ok {
+			return foreignGenome{}, fmt.Errorf("connection %d targets node %d which is not a hidden or output node", c.ID, c.Tgt)
+		}
+		if hasBias && c.Src == bias {
+			fg.nodes[nodeIndex[c.Tgt]].bias += gain * c.Weight
+			continue
+		}
+		fg.conns = append(fg.conns, foreignConn{in: c.Src, out: c.Tgt, weight: gain * c.Weight, enabled: true})
+	}
+	return fg, nil
+}
+
+// WriteSharpNEATGenomes writes genomes as a SharpNEAT 2 genome XML file.
+// Node 0 is the bias node carrying our per-node biases, inputs are 1..n,
+// outputs n+1..n+m and hidden nodes follow; connection ids come after the
+// node ids and are shared across genomes. SharpNEAT has no disabled
+// connections, so disabled genes are dropped.
+func WriteSharpNEATGenomes(w io.Writer, genomes []Genome) error {
+	layout, err := newExportLayout(genomes)
+	if err != nil {
+		return err
+	}
+	firstConnID := int64(1 + layout.inputs + layout.outputs + len(layout.hidden))
+	connIDs := map[[2]int64]int64{}
+	connID := func(src, tgt int64) int64 {
+		key := [2]int64{src, tgt}
+		id, ok := connIDs[key]
+		if !ok {
+			id = firstConnID + int64(len(connIDs))
+			connIDs[key] = id
+		}
+		return id
+	}
+
+	fnIDs := map[ActivationType]int{}
+	root := sharpNEATRoot{}
+	fnID := func(a ActivationType) (int, error) {
+		if id, ok := fnIDs[a]; ok {
+			return id, nil
+		}
+		name, ok := sharpNEATActivationNames[a]
+		if !ok {
+			return 0, fmt.Errorf("activation %s has no SharpNEAT equivalent", a)
+		}
+		id := len(fnIDs)
+		fnIDs[a] = id
+		root.Functions = append(root.Functions, sharpNEATFunction{ID: id, Name: name})
+		return id, nil
+	}
+	// Bias and input nodes are never evaluated; give them a linear function.
+	if _, err := fnID(ActivationLinear); err != nil {
+		return err
+	}
+
+	for gi, g := range genomes {
+		pos := layout.positions(g)
+		nodes := nodeGeneMap(g.Nodes)
+		key := func(id NodeID) int64 {
+			switch nodes[id].Kind {
+			case NodeInput:
+				return int64(1 + pos[id])
+			case NodeOutput:
+				return int64(1 + layout.inputs + pos[id])
+			default:
+				return int64(1 + layout.inputs + layout.outputs + pos[id])
+			}
+		}
+
+		net := sharpNEATNetwork{ID: int64(g.ID), BirthGen: g.Birth, Fitness: g.Fitness}
+		net.Nodes = append(net.Nodes, sharpNEATNode{Type: "bias", ID: 0, FnID: fnIDs[ActivationLinear]})
+		var biasConns []sharpNEATConnection
+		sorted := make([]NodeGene, len(g.Nodes))
+		copy(sorted, g.Nodes)
+		sort.Slice(sorted, func(i, j int) bool { return key(sorted[i].ID) < key(sorted[j].ID) })
+		for _, n := range sorted {
+			node := sharpNEATNode{ID: key(n.ID), FnID: fnIDs[ActivationLinear]}
+			switch n.Kind {
+			case NodeInput:
+				node.Type = "in"
+			case NodeOutput:
+				node.Type = "out"
+			default:
+				node.Type = "hid"
+			}
+			if n.Kind != NodeInput {
+				id, err := fnID(n.Activation)
+				if err != nil {
+					return fmt.Errorf("genome %d node %d: %w", gi, n.ID, err)
+				}
+				node.FnID = id
+				if n.Bias != 0 {
+					tgt := key(n.ID)
+					biasConns = append(biasConns, sharpNEATConnection{
+						ID:     connID(0, tgt),
+						Src:    0,
+						Tgt:    tgt,
+						Weight: n.Bias / sharpNEATActivations[sharpNEATActivationNames[n.Activation]].gain,
+					})
+				}
+			}
+			net.Nodes = append(net.Nodes, node)
+		}
+
+		for _, c := range sortedConnections(g.Connections) {
+			if !c.Enabled {
+				continue
+			}
+			_, inOK := nodes[c.In]
+			out, outOK := nodes[c.Out]
+			if !inOK || !outOK {
+				return fmt.Errorf("genome %d: connection %d references a missing node", gi, c.Innovation)
+			}
+			src, tgt := key(c.In), key(c.Out)
+			net.Connections = append(net.Connections, sharpNEATConnection{
+				ID:     connID(src, tgt),
+				Src:    src,
+				Tgt:    tgt,
+				Weight: c.Weight / sharpNEATActivations[sharpNEATActivationNames[out.Activation]].gain,
+			})
+		}
+		net.Connections = append(net.Connections, biasConns...)
+		sort.Slice(net.Connections, func(i, j int) bool { return net.Connections[i].ID < net.Connections[j].ID })
+		root.Networks = append(root.Networks, net)
+	}
+
+	for i := range root.Functions {
+		root.Functions[i].Prob = 1 / float64(len(root.Functions))
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,112 @@
+package neat
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+const sharpNEATXML = `<?xml version="1.0" encoding="utf-8"?>
+<Root>
+  <ActivationFunctions>
+    <Fn id="0" name="SteepenedSigmoid" prob="0.5" />
+    <Fn id="1" name="Sine" prob="0.5" />
+  </ActivationFunctions>
+  <Networks>
+    <Network id="42" birthGen="17" fitness="0.75">
+      <Nodes>
+        <Node type="bias" id="0" fnId="0" />
+        <Node type="in" id="1" fnId="0" />
+        <Node type="in" id="2" fnId="0" />
+        <Node type="out" id="3" fnId="0" />
+        <Node type="hid" id="20" fnId="1" />
+      </Nodes>
+      <Connections>
+        <Con id="4" src="0" tgt="3" wght="-0.2" />
+        <Con id="5" src="1" tgt="20" wght="0.9" />
+        <Con id="6" src="2" tgt="3" wght="0.6" />
+        <Con id="21" src="20" tgt="3" wght="1.5" />
+        <Con id="22" src="0" tgt="20" wght="0.3" />
+      </Connections>
+    </Network>
+  </Networks>
+</Root>`
+
+func sharpNEATReference(x1, x2 float64) float64 {
+	h := math.Sin(2 * (0.3 + 0.9*x1))
+	return 1 / (1 + math.Exp(-4.9*(-0.2+0.6*x2+1.5*h)))
+}
+
+func TestReadSharpNEATGenomes(t *testing.T) {
+	genomes, tracker, err := ReadSharpNEATGenomes(strings.NewReader(sharpNEATXML))
+	if err != nil {
+		t.Fatalf("ReadSharpNEATGenomes error: %v", err)
+	}
+	g := genomes[0]
+	if g.ID != 42 || g.Birth != 17 || g.Fitness != 0.75 {
+		t.Fatalf("unexpected metadata %+v", g)
+	}
+	if len(g.Nodes) != 4 || len(g.Connections) != 3 {
+		t.Fatalf("expected bias node folded into biases, got %d nodes %d connections", len(g.Nodes), len(g.Connections))
+	}
+	plan, err := BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	for _, in := range [][2]float64{{0, 0}, {1, -1}, {0.4, 0.8}} {
+		out, err := plan.Eval([]float64{in[0], in[1]})
+		if err != nil {
+			t.Fatalf("Eval error: %v", err)
+		}
+		if want := sharpNEATReference(in[0], in[1]); math.Abs(out[0]-want) > 1e-12 {
+			t.Fatalf("inputs %v: got %v want %v", in, out[0], want)
+		}
+	}
+	if id := tracker.NextNodeID(); id != 5 {
+		t.Fatalf("expected next node id 5, got %d", id)
+	}
+}
+
+func TestSharpNEATRoundTrip(t *testing.T) {
+	genomes, _, err := ReadSharpNEATGenomes(strings.NewReader(sharpNEATXML))
+	if err != nil {
+		t.Fatalf("ReadSharpNEATGenomes error: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteSharpNEATGenomes(buf, genomes); err != nil {
+		t.Fatalf("WriteSharpNEATGenomes error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `type="bias"`) || !strings.Contains(buf.String(), `name="Sine"`) {
+		t.Fatalf("unexpected XML:\n%s", buf.String())
+	}
+	again, _, err := ReadSharpNEATGenomes(buf)
+	if err != nil {
+		t.Fatalf("re-read error: %v", err)
+	}
+	a, _ := BuildAcyclicPlan(genomes[0], nil, nil)
+	b, _ := BuildAcyclicPlan(again[0], nil, nil)
+	outA, _ := a.Eval([]float64{0.1, 0.2})
+	outB, _ := b.Eval([]float64{0.1, 0.2})
+	if math.Abs(outA[0]-outB[0]) > 1e-12 {
+		t.Fatalf("round trip changed output %v -> %v", outA[0], outB[0])
+	}
+}
+
+func TestSharpNEATErrors(t *testing.T) {
+	unsupported := strings.Replace(sharpNEATXML, `name="Sine"`, `name="BipolarGaussian"`, 1)
+	if _, _, err := ReadSharpNEATGenomes(strings.NewReader(unsupported)); err == nil {
+		t.Fatalf("expected error for unsupported activation")
+	}
+
+	g := Genome{
+		Nodes: []NodeGene{
+			{ID: 1, Kind: NodeInput},
+			{ID: 2, Kind: NodeOutput, Activation: ActivationGaussian},
+		},
+		Connections: []ConnectionGene{{Innovation: 1, In: 1, Out: 2, Weight: 1, Enabled: true}},
+	}
+	if err := WriteSharpNEATGenomes(&bytes.Buffer{}, []Genome{g}); err == nil {
+		t.Fatalf("expected error exporting gaussian activation")
+	}
+}
@@ -0,0 +1,145 @@
+package neat
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// HallOfFame keeps the fittest structurally distinct genomes seen across a
+// run. Genomes with the same structural key compete for one slot and only
+// the fitter one is kept.
+type HallOfFame struct {
+	capacity int
+	entries  []Genome
+	keys     []string
+}
+
+// NewHallOfFame creates a hall of fame holding up to capacity genomes.
+func NewHallOfFame(capacity int) *HallOfFame {
+	return &HallOfFame{capacity: capacity}
+}
+
+// StructuralKey identifies a genome's topology: node ids, kinds and
+// activations plus the enabled connection innovations. Weights and biases
+// are ignored.
+func StructuralKey(g Genome) string {
+	nodes := make([]NodeGene, len(g.Nodes))
+	copy(nodes, g.Nodes)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	var b strings.Builder
+	for _, n := range nodes {
+		fmt.Fprintf(&b, "%d:%d:%d;", n.ID, n.Kind, n.Activation)
+	}
+	b.WriteString("|")
+	for _, c := range sortedConnections(g.Connections) {
+		if c.Enabled {
+			fmt.Fprintf(&b, "%d;", c.Innovation)
+		}
+	}
+	return b.String()
+}
+
+// Capacity returns the maximum number of genomes kept.
+func (h *HallOfFame) Capacity() int {
+	return h.capacity
+}
+
+// Len returns the number of genomes currently kept.
+func (h *HallOfFame) Len() int {
+	return len(h.entries)
+}
+
+// Add offers a genome to the hall of fame and reports whether it was kept.
+func (h *HallOfFame) Add(g Genome) bool {
+	if h == nil || h.capacity <= 0 {
+		return false
+	}
+	key := StructuralKey(g)
+	for i, k := range h.keys {
+		if k != key {
+			continue
+		}
+		if g.Fitness <= h.entries[i].Fitness {
+			return false
+		}
+		h.remove(i)
+		h.insert(g, key)
+		return true
+	}
+	if len(h.entries) >= h.capacity {
+		if g.Fitness <= h.entries[len(h.entries)-1].Fitness {
+			return false
+		}
+		h.remove(len(h.entries) - 1)
+	}
+	h.insert(g, key)
+	return true
+}
+
+// Update offers every genome to the hall of fame.
+func (h *HallOfFame) Update(genomes []Genome) {
+	for _, g := range genomes {
+		h.Add(g)
+	}
+}
+
+// Best returns the fittest genome kept.
+func (h *HallOfFame) Best() (Genome, bool) {
+	if h == nil || len(h.entries) == 0 {
+		return Genome{}, false
+	}
+	return cloneGenome(h.entries[0]), true
+}
+
+// Genomes returns copies of the kept genomes, fittest first.
+func (h *HallOfFame) Genomes() []Genome {
+	out := make([]Genome, len(h.entries))
+	for i, g := range h.entries {
+		out[i] = cloneGenome(g)
+	}
+	return out
+}
+
+// insert places g in fitness order; ties keep the earlier entry first.
+func (h *HallOfFame) insert(g Genome, key string) {
+	idx := sort.Search(len(h.entries), func(i int) bool { return h.entries[i].Fitness < g.Fitness })
+	h.entries = append(h.entries, Genome{})
+	copy(h.entries[idx+1:], h.entries[idx:])
+	h.entries[idx] = cloneGenome(g)
+	h.keys = append(h.keys, "")
+	copy(h.keys[idx+1:], h.keys[idx:])
+	h.keys[idx] = key
+}
+
+func (h *HallOfFame) remove(idx int) {
+	h.entries = append(h.entries[:idx], h.entries[idx+1:]...)
+	h.keys = append(h.keys[:idx], h.keys[idx+1:]...)
+}
+
+type hallOfFameJSON struct {
+	Capacity int      `json:"capacity"`
+	Genomes  []Genome `json:"genomes"`
+}
+
+// MarshalJSON encodes the capacity and kept genomes.
+func (h *HallOfFame) MarshalJSON() ([]byte, error) {
+	genomes := h.entries
+	if genomes == nil {
+		genomes = []Genome{}
+	}
+	return json.Marshal(hallOfFameJSON{Capacity: h.capacity, Genomes: genomes})
+}
+
+// UnmarshalJSON restores a hall of fame, re-deriving structural keys.
+func (h *HallOfFame) UnmarshalJSON(data []byte) error {
+	var raw hallOfFameJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*h = HallOfFame{capacity: raw.Capacity}
+	h.Update(raw.Genomes)
+	return nil
+}
@@ -0,0 +1,225 @@
+package neat
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func hofGenome(id GenomeID, fitness float64, act ActivationType) Genome {
+	return Genome{
+		ID:      id,
+		Fitness: fitness,
+		Nodes: []NodeGene{
+			{ID: 1, Kind: NodeInput, Activation: ActivationLinear},
+			{ID: 2, Kind: NodeOutput, Activation: act},
+		},
+		Connections: []ConnectionGene{{Innovation: 1, In: 1, Out: 2, Weight: float64(id), Enabled: true}},
+	}
+}
+
+func TestHallOfFameDeduplicatesStructurally(t *testing.T) {
+	hof := NewHallOfFame(2)
+	if !hof.Add(hofGenome(1, 1.0, ActivationSigmoid)) {
+		t.Fatalf("expected first genome to be kept")
+	}
+	// Same structure, different weight: replaces only when fitter.
+	if hof.Add(hofGenome(2, 0.5, ActivationSigmoid)) {
+		t.Fatalf("expected weaker structural duplicate to be rejected")
+	}
+	if !hof.Add(hofGenome(3, 2.0, ActivationSigmoid)) {
+		t.Fatalf("expected fitter structural duplicate to replace entry")
+	}
+	if hof.Len() != 1 {
+		t.Fatalf("expected 1 entry, got %d", hof.Len())
+	}
+
+	hof.Add(hofGenome(4, 1.5, ActivationTanh))
+	hof.Add(hofGenome(5, 0.1, ActivationSin))
+	if hof.Len() != 2 {
+		t.Fatalf("expected capacity to cap entries at 2, got %d", hof.Len())
+	}
+	hof.Add(hofGenome(6, 3.0, ActivationGaussian))
+
+	genomes := hof.Genomes()
+	if genomes[0].ID != 6 || genomes[1].ID != 3 {
+		t.Fatalf("unexpected hall of fame order: %d, %d", genomes[0].ID, genomes[1].ID)
+	}
+}
+
+func TestHallOfFameJSONRoundTrip(t *testing.T) {
+	hof := NewHallOfFame(3)
+	hof.Add(hofGenome(1, 1.0, ActivationSigmoid))
+	hof.Add(hofGenome(2, 2.0, ActivationTanh))
+
+	data, err := json.Marshal(hof)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	var loaded HallOfFame
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if loaded.Capacity() != 3 || loaded.Len() != 2 {
+		t.Fatalf("unexpected loaded hall of fame: capacity %d len %d", loaded.Capacity(), loaded.Len())
+	}
+	best, _ := loaded.Best()
+	if best.ID != 2 {
+		t.Fatalf("expected best id 2, got %d", best.ID)
+	}
+}
+
+func TestRunnerHallOfFameKeepsEarlierChampion(t *testing.T) {
+	pop := contextTestPopulation(t, 4)
+	generation := 0
+	runner := Runner{
+		Population:       pop,
+		Mutation:         DefaultMutationConfig(),
+		Reproduction:     DefaultReproductionConfig(),
+		HallOfFame:       NewHallOfFame(3),
+		HallOfFameElites: 1,
+		Reporters:        []Reporter{&generationCounter{gen: &generation}},
+		Fitness: func(g *Genome) (float64, error) {
+			// Fitness only ever gets worse after the first generation.
+			return 10 - float64(generation) - 0.01*float64(g.ID%4), nil
+		},
+	}
+
+	best, _, err := runner.Run(4, math.Inf(1))
+	if err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	if best.Fitness != 10-0.01*float64(best.ID%4) || best.Birth != 0 {
+		t.Fatalf("expected first-generation champion, got fitness %v birth %d", best.Fitness, best.Birth)
+	}
+
+	found := false
+	for _, g := range pop.Genomes {
+		if g.ID == best.ID {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected champion %d to be re-injected into the population", best.ID)
+	}
+}
+
+type generationCounter struct {
+	BaseReporter
+	gen *int
+}
+
+func (c *generationCounter) GenerationStart(gen int) error {
+	*c.gen = gen
+	return nil
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	pop := contextTestPopulation(t, 3)
+	pop.Generation = 7
+	hof := NewHallOfFame(2)
+	hof.Add(hofGenome(99, 5, ActivationSin))
+
+	buf := &bytes.Buffer{}
+	if err := SaveCheckpoint(buf, pop, hof); err != nil {
+		t.Fatalf("SaveCheckpoint error: %v", err)
+	}
+	cp, err := LoadCheckpoint(buf)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint error: %v", err)
+	}
+	if cp.HallOfFame == nil || cp.HallOfFame.Len() != 1 {
+		t.Fatalf("expected hall of fame in checkpoint")
+	}
+	restored, err := cp.Population(NewRand(1), DefaultPopulationConfig())
+	if err != nil {
+		t.Fatalf("Population error: %v", err)
+	}
+	if restored.Generation != 7 || len(restored.Genomes) != 3 {
+		t.Fatalf("unexpected restored population: generation %d size %d", restored.Generation, len(restored.Genomes))
+	}
+}
+
+func TestCheckpointResumeReinjectsWithoutCollisions(t *testing.T) {
+	pop := contextTestPopulation(t, 4)
+	// The elite was archived earlier; its hidden nodes, innovations and id
+	// are above anything left in the population.
+	elite := cloneGenome(pop.Genomes[0])
+	rng := NewRand(2)
+	for i := 0; i < 2; i++ {
+		if err := MutateAddNode(rng, &elite, pop.Tracker, []ActivationType{ActivationSin}); err != nil {
+			t.Fatalf("MutateAddNode error: %v", err)
+		}
+	}
+	elite.ID = 1000
+	elite.Fitness = 10
+	hof := NewHallOfFame(1)
+	hof.Add(elite)
+
+	buf := &bytes.Buffer{}
+	if err := SaveCheckpoint(buf, pop, hof); err != nil {
+		t.Fatalf("SaveCheckpoint error: %v", err)
+	}
+	cp, err := LoadCheckpoint(buf)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint error: %v", err)
+	}
+	restored, err := cp.Population(NewRand(1), DefaultPopulationConfig())
+	if err != nil {
+		t.Fatalf("Population error: %v", err)
+	}
+
+	maxNode, maxInnov := NodeID(0), InnovID(0)
+	for _, n := range elite.Nodes {
+		maxNode = max(maxNode, n.ID)
+	}
+	for _, c := range elite.Connections {
+		maxInnov = max(maxInnov, c.Innovation)
+	}
+	node := restored.Tracker.NextNodeID()
+	if node <= maxNode {
+		t.Fatalf("node id %d collides with the hall of fame (max %d)", node, maxNode)
+	}
+	if innov := restored.Tracker.Innovation(1, node); innov <= maxInnov {
+		t.Fatalf("innovation %d collides with the hall of fame (max %d)", innov, maxInnov)
+	}
+
+	runner := Runner{
+		Population:       restored,
+		Mutation:         DefaultMutationConfig(),
+		Reproduction:     DefaultReproductionConfig(),
+		HallOfFame:       cp.HallOfFame,
+		HallOfFameElites: 1,
+	}
+	for gen := 0; gen < 2; gen++ {
+		for i := range restored.Genomes {
+			restored.Genomes[i].Fitness = float64(i)
+		}
+		if err := restored.Speciate(); err != nil {
+			t.Fatalf("Speciate error: %v", err)
+		}
+		if err := runner.reproduce(); err != nil {
+			t.Fatalf("reproduce error: %v", err)
+		}
+		seen := make(map[GenomeID]bool)
+		for _, g := range restored.Genomes {
+			if seen[g.ID] {
+				t.Fatalf("duplicate genome id %d after re-injection", g.ID)
+			}
+			seen[g.ID] = true
+			if g.ID != elite.ID && g.Birth == restored.Generation && g.ID < elite.ID {
+				t.Fatalf("new genome id %d is below the archived id %d", g.ID, elite.ID)
+			}
+		}
+		if !seen[elite.ID] {
+			t.Fatalf("expected the hall of fame elite to be re-injected")
+		}
+		// Every child logged for this generation must still be in it.
+		for _, rec := range restored.Lineage.Records() {
+			if rec.Generation == restored.Generation && !seen[rec.ID] {
+				t.Fatalf("lineage records genome %d, which was replaced before evaluation", rec.ID)
+			}
+		}
+	}
+}
@@ -4,8 +4,14 @@ import "sort"
 
 func cloneGenome(g Genome) Genome {
 	clone := Genome{
+		ID:      g.ID,
+		Birth:   g.Birth,
 		Fitness: g.Fitness,
 	}
+	if len(g.Parents) > 0 {
+		clone.Parents = make([]GenomeID, len(g.Parents))
+		copy(clone.Parents, g.Parents)
+	}
 	if len(g.Nodes) > 0 {
 		clone.Nodes = make([]NodeGene, len(g.Nodes))
 		copy(clone.Nodes, g.Nodes)
@@ -0,0 +1,187 @@
+package neat
+
+import "fmt"
+
+// BreedSelected replaces the population with the offspring of the genomes at
+// the selected indices, for interactive evolution where a person picks the
+// parents instead of a fitness function. Weights, if given, bias how often
+// each selected genome is picked as a parent and which parent leads
+// crossover; nil weights treat the selection equally. Up to rcfg.Elitism
+// selected genomes survive unchanged, heaviest first, and the rest of the
+// generation is bred using rcfg.CrossoverProb and mcfg. Species and fitness
+// are ignored and reset.
+func (p *Population) BreedSelected(selected []int, weights []float64, mcfg MutationConfig, rcfg ReproductionConfig) error {
+	if p.RNG == nil {
+		return fmt.Errorf("rng is nil")
+	}
+	popSize := len(p.Genomes)
+	if len(selected) == 0 {
+		return fmt.Errorf("no genomes selected")
+	}
+	if weights != nil && len(weights) != len(selected) {
+		return fmt.Errorf("got %d weights for %d selected genomes", len(weights), len(selected))
+	}
+	parents := make([]Genome, len(selected))
+	seen := make(map[int]bool, len(selected))
+	for i, idx := range selected {
+		if idx < 0 || idx >= popSize {
+			return fmt.Errorf("selected index %d out of range [0,%d)", idx, popSize)
+		}
+		if seen[idx] {
+			return fmt.Errorf("genome %d selected twice", idx)
+		}
+		seen[idx] = true
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+			if w < 0 {
+				return fmt.Errorf("weight %d is negative", i)
+			}
+		}
+		parents[i] = cloneGenome(p.Genomes[idx])
+		parents[i].Fitness = w
+	}
+	// Selected parents act as a single species whose fitness is the weight.
+	pool := &Population{RNG: p.RNG, Genomes: parents}
+	order := make([]int, len(parents))
+	for i := range order {
+		order[i] = i
+	}
+	order = sortMembersByFitness(parents, order)
+
+	next := make([]Genome, 0, popSize)
+	births := make([]LineageRecord, 0, popSize)
+	elitism := minInt(rcfg.Elitism, len(order))
+	if elitism > popSize {
+		elitism = popSize
+	}
+	for _, idx := range order[:elitism] {
+		child := cloneGenome(parents[idx])
+		child.Fitness = 0
+		next = append(next, child)
+	}
+	for len(next) < popSize {
+		child, err := pool.makeOffspring(order, 0, rcfg)
+		if err != nil {
+			return err
+		}
+		mutations, err := mcfg.MutateLogged(p.RNG, &child, p.Tracker)
+		if err != nil {
+			return err
+		}
+		child.ID = p.newGenomeID()
+		child.Birth = p.Generation + 1
+		child.Fitness = 0
+		next = append(next, child)
+		births = append(births, LineageRecord{
+			ID:         child.ID,
+			Parents:    child.Parents,
+			Generation: child.Birth,
+			Mutations:  mutations,
+		})
+	}
+	p.Species = nil
+	return p.commitGeneration(next, births)
+}
+
+// SelectionHistory wraps a population with undo and redo of interactive
+// generations. Undo restores the genomes, species and generation number of
+// the previous generation; genome ids, innovations and lineage records are
+// never reused, so breeding again after an undo starts a new branch.
+type SelectionHistory struct {
+	Population   *Population
+	Mutation     MutationConfig
+	Reproduction ReproductionConfig
+	// Limit caps the number of undoable generations; zero keeps all of them.
+	Limit int
+
+	undo []generationSnapshot
+	redo []generationSnapshot
+}
+
+type generationSnapshot struct {
+	genomes    []Genome
+	species    []Species
+	generation int
+}
+
+// NewSelectionHistory returns a history for pop with no undoable steps.
+func NewSelectionHistory(pop *Population, mcfg MutationConfig, rcfg ReproductionConfig) (*SelectionHistory, error) {
+	if pop == nil || len(pop.Genomes) == 0 {
+		return nil, fmt.Errorf("population has no genomes")
+	}
+	return &SelectionHistory{Population: pop, Mutation: mcfg, Reproduction: rcfg}, nil
+}
+
+// Breed records the current generation and breeds the next one from the
+// selection; see Population.BreedSelected. It clears the redo stack.
+func (h *SelectionHistory) Breed(selected []int, weights []float64) error {
+	snap := h.snapshot()
+	if err := h.Population.BreedSelected(selected, weights, h.Mutation, h.Reproduction); err != nil {
+		h.restore(snap)
+		return err
+	}
+	h.undo = append(h.undo, snap)
+	if h.Limit > 0 && len(h.undo) > h.Limit {
+		h.undo = h.undo[len(h.undo)-h.Limit:]
+	}
+	h.redo = nil
+	return nil
+}
+
+// Undo returns to the previous generation. It reports false if there is none.
+func (h *SelectionHistory) Undo() bool {
+	if len(h.undo) == 0 {
+		return false
+	}
+	h.redo = append(h.redo, h.snapshot())
+	h.restore(h.undo[len(h.undo)-1])
+	h.undo = h.undo[:len(h.undo)-1]
+	return true
+}
+
+// Redo reapplies the generation last undone. It reports false if there is
+// none.
+func (h *SelectionHistory) Redo() bool {
+	if len(h.redo) == 0 {
+		return false
+	}
+	h.undo = append(h.undo, h.snapshot())
+	h.restore(h.redo[len(h.redo)-1])
+	h.redo = h.redo[:len(h.redo)-1]
+	return true
+}
+
+// CanUndo reports whether Undo would change the population.
+func (h *SelectionHistory) CanUndo() bool {
+	return len(h.undo) > 0
+}
+
+// CanRedo reports whether Redo would change the population.
+func (h *SelectionHistory) CanRedo() bool {
+	return len(h.redo) > 0
+}
+
+func (h *SelectionHistory) snapshot() generationSnapshot {
+	p := h.Population
+	snap := generationSnapshot{
+		genomes:    make([]Genome, len(p.Genomes)),
+		species:    make([]Species, len(p.Species)),
+		generation: p.Generation,
+	}
+	for i, g := range p.Genomes {
+		snap.genomes[i] = cloneGenome(g)
+	}
+	for i, s := range p.Species {
+		s.Members = append([]int(nil), s.Members...)
+		snap.species[i] = s
+	}
+	return snap
+}
+
+func (h *SelectionHistory) restore(snap generationSnapshot) {
+	p := h.Population
+	p.Genomes = snap.genomes
+	p.Species = snap.species
+	p.Generation = snap.generation
+}
@@ -0,0 +1,178 @@
+package neat
+
+import "testing"
+
+func TestBreedSelectedUsesOnlySelectedParents(t *testing.T) {
+	pop := contextTestPopulation(t, 8)
+	selected := []int{2, 5}
+	chosen := map[GenomeID]bool{pop.Genomes[2].ID: true, pop.Genomes[5].ID: true}
+
+	rcfg := DefaultReproductionConfig()
+	rcfg.Elitism = 1
+	if err := pop.BreedSelected(selected, []float64{1, 3}, DefaultMutationConfig(), rcfg); err != nil {
+		t.Fatalf("BreedSelected error: %v", err)
+	}
+	if len(pop.Genomes) != 8 {
+		t.Fatalf("expected 8 genomes, got %d", len(pop.Genomes))
+	}
+	if pop.Generation != 1 {
+		t.Fatalf("expected generation 1, got %d", pop.Generation)
+	}
+	if !chosen[pop.Genomes[0].ID] {
+		t.Fatalf("expected the elite to keep a selected genome's id, got %d", pop.Genomes[0].ID)
+	}
+	for _, g := range pop.Genomes[1:] {
+		if len(g.Parents) == 0 {
+			t.Fatalf("offspring %d has no parents", g.ID)
+		}
+		for _, parent := range g.Parents {
+			if !chosen[parent] {
+				t.Fatalf("offspring %d has unselected parent %d", g.ID, parent)
+			}
+		}
+		if _, ok := pop.Lineage.Get(g.ID); !ok {
+			t.Fatalf("offspring %d missing from lineage", g.ID)
+		}
+	}
+}
+
+func TestBreedSelectedElitePrefersHeaviestWeight(t *testing.T) {
+	pop := contextTestPopulation(t, 4)
+	heavy := pop.Genomes[3].ID
+	rcfg := DefaultReproductionConfig()
+	rcfg.Elitism = 1
+	if err := pop.BreedSelected([]int{0, 3}, []float64{0.5, 2}, DefaultMutationConfig(), rcfg); err != nil {
+		t.Fatalf("BreedSelected error: %v", err)
+	}
+	if pop.Genomes[0].ID != heavy {
+		t.Fatalf("expected elite %d, got %d", heavy, pop.Genomes[0].ID)
+	}
+	if pop.Genomes[0].Fitness != 0 {
+		t.Fatalf("expected elite fitness reset, got %v", pop.Genomes[0].Fitness)
+	}
+}
+
+func TestBreedSelectedRejectsBadSelection(t *testing.T) {
+	pop := contextTestPopulation(t, 4)
+	mcfg, rcfg := DefaultMutationConfig(), DefaultReproductionConfig()
+	cases := []struct {
+		name     string
+		selected []int
+		weights  []float64
+	}{
+		{"empty", nil, nil},
+		{"out of range", []int{4}, nil},
+		{"duplicate", []int{1, 1}, nil},
+		{"weight count", []int{0, 1}, []float64{1}},
+		{"negative weight", []int{0}, []float64{-1}},
+	}
+	for _, tc := range cases {
+		if err := pop.BreedSelected(tc.selected, tc.weights, mcfg, rcfg); err == nil {
+			t.Fatalf("%s: expected error", tc.name)
+		}
+	}
+	if pop.Generation != 0 {
+		t.Fatalf("failed breeding changed the generation to %d", pop.Generation)
+	}
+}
+
+func TestSelectionHistoryUndoRedo(t *testing.T) {
+	pop := contextTestPopulation(t, 6)
+	h, err := NewSelectionHistory(pop, DefaultMutationConfig(), DefaultReproductionConfig())
+	if err != nil {
+		t.Fatalf("NewSelectionHistory error: %v", err)
+	}
+	if h.CanUndo() || h.CanRedo() {
+		t.Fatalf("new history should have nothing to undo or redo")
+	}
+	ids := func() []GenomeID {
+		out := make([]GenomeID, len(pop.Genomes))
+		for i, g := range pop.Genomes {
+			out[i] = g.ID
+		}
+		return out
+	}
+	same := func(a, b []GenomeID) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	gen0 := ids()
+	if err := h.Breed([]int{0, 1}, nil); err != nil {
+		t.Fatalf("Breed error: %v", err)
+	}
+	gen1 := ids()
+	if err := h.Breed([]int{2}, nil); err != nil {
+		t.Fatalf("Breed error: %v", err)
+	}
+	gen2 := ids()
+
+	if !h.Undo() || !same(ids(), gen1) || pop.Generation != 1 {
+		t.Fatalf("undo did not restore generation 1")
+	}
+	if !h.Undo() || !same(ids(), gen0) || pop.Generation != 0 {
+		t.Fatalf("undo did not restore generation 0")
+	}
+	if h.Undo() {
+		t.Fatalf("expected nothing left to undo")
+	}
+	if !h.Redo() || !same(ids(), gen1) {
+		t.Fatalf("redo did not restore generation 1")
+	}
+	if !h.Redo() || !same(ids(), gen2) || pop.Generation != 2 {
+		t.Fatalf("redo did not restore generation 2")
+	}
+
+	h.Undo()
+	if err := h.Breed([]int{3}, nil); err != nil {
+		t.Fatalf("Breed error: %v", err)
+	}
+	if h.CanRedo() {
+		t.Fatalf("breeding should clear the redo stack")
+	}
+	for _, g := range pop.Genomes {
+		if g.Birth == 2 && containsID(gen2, g.ID) {
+			t.Fatalf("new branch reused genome id %d", g.ID)
+		}
+	}
+}
+
+func TestSelectionHistoryLimit(t *testing.T) {
+	pop := contextTestPopulation(t, 4)
+	h, err := NewSelectionHistory(pop, DefaultMutationConfig(), DefaultReproductionConfig())
+	if err != nil {
+		t.Fatalf("NewSelectionHistory error: %v", err)
+	}
+	h.Limit = 2
+	for i := 0; i < 4; i++ {
+		if err := h.Breed([]int{0}, nil); err != nil {
+			t.Fatalf("Breed error: %v", err)
+		}
+	}
+	undone := 0
+	for h.Undo() {
+		undone++
+	}
+	if undone != 2 {
+		t.Fatalf("expected 2 undoable generations, got %d", undone)
+	}
+	if pop.Generation != 2 {
+		t.Fatalf("expected generation 2, got %d", pop.Generation)
+	}
+}
+
+func containsID(ids []GenomeID, id GenomeID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
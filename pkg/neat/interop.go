@@ -0,0 +1,183 @@
+package neat
+
+import (
+	"fmt"
+	"sort"
+)
+
+// foreignNode is a non-input node read from another NEAT library, keyed by
+// that library's node id. Bias is already expressed in our activation scale.
+type foreignNode struct {
+	key        int64
+	kind       NodeKind
+	activation ActivationType
+	bias       float64
+}
+
+// foreignConn is a connection between foreign node keys.
+type foreignConn struct {
+	in, out int64
+	weight  float64
+	enabled bool
+}
+
+// foreignGenome is a genome read from another NEAT library before its node
+// keys are renumbered. Inputs and outputs list keys in network order.
+type foreignGenome struct {
+	id      GenomeID
+	birth   int
+	fitness float64
+	inputs  []int64
+	outputs []int64
+	nodes   []foreignNode
+	conns   []foreignConn
+}
+
+// finishImport renumbers foreign genomes onto our id convention: inputs
+// 1..n, outputs n+1..n+m, then hidden nodes in ascending foreign key order.
+// Hidden keys and connection innovations are shared across all genomes so
+// the returned tracker is consistent with every one of them.
+func finishImport(foreign []foreignGenome) ([]Genome, *InnovationTracker, error) {
+	if len(foreign) == 0 {
+		return nil, nil, fmt.Errorf("no genomes to import")
+	}
+	inputs, outputs := len(foreign[0].inputs), len(foreign[0].outputs)
+	hiddenKeys := map[int64]bool{}
+	for i, fg := range foreign {
+		if len(fg.inputs) != inputs || len(fg.outputs) != outputs {
+			return nil, nil, fmt.Errorf("genome %d has %d inputs/%d outputs, expected %d/%d", i, len(fg.inputs), len(fg.outputs), inputs, outputs)
+		}
+		for _, n := range fg.nodes {
+			if n.kind == NodeHidden {
+				hiddenKeys[n.key] = true
+			}
+		}
+	}
+	sortedHidden := make([]int64, 0, len(hiddenKeys))
+	for k := range hiddenKeys {
+		sortedHidden = append(sortedHidden, k)
+	}
+	sort.Slice(sortedHidden, func(i, j int) bool { return sortedHidden[i] < sortedHidden[j] })
+	hiddenIDs := make(map[int64]NodeID, len(sortedHidden))
+	for i, k := range sortedHidden {
+		hiddenIDs[k] = NodeID(inputs + outputs + i + 1)
+	}
+
+	innovations := map[connKey]InnovID{}
+	genomes := make([]Genome, 0, len(foreign))
+	for gi, fg := range foreign {
+		ids := make(map[int64]NodeID, inputs+outputs+len(fg.nodes))
+		g := Genome{ID: fg.id, Birth: fg.birth, Fitness: fg.fitness}
+		for i, k := range fg.inputs {
+			ids[k] = NodeID(i + 1)
+			g.Nodes = append(g.Nodes, NodeGene{ID: NodeID(i + 1), Kind: NodeInput, Activation: ActivationLinear})
+		}
+		for i, k := range fg.outputs {
+			ids[k] = NodeID(inputs + i + 1)
+		}
+		nodes := make([]NodeGene, 0, len(fg.nodes))
+		seenOutputs := 0
+		for _, n := range fg.nodes {
+			id, ok := ids[n.key]
+			if n.kind == NodeHidden {
+				id, ok = hiddenIDs[n.key], true
+				ids[n.key] = id
+			}
+			if !ok {
+				return nil, nil, fmt.Errorf("genome %d: output node %d is not a declared output", gi, n.key)
+			}
+			if n.kind == NodeOutput {
+				seenOutputs++
+			}
+			nodes = append(nodes, NodeGene{ID: id, Kind: n.kind, Activation: n.activation, Bias: n.bias})
+		}
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+		g.Nodes = append(g.Nodes, nodes...)
+		if seenOutputs != outputs {
+			return nil, nil, fmt.Errorf("genome %d: expected %d output nodes, found %d", gi, outputs, seenOutputs)
+		}
+
+		for _, c := range fg.conns {
+			in, ok := ids[c.in]
+			if !ok {
+				return nil, nil, fmt.Errorf("genome %d: connection %d->%d has unknown source", gi, c.in, c.out)
+			}
+			out, ok := ids[c.out]
+			if !ok {
+				return nil, nil, fmt.Errorf("genome %d: connection %d->%d has unknown target", gi, c.in, c.out)
+			}
+			key := connKey{in: in, out: out}
+			innov, ok := innovations[key]
+			if !ok {
+				innov = InnovID(len(innovations) + 1)
+				innovations[key] = innov
+			}
+			g.Connections = append(g.Connections, ConnectionGene{Innovation: innov, In: in, Out: out, Weight: c.weight, Enabled: c.enabled})
+		}
+		g.Connections = sortedConnections(g.Connections)
+		if _, err := BuildAcyclicPlan(g, nil, nil); err != nil {
+			return nil, nil, fmt.Errorf("genome %d: %w (only feed-forward networks are supported)", gi, err)
+		}
+		genomes = append(genomes, g)
+	}
+
+	tracker, err := NewInnovationTracker(genomes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return genomes, tracker, nil
+}
+
+// exportLayout assigns positions to our node ids for export: inputs and
+// outputs by ascending id, hidden nodes by ascending id across all genomes
+// so the same hidden node gets the same foreign key in every genome.
+type exportLayout struct {
+	inputs  int
+	outputs int
+	hidden  map[NodeID]int
+}
+
+func newExportLayout(genomes []Genome) (*exportLayout, error) {
+	if len(genomes) == 0 {
+		return nil, fmt.Errorf("no genomes to export")
+	}
+	layout := &exportLayout{inputs: -1, hidden: map[NodeID]int{}}
+	var hiddenIDs []NodeID
+	for i, g := range genomes {
+		nodes := nodeGeneMap(g.Nodes)
+		inputs := len(nodesByKind(nodes, NodeInput))
+		outputs := len(nodesByKind(nodes, NodeOutput))
+		if layout.inputs < 0 {
+			layout.inputs, layout.outputs = inputs, outputs
+		} else if inputs != layout.inputs || outputs != layout.outputs {
+			return nil, fmt.Errorf("genome %d has %d inputs/%d outputs, expected %d/%d", i, inputs, outputs, layout.inputs, layout.outputs)
+		}
+		for _, id := range nodesByKind(nodes, NodeHidden) {
+			if _, ok := layout.hidden[id]; !ok {
+				layout.hidden[id] = 0
+				hiddenIDs = append(hiddenIDs, id)
+			}
+		}
+	}
+	sort.Slice(hiddenIDs, func(i, j int) bool { return hiddenIDs[i] < hiddenIDs[j] })
+	for i, id := range hiddenIDs {
+		layout.hidden[id] = i
+	}
+	return layout, nil
+}
+
+// positions maps each node id of g to its position among nodes of its kind.
+func (l *exportLayout) positions(g Genome) map[NodeID]int {
+	nodes := nodeGeneMap(g.Nodes)
+	out := make(map[NodeID]int, len(nodes))
+	for i, id := range nodesByKind(nodes, NodeInput) {
+		out[id] = i
+	}
+	for i, id := range nodesByKind(nodes, NodeOutput) {
+		out[id] = i
+	}
+	for _, id := range nodesByKind(nodes, NodeHidden) {
+		out[id] = l.hidden[id]
+	}
+	return out
+}
@@ -0,0 +1,324 @@
+package neat
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// MigrationTopology decides which islands exchange migrants.
+type MigrationTopology uint8
+
+const (
+	// TopologyRing sends migrants from island i to island i+1.
+	TopologyRing MigrationTopology = iota
+	// TopologyFull sends migrants from every island to every other island.
+	TopologyFull
+)
+
+// IslandConfig controls migration between islands.
+type IslandConfig struct {
+	Topology MigrationTopology
+	// Interval is the number of generations between migrations; zero
+	// disables migration.
+	Interval int
+	// Migrants is the number of top genomes each island sends per target.
+	Migrants int
+}
+
+// DefaultIslandConfig returns ring migration of two genomes every ten
+// generations.
+func DefaultIslandConfig() IslandConfig {
+	return IslandConfig{
+		Topology: TopologyRing,
+		Interval: 10,
+		Migrants: 2,
+	}
+}
+
+// IslandRunner evolves several populations side by side. Each island is a
+// Runner with its own population, RNG, reporters and optional hall of fame;
+// the islands share one InnovationTracker so migrants stay compatible.
+// Islands are stepped in order, so runs are deterministic.
+type IslandRunner struct {
+	Islands []*Runner
+	Config  IslandConfig
+}
+
+// IslandGenome is a genome tagged with the island it lives on.
+type IslandGenome struct {
+	Island int
+	Genome Genome
+}
+
+// NewIslandRunner validates the islands and replaces their trackers with one
+// shared tracker that knows every island's innovations.
+func NewIslandRunner(islands []*Runner, cfg IslandConfig) (*IslandRunner, error) {
+	if len(islands) == 0 {
+		return nil, fmt.Errorf("no islands")
+	}
+	if cfg.Interval < 0 || cfg.Migrants < 0 {
+		return nil, fmt.Errorf("migration interval and migrants must be >= 0")
+	}
+	if cfg.Topology != TopologyRing && cfg.Topology != TopologyFull {
+		return nil, fmt.Errorf("unknown migration topology %d", cfg.Topology)
+	}
+	pops := make([]*Population, len(islands))
+	for i, r := range islands {
+		if r == nil || r.Population == nil {
+			return nil, fmt.Errorf("island %d has no population", i)
+		}
+		pops[i] = r.Population
+	}
+	if err := ShareTracker(pops); err != nil {
+		return nil, err
+	}
+	return &IslandRunner{Islands: islands, Config: cfg}, nil
+}
+
+// ShareTracker gives every population one tracker built from all of their
+// genomes and trackers. It fails if two populations assigned different
+// innovation numbers to the same connection.
+func ShareTracker(pops []*Population) error {
+	var genomes []Genome
+	for _, p := range pops {
+		genomes = append(genomes, p.Genomes...)
+	}
+	shared, err := NewInnovationTracker(genomes)
+	if err != nil {
+		return err
+	}
+	for _, p := range pops {
+		t := p.Tracker
+		if t == nil {
+			continue
+		}
+		for key, innov := range t.conns {
+			if err := shared.SeedConnectionInnovation(key.in, key.out, innov); err != nil {
+				return err
+			}
+		}
+		if t.nextNode > shared.nextNode {
+			shared.nextNode = t.nextNode
+		}
+		if t.nextInnov > shared.nextInnov {
+			shared.nextInnov = t.nextInnov
+		}
+	}
+	for _, p := range pops {
+		p.Tracker = shared
+	}
+	return nil
+}
+
+// Run evolves all islands for up to maxGenerations and stops early once any
+// island reaches targetFitness. It returns the best genome across islands,
+// its island and the generation reached.
+func (ir *IslandRunner) Run(maxGenerations int, targetFitness float64) (IslandGenome, int, error) {
+	return ir.RunContext(context.Background(), maxGenerations, targetFitness)
+}
+
+// RunContext is Run with cancellation; on cancellation it returns the best
+// genome evaluated so far with ctx.Err().
+func (ir *IslandRunner) RunContext(ctx context.Context, maxGenerations int, targetFitness float64) (IslandGenome, int, error) {
+	if maxGenerations <= 0 {
+		return IslandGenome{}, 0, fmt.Errorf("maxGenerations must be > 0")
+	}
+	best := IslandGenome{Island: -1}
+	consider := func(island int, g Genome) {
+		if len(g.Nodes) > 0 && (best.Island < 0 || g.Fitness > best.Genome.Fitness) {
+			best = IslandGenome{Island: island, Genome: g}
+		}
+	}
+
+	for gen := 0; gen < maxGenerations; gen++ {
+		for i, r := range ir.Islands {
+			if err := ctx.Err(); err != nil {
+				return best, gen, err
+			}
+			if err := r.reportStart(); err != nil {
+				return IslandGenome{}, gen, err
+			}
+			islandBest, err := r.EvaluateContext(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					consider(i, islandBest)
+					return best, gen, err
+				}
+				return IslandGenome{}, gen, fmt.Errorf("island %d: %w", i, err)
+			}
+			if r.HallOfFame != nil {
+				r.HallOfFame.Update(r.Population.Genomes)
+				if champion, ok := r.HallOfFame.Best(); ok {
+					islandBest = champion
+				}
+			}
+			consider(i, islandBest)
+		}
+
+		last := gen == maxGenerations-1
+		done := best.Genome.Fitness >= targetFitness
+		if ir.Config.Interval > 0 && (gen+1)%ir.Config.Interval == 0 && !last && !done {
+			if err := ir.Migrate(); err != nil {
+				return IslandGenome{}, gen, err
+			}
+		}
+
+		stats := make([]GenerationStats, len(ir.Islands))
+		for i, r := range ir.Islands {
+			s, err := r.speciateAndReport()
+			if err != nil {
+				return IslandGenome{}, gen, fmt.Errorf("island %d: %w", i, err)
+			}
+			stats[i] = s
+		}
+		if done || last {
+			for i, r := range ir.Islands {
+				if err := r.reportEnd(stats[i]); err != nil {
+					return IslandGenome{}, gen, err
+				}
+			}
+			if done {
+				return best, gen, nil
+			}
+			break
+		}
+		for i, r := range ir.Islands {
+			if err := r.reproduce(); err != nil {
+				return IslandGenome{}, gen, fmt.Errorf("island %d: %w", i, err)
+			}
+			if err := r.reportEnd(stats[i]); err != nil {
+				return IslandGenome{}, gen, err
+			}
+		}
+	}
+	return best, maxGenerations - 1, nil
+}
+
+// Migrate copies each island's top genomes, by current fitness, to its
+// targets, replacing the targets' weakest genomes. Emigrants are chosen
+// before any island is changed. Migrants get a new id on arrival and no
+// parents, since ids are only unique within an island; the lineage record
+// names the source island and id instead. Migrants never replace more than
+// half of an island.
+func (ir *IslandRunner) Migrate() error {
+	n := len(ir.Islands)
+	if n < 2 || ir.Config.Migrants <= 0 {
+		return nil
+	}
+	emigrants := make([][]Genome, n)
+	for i, r := range ir.Islands {
+		emigrants[i] = topGenomes(r.Population.Genomes, ir.Config.Migrants)
+	}
+
+	for dst, r := range ir.Islands {
+		var arrivals []Genome
+		var sources []int
+		for src := 0; src < n; src++ {
+			if src == dst || !ir.migrates(src, dst) {
+				continue
+			}
+			for _, g := range emigrants[src] {
+				arrivals = append(arrivals, g)
+				sources = append(sources, src)
+			}
+		}
+		pop := r.Population
+		if pop.Lineage == nil {
+			pop.Lineage = NewLineageLog(nil)
+		}
+		if limit := len(pop.Genomes) / 2; len(arrivals) > limit {
+			arrivals = arrivals[:limit]
+		}
+		order := make([]int, len(pop.Genomes))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return pop.Genomes[order[a]].Fitness < pop.Genomes[order[b]].Fitness
+		})
+		for k, g := range arrivals {
+			migrant := cloneGenome(g)
+			migrant.ID = pop.newGenomeID()
+			migrant.Parents = nil
+			migrant.Birth = pop.Generation
+			pop.Genomes[order[k]] = migrant
+			record := LineageRecord{
+				ID:         migrant.ID,
+				Generation: pop.Generation,
+				Mutations:  []string{fmt.Sprintf("migrate_from_island_%d_genome_%d", sources[k], g.ID)},
+			}
+			if err := pop.Lineage.Append(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (ir *IslandRunner) migrates(src, dst int) bool {
+	if ir.Config.Topology == TopologyFull {
+		return true
+	}
+	return dst == (src+1)%len(ir.Islands)
+}
+
+// topGenomes returns copies of the n fittest genomes, fittest first.
+func topGenomes(genomes []Genome, n int) []Genome {
+	idx := make([]int, len(genomes))
+	for i := range idx {
+		idx[i] = i
+	}
+	idx = sortMembersByFitness(genomes, idx)
+	if n > len(idx) {
+		n = len(idx)
+	}
+	out := make([]Genome, n)
+	for i := 0; i < n; i++ {
+		out[i] = cloneGenome(genomes[idx[i]])
+	}
+	return out
+}
+
+// Stats returns current statistics for each island.
+func (ir *IslandRunner) Stats() []GenerationStats {
+	out := make([]GenerationStats, len(ir.Islands))
+	for i, r := range ir.Islands {
+		out[i] = ComputeStats(r.Population)
+	}
+	return out
+}
+
+// Merged returns every island's genomes, fittest first, for a combined
+// gallery. Ties keep island order.
+func (ir *IslandRunner) Merged() []IslandGenome {
+	var out []IslandGenome
+	for i, r := range ir.Islands {
+		for _, g := range r.Population.Genomes {
+			out = append(out, IslandGenome{Island: i, Genome: cloneGenome(g)})
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool { return out[a].Genome.Fitness > out[b].Genome.Fitness })
+	return out
+}
+
+// MergedStats summarises all islands as if they were one population; each
+// island's species are listed separately.
+func (ir *IslandRunner) MergedStats() GenerationStats {
+	merged := &Population{}
+	for _, r := range ir.Islands {
+		offset := len(merged.Genomes)
+		merged.Genomes = append(merged.Genomes, r.Population.Genomes...)
+		for _, s := range r.Population.Species {
+			members := make([]int, len(s.Members))
+			for i, m := range s.Members {
+				members[i] = m + offset
+			}
+			merged.Species = append(merged.Species, Species{ID: s.ID, Representative: s.Representative + offset, Members: members})
+		}
+		if r.Population.Generation > merged.Generation {
+			merged.Generation = r.Population.Generation
+		}
+	}
+	return ComputeStats(merged)
+}
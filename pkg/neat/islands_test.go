@@ -0,0 +1,200 @@
+package neat
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func islandTestRunners(t *testing.T, n int) []*Runner {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Inputs = 2
+	cfg.Outputs = 1
+	cfg.PopulationSize = 12
+	pops, err := cfg.NewIslands(n)
+	if err != nil {
+		t.Fatalf("NewIslands error: %v", err)
+	}
+	runners := make([]*Runner, n)
+	for i, pop := range pops {
+		runners[i] = &Runner{
+			Population:   pop,
+			Mutation:     cfg.Mutation,
+			Reproduction: cfg.Reproduction,
+			Fitness: func(g *Genome) (float64, error) {
+				sum := 0.0
+				for _, c := range g.Connections {
+					sum += c.Weight
+				}
+				return sum + float64(len(g.Nodes)), nil
+			},
+		}
+	}
+	return runners
+}
+
+func TestShareTracker(t *testing.T) {
+	runners := islandTestRunners(t, 3)
+	runners[1].Population.Tracker.NextNodeID()
+	runners[1].Population.Tracker.NextNodeID()
+	want := runners[1].Population.Tracker.nextNode
+
+	ir, err := NewIslandRunner(runners, DefaultIslandConfig())
+	if err != nil {
+		t.Fatalf("NewIslandRunner error: %v", err)
+	}
+	shared := ir.Islands[0].Population.Tracker
+	for i, r := range ir.Islands {
+		if r.Population.Tracker != shared {
+			t.Fatalf("island %d does not use the shared tracker", i)
+		}
+	}
+	if shared.nextNode < want {
+		t.Fatalf("shared tracker would reuse node ids: next %d < %d", shared.nextNode, want)
+	}
+}
+
+func TestIslandMigrationRing(t *testing.T) {
+	runners := islandTestRunners(t, 3)
+	for i, r := range runners {
+		for j := range r.Population.Genomes {
+			r.Population.Genomes[j].Fitness = float64(100*i + j)
+		}
+	}
+	cfg := DefaultIslandConfig()
+	cfg.Migrants = 2
+	ir, err := NewIslandRunner(runners, cfg)
+	if err != nil {
+		t.Fatalf("NewIslandRunner error: %v", err)
+	}
+	if err := ir.Migrate(); err != nil {
+		t.Fatalf("Migrate error: %v", err)
+	}
+
+	// Island 1 receives island 0's best two (fitness 11 and 10) in place of
+	// its own worst two (100 and 101).
+	pop := ir.Islands[1].Population
+	if pop.Genomes[0].Fitness != 11 || pop.Genomes[1].Fitness != 10 {
+		t.Fatalf("unexpected migrants %v %v", pop.Genomes[0].Fitness, pop.Genomes[1].Fitness)
+	}
+	rec, ok := pop.Lineage.Get(pop.Genomes[0].ID)
+	if !ok || len(rec.Parents) != 0 || !strings.Contains(rec.Mutations[0], "island_0") {
+		t.Fatalf("unexpected migrant lineage %+v", rec)
+	}
+	// Island 0 receives from island 2 (ring wraps around).
+	if got := ir.Islands[0].Population.Genomes[0].Fitness; got != 211 {
+		t.Fatalf("expected wrap-around migrant 211, got %v", got)
+	}
+}
+
+func TestMigrantAncestryStaysOnIsland(t *testing.T) {
+	runners := islandTestRunners(t, 2)
+	src := runners[0].Population
+	for i := range src.Genomes {
+		src.Genomes[i].Fitness = float64(100 + i)
+	}
+	emigrant := src.Genomes[len(src.Genomes)-1]
+	cfg := DefaultIslandConfig()
+	cfg.Migrants = 1
+	ir, err := NewIslandRunner(runners, cfg)
+	if err != nil {
+		t.Fatalf("NewIslandRunner error: %v", err)
+	}
+	if err := ir.Migrate(); err != nil {
+		t.Fatalf("Migrate error: %v", err)
+	}
+
+	// Both islands number their genomes from 1, so the emigrant's id is an
+	// unrelated genome on island 1 and must not appear in the ancestry.
+	dst := ir.Islands[1].Population
+	if _, ok := dst.Lineage.Get(emigrant.ID); !ok {
+		t.Fatalf("expected island 1 to have its own genome %d", emigrant.ID)
+	}
+	var migrant Genome
+	for _, g := range dst.Genomes {
+		if g.Fitness == emigrant.Fitness {
+			migrant = g
+		}
+	}
+	ancestry, err := dst.Lineage.Ancestry(migrant.ID)
+	if err != nil {
+		t.Fatalf("Ancestry error: %v", err)
+	}
+	if len(ancestry) != 1 || ancestry[0].ID != migrant.ID {
+		t.Fatalf("expected the migrant to be a lineage root, got %+v", ancestry)
+	}
+	want := fmt.Sprintf("migrate_from_island_0_genome_%d", emigrant.ID)
+	if len(ancestry[0].Mutations) != 1 || ancestry[0].Mutations[0] != want {
+		t.Fatalf("expected mutation %q, got %v", want, ancestry[0].Mutations)
+	}
+}
+
+func TestIslandMigrationWithoutLineage(t *testing.T) {
+	runners := islandTestRunners(t, 2)
+	for _, r := range runners {
+		r.Population.Lineage = nil
+	}
+	cfg := DefaultIslandConfig()
+	cfg.Migrants = 1
+	ir, err := NewIslandRunner(runners, cfg)
+	if err != nil {
+		t.Fatalf("NewIslandRunner error: %v", err)
+	}
+	if err := ir.Migrate(); err != nil {
+		t.Fatalf("Migrate error: %v", err)
+	}
+	for i, r := range ir.Islands {
+		if r.Population.Lineage == nil || r.Population.Lineage.Len() != 1 {
+			t.Fatalf("expected island %d to log its migrant", i)
+		}
+	}
+}
+
+func TestIslandRunnerDeterministic(t *testing.T) {
+	run := func(topology MigrationTopology) (IslandGenome, []GenerationStats, GenerationStats, []IslandGenome) {
+		cfg := DefaultIslandConfig()
+		cfg.Topology = topology
+		cfg.Interval = 2
+		ir, err := NewIslandRunner(islandTestRunners(t, 3), cfg)
+		if err != nil {
+			t.Fatalf("NewIslandRunner error: %v", err)
+		}
+		best, gen, err := ir.Run(6, 1e9)
+		if err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+		if gen != 5 {
+			t.Fatalf("expected to run all generations, stopped at %d", gen)
+		}
+		return best, ir.Stats(), ir.MergedStats(), ir.Merged()
+	}
+
+	for _, topology := range []MigrationTopology{TopologyRing, TopologyFull} {
+		bestA, statsA, mergedA, galleryA := run(topology)
+		bestB, _, _, _ := run(topology)
+		if bestA.Island != bestB.Island || bestA.Genome.Fitness != bestB.Genome.Fitness {
+			t.Fatalf("runs differ: %+v vs %+v", bestA, bestB)
+		}
+		if len(statsA) != 3 || mergedA.PopulationSize != 36 {
+			t.Fatalf("unexpected stats %+v / %+v", statsA, mergedA)
+		}
+		if len(galleryA) != 36 || galleryA[0].Genome.Fitness < galleryA[35].Genome.Fitness {
+			t.Fatalf("merged gallery not sorted by fitness")
+		}
+		if galleryA[0].Genome.Fitness != mergedA.MaxFitness {
+			t.Fatalf("merged stats and gallery disagree")
+		}
+	}
+}
+
+func TestNewIslandRunnerErrors(t *testing.T) {
+	if _, err := NewIslandRunner(nil, DefaultIslandConfig()); err == nil {
+		t.Fatalf("expected error for no islands")
+	}
+	cfg := DefaultIslandConfig()
+	cfg.Topology = 9
+	if _, err := NewIslandRunner(islandTestRunners(t, 2), cfg); err == nil {
+		t.Fatalf("expected error for unknown topology")
+	}
+}
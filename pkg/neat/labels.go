@@ -0,0 +1,62 @@
+package neat
+
+import (
+	"fmt"
+	"sort"
+)
+
+// InputLabels returns the Label of each input node in plan input order
+// (ascending node ID). Unlabelled inputs give "".
+func (g Genome) InputLabels() []string {
+	return g.labels(NodeInput)
+}
+
+// SetInputLabels labels the input nodes in plan input order. It needs one
+// label per input node.
+func (g *Genome) SetInputLabels(labels []string) error {
+	return g.setLabels(NodeInput, labels)
+}
+
+// OutputLabels returns the Label of each output node in plan output order.
+func (g Genome) OutputLabels() []string {
+	return g.labels(NodeOutput)
+}
+
+// SetOutputLabels labels the output nodes in plan output order. It needs one
+// label per output node.
+func (g *Genome) SetOutputLabels(labels []string) error {
+	return g.setLabels(NodeOutput, labels)
+}
+
+func (g Genome) labels(kind NodeKind) []string {
+	nodes := g.nodesOfKind(kind)
+	labels := make([]string, len(nodes))
+	for i, idx := range nodes {
+		labels[i] = g.Nodes[idx].Label
+	}
+	return labels
+}
+
+func (g *Genome) setLabels(kind NodeKind, labels []string) error {
+	nodes := g.nodesOfKind(kind)
+	if len(labels) != len(nodes) {
+		return fmt.Errorf("got %d labels for %d %s nodes", len(labels), len(nodes), kind)
+	}
+	for i, idx := range nodes {
+		g.Nodes[idx].Label = labels[i]
+	}
+	return nil
+}
+
+// nodesOfKind returns the indices into g.Nodes of the nodes of kind, sorted
+// by node ID.
+func (g Genome) nodesOfKind(kind NodeKind) []int {
+	var idx []int
+	for i, n := range g.Nodes {
+		if n.Kind == kind {
+			idx = append(idx, i)
+		}
+	}
+	sort.Slice(idx, func(a, b int) bool { return g.Nodes[idx[a]].ID < g.Nodes[idx[b]].ID })
+	return idx
+}
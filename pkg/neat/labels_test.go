@@ -0,0 +1,57 @@
+package neat
+
+import "testing"
+
+func TestInputLabelsFollowPlanOrder(t *testing.T) {
+	g := Genome{
+		Nodes: []NodeGene{
+			{ID: 3, Kind: NodeOutput, Activation: ActivationSigmoid},
+			{ID: 2, Kind: NodeInput, Activation: ActivationLinear},
+			{ID: 1, Kind: NodeInput, Activation: ActivationLinear},
+		},
+		Connections: []ConnectionGene{
+			{Innovation: 1, In: 1, Out: 3, Weight: 1, Enabled: true},
+			{Innovation: 2, In: 2, Out: 3, Weight: 1, Enabled: true},
+		},
+	}
+	if err := g.SetInputLabels([]string{"x"}); err == nil {
+		t.Fatalf("expected error for wrong label count")
+	}
+	if err := g.SetInputLabels([]string{"x", "y"}); err != nil {
+		t.Fatalf("SetInputLabels error: %v", err)
+	}
+	if g.Nodes[2].Label != "x" || g.Nodes[1].Label != "y" {
+		t.Fatalf("labels not assigned by node id: %+v", g.Nodes)
+	}
+	labels := g.InputLabels()
+	if len(labels) != 2 || labels[0] != "x" || labels[1] != "y" {
+		t.Fatalf("unexpected labels %v", labels)
+	}
+
+	plan, err := BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	if len(plan.InputLabels) != 2 || plan.InputLabels[0] != "x" || plan.InputLabels[1] != "y" {
+		t.Fatalf("unexpected plan labels %v", plan.InputLabels)
+	}
+
+	if err := g.SetOutputLabels([]string{"value"}); err != nil {
+		t.Fatalf("SetOutputLabels error: %v", err)
+	}
+	if out := g.OutputLabels(); len(out) != 1 || out[0] != "value" {
+		t.Fatalf("unexpected output labels %v", out)
+	}
+	plan, err = BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	if len(plan.OutputLabels) != 1 || plan.OutputLabels[0] != "value" {
+		t.Fatalf("unexpected plan output labels %v", plan.OutputLabels)
+	}
+
+	clone := cloneGenome(g)
+	if clone.InputLabels()[1] != "y" {
+		t.Fatalf("expected clone to keep labels")
+	}
+}
@@ -0,0 +1,226 @@
+package neat
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// LineageRecord describes how a genome came into existence.
+type LineageRecord struct {
+	ID         GenomeID   `json:"id"`
+	Parents    []GenomeID `json:"parents,omitempty"`
+	Generation int        `json:"generation"`
+	Mutations  []string   `json:"mutations,omitempty"`
+}
+
+// LineageLog is an append-only record of genome births. Records can be
+// mirrored to a sink as JSON Lines while evolution runs.
+type LineageLog struct {
+	records []LineageRecord
+	index   map[GenomeID]int
+	sink    io.Writer
+}
+
+// NewLineageLog creates an empty log. If sink is non-nil every appended
+// record is also written to it as a JSON line.
+func NewLineageLog(sink io.Writer) *LineageLog {
+	return &LineageLog{
+		index: make(map[GenomeID]int),
+		sink:  sink,
+	}
+}
+
+// ReadLineage reads a JSON Lines lineage stream into a new log.
+func ReadLineage(r io.Reader) (*LineageLog, error) {
+	log := NewLineageLog(nil)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var rec LineageRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return nil, fmt.Errorf("lineage line %d: %w", line, err)
+		}
+		if err := log.Append(rec); err != nil {
+			return nil, fmt.Errorf("lineage line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return log, nil
+}
+
+// SetSink mirrors the log to w, first writing every record already stored.
+func (l *LineageLog) SetSink(w io.Writer) error {
+	l.sink = w
+	if w == nil {
+		return nil
+	}
+	for _, rec := range l.records {
+		if err := writeLineageLine(w, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Append adds a record. Ids must be non-zero and unique.
+func (l *LineageLog) Append(rec LineageRecord) error {
+	if rec.ID == 0 {
+		return fmt.Errorf("lineage record has no id")
+	}
+	if _, exists := l.index[rec.ID]; exists {
+		return fmt.Errorf("duplicate lineage record for genome %d", rec.ID)
+	}
+	rec.Parents = append([]GenomeID(nil), rec.Parents...)
+	rec.Mutations = append([]string(nil), rec.Mutations...)
+	l.index[rec.ID] = len(l.records)
+	l.records = append(l.records, rec)
+	if l.sink != nil {
+		return writeLineageLine(l.sink, rec)
+	}
+	return nil
+}
+
+// appendAll adds recs after checking that every id is non-zero, unique and
+// new, so a rejected batch leaves the log unchanged.
+func (l *LineageLog) appendAll(recs []LineageRecord) error {
+	seen := make(map[GenomeID]struct{}, len(recs))
+	for _, rec := range recs {
+		if rec.ID == 0 {
+			return fmt.Errorf("lineage record has no id")
+		}
+		if _, exists := l.index[rec.ID]; exists {
+			return fmt.Errorf("duplicate lineage record for genome %d", rec.ID)
+		}
+		if _, exists := seen[rec.ID]; exists {
+			return fmt.Errorf("duplicate lineage record for genome %d", rec.ID)
+		}
+		seen[rec.ID] = struct{}{}
+	}
+	for _, rec := range recs {
+		if err := l.Append(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of records.
+func (l *LineageLog) Len() int {
+	return len(l.records)
+}
+
+// Get returns the record for id.
+func (l *LineageLog) Get(id GenomeID) (LineageRecord, bool) {
+	idx, ok := l.index[id]
+	if !ok {
+		return LineageRecord{}, false
+	}
+	return l.records[idx], true
+}
+
+// Records returns a copy of all records in append order.
+func (l *LineageLog) Records() []LineageRecord {
+	out := make([]LineageRecord, len(l.records))
+	copy(out, l.records)
+	return out
+}
+
+// Ancestry returns the record for id followed by every known ancestor, each
+// listed once, in breadth-first order.
+func (l *LineageLog) Ancestry(id GenomeID) ([]LineageRecord, error) {
+	if _, ok := l.index[id]; !ok {
+		return nil, fmt.Errorf("genome %d not in lineage", id)
+	}
+	seen := map[GenomeID]struct{}{id: {}}
+	queue := []GenomeID{id}
+	out := make([]LineageRecord, 0)
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		rec, ok := l.Get(cur)
+		if !ok {
+			continue
+		}
+		out = append(out, rec)
+		for _, p := range rec.Parents {
+			if _, done := seen[p]; done {
+				continue
+			}
+			seen[p] = struct{}{}
+			queue = append(queue, p)
+		}
+	}
+	return out, nil
+}
+
+// WriteAncestryJSON writes the ancestry of id as an indented JSON array.
+func (l *LineageLog) WriteAncestryJSON(w io.Writer, id GenomeID) error {
+	records, err := l.Ancestry(id)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// Newick returns the ancestry of id as a Newick tree rooted at id, with
+// parents as children and branch lengths in generations. Crossover makes
+// ancestry a DAG, so shared ancestors are repeated; maxDepth bounds the
+// expansion (<= 0 means unbounded).
+func (l *LineageLog) Newick(id GenomeID, maxDepth int) (string, error) {
+	rec, ok := l.Get(id)
+	if !ok {
+		return "", fmt.Errorf("genome %d not in lineage", id)
+	}
+	var b strings.Builder
+	l.writeNewick(&b, rec, 0, maxDepth)
+	b.WriteString(";")
+	return b.String(), nil
+}
+
+func (l *LineageLog) writeNewick(b *strings.Builder, rec LineageRecord, depth, maxDepth int) {
+	var parents []LineageRecord
+	if maxDepth <= 0 || depth < maxDepth {
+		for _, p := range rec.Parents {
+			if pr, ok := l.Get(p); ok {
+				parents = append(parents, pr)
+			}
+		}
+		sort.Slice(parents, func(i, j int) bool { return parents[i].ID < parents[j].ID })
+	}
+	if len(parents) > 0 {
+		b.WriteString("(")
+		for i, p := range parents {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			l.writeNewick(b, p, depth+1, maxDepth)
+			fmt.Fprintf(b, ":%d", rec.Generation-p.Generation)
+		}
+		b.WriteString(")")
+	}
+	fmt.Fprintf(b, "g%d", rec.ID)
+}
+
+func writeLineageLine(w io.Writer, rec LineageRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,178 @@
+package neat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func evolvedLineagePopulation(t *testing.T, generations int) *Population {
+	t.Helper()
+	rng := NewRand(21)
+	tracker, err := NewInnovationTracker(nil)
+	if err != nil {
+		t.Fatalf("NewInnovationTracker error: %v", err)
+	}
+	genomes := make([]Genome, 0, 12)
+	for i := 0; i < 12; i++ {
+		g, err := NewMinimalGenome(2, 1, ActivationSigmoid, rng, tracker, 1.0)
+		if err != nil {
+			t.Fatalf("NewMinimalGenome error: %v", err)
+		}
+		genomes = append(genomes, g)
+	}
+	pop, err := NewPopulation(rng, DefaultPopulationConfig(), genomes)
+	if err != nil {
+		t.Fatalf("NewPopulation error: %v", err)
+	}
+	for gen := 0; gen < generations; gen++ {
+		for i := range pop.Genomes {
+			pop.Genomes[i].Fitness = rng.Float64()
+		}
+		if err := pop.NextGeneration(DefaultMutationConfig(), DefaultReproductionConfig()); err != nil {
+			t.Fatalf("NextGeneration error: %v", err)
+		}
+	}
+	return pop
+}
+
+func TestPopulationAssignsLineage(t *testing.T) {
+	pop := evolvedLineagePopulation(t, 4)
+	if pop.Generation != 4 {
+		t.Fatalf("expected generation 4, got %d", pop.Generation)
+	}
+
+	seen := make(map[GenomeID]bool)
+	for _, g := range pop.Genomes {
+		if g.ID == 0 {
+			t.Fatalf("genome without id")
+		}
+		if seen[g.ID] {
+			t.Fatalf("duplicate genome id %d", g.ID)
+		}
+		seen[g.ID] = true
+		rec, ok := pop.Lineage.Get(g.ID)
+		if !ok {
+			t.Fatalf("genome %d missing from lineage", g.ID)
+		}
+		if rec.Generation != g.Birth {
+			t.Fatalf("genome %d birth %d does not match record %d", g.ID, g.Birth, rec.Generation)
+		}
+
+		ancestry, err := pop.Lineage.Ancestry(g.ID)
+		if err != nil {
+			t.Fatalf("Ancestry error: %v", err)
+		}
+		founder := false
+		for _, a := range ancestry {
+			if a.Generation == 0 && len(a.Parents) == 0 {
+				founder = true
+			}
+		}
+		if !founder {
+			t.Fatalf("ancestry of %d does not reach a founder", g.ID)
+		}
+	}
+}
+
+func TestMutateLoggedReportsOperators(t *testing.T) {
+	rng := NewRand(4)
+	g := Genome{
+		Nodes: []NodeGene{
+			{ID: 1, Kind: NodeInput, Activation: ActivationLinear},
+			{ID: 2, Kind: NodeOutput, Activation: ActivationLinear},
+		},
+		Connections: []ConnectionGene{{Innovation: 1, In: 1, Out: 2, Weight: 1, Enabled: true}},
+	}
+	tracker, err := NewInnovationTracker([]Genome{g})
+	if err != nil {
+		t.Fatalf("NewInnovationTracker error: %v", err)
+	}
+	mcfg := MutationConfig{AddNodeProb: 1, WeightMutateProb: 1, AllowedActivations: []ActivationType{ActivationSin}}
+	applied, err := mcfg.MutateLogged(rng, &g, tracker)
+	if err != nil {
+		t.Fatalf("MutateLogged error: %v", err)
+	}
+	if strings.Join(applied, ",") != MutationAddNode+","+MutationWeights {
+		t.Fatalf("unexpected mutations %v", applied)
+	}
+}
+
+func TestLineageNewickAndRoundTrip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := NewLineageLog(buf)
+	records := []LineageRecord{
+		{ID: 1, Generation: 0},
+		{ID: 2, Generation: 0},
+		{ID: 3, Parents: []GenomeID{1, 2}, Generation: 1, Mutations: []string{MutationWeights}},
+		{ID: 4, Parents: []GenomeID{3}, Generation: 3},
+	}
+	for _, rec := range records {
+		if err := log.Append(rec); err != nil {
+			t.Fatalf("Append error: %v", err)
+		}
+	}
+	if err := log.Append(LineageRecord{ID: 2}); err == nil {
+		t.Fatalf("expected duplicate id error")
+	}
+
+	newick, err := log.Newick(4, 0)
+	if err != nil {
+		t.Fatalf("Newick error: %v", err)
+	}
+	if newick != "((g1:1,g2:1)g3:2)g4;" {
+		t.Fatalf("unexpected newick %q", newick)
+	}
+	shallow, err := log.Newick(4, 1)
+	if err != nil {
+		t.Fatalf("Newick error: %v", err)
+	}
+	if shallow != "(g3:2)g4;" {
+		t.Fatalf("unexpected depth-limited newick %q", shallow)
+	}
+
+	loaded, err := ReadLineage(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadLineage error: %v", err)
+	}
+	if loaded.Len() != len(records) {
+		t.Fatalf("expected %d records, got %d", len(records), loaded.Len())
+	}
+	rec, _ := loaded.Get(3)
+	if len(rec.Parents) != 2 || rec.Mutations[0] != MutationWeights {
+		t.Fatalf("unexpected round-tripped record %+v", rec)
+	}
+
+	out := &bytes.Buffer{}
+	if err := log.WriteAncestryJSON(out, 4); err != nil {
+		t.Fatalf("WriteAncestryJSON error: %v", err)
+	}
+	if !strings.Contains(out.String(), "\"mutations\"") {
+		t.Fatalf("expected mutations in ancestry JSON: %s", out.String())
+	}
+}
+
+func TestLineageErrorKeepsGeneration(t *testing.T) {
+	pop := evolvedLineagePopulation(t, 1)
+	genomes := append([]Genome(nil), pop.Genomes...)
+	// Claim the id of the third birth, so the first two would be orphaned
+	// if they were logged before the clash was found.
+	if err := pop.Lineage.Append(LineageRecord{ID: pop.nextID + 3}); err != nil {
+		t.Fatalf("Append error: %v", err)
+	}
+	records := pop.Lineage.Len()
+	if err := pop.NextGeneration(DefaultMutationConfig(), DefaultReproductionConfig()); err == nil {
+		t.Fatalf("expected duplicate lineage error")
+	}
+	if pop.Lineage.Len() != records {
+		t.Fatalf("failed commit left %d orphan lineage records", pop.Lineage.Len()-records)
+	}
+	if pop.Generation != 1 || len(pop.Genomes) != len(genomes) {
+		t.Fatalf("expected generation 1 to stay installed, got %d", pop.Generation)
+	}
+	for i := range genomes {
+		if pop.Genomes[i].ID != genomes[i].ID {
+			t.Fatalf("genome %d changed after a failed commit", i)
+		}
+	}
+}
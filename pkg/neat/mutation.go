@@ -11,23 +11,33 @@ var (
 	ErrNoEnabledConnections   = errors.New("no enabled connections to split")
 )
 
+// Mutation names recorded in lineage logs.
+const (
+	MutationAddConnection = "add_connection"
+	MutationAddNode       = "add_node"
+	MutationWeights       = "weights"
+	MutationBiases        = "biases"
+	MutationToggle        = "toggle_connection"
+	MutationActivation    = "activation"
+)
+
 // MutationConfig controls mutation probabilities and ranges.
 type MutationConfig struct {
-	AddConnectionProb    float64
-	AddNodeProb          float64
-	WeightMutateProb     float64
-	WeightPerturbProb    float64
-	WeightPerturbScale   float64
-	WeightResetScale     float64
-	WeightInitRange      float64
-	BiasMutateProb       float64
-	BiasPerturbProb      float64
-	BiasPerturbScale     float64
-	BiasResetScale       float64
-	ToggleEnableProb     float64
-	ActivationMutateProb float64
-	AllowedActivations   []ActivationType
-	MaxAttempts          int
+	AddConnectionProb    float64          `json:"add_connection_prob"`
+	AddNodeProb          float64          `json:"add_node_prob"`
+	WeightMutateProb     float64          `json:"weight_mutate_prob"`
+	WeightPerturbProb    float64          `json:"weight_perturb_prob"`
+	WeightPerturbScale   float64          `json:"weight_perturb_scale"`
+	WeightResetScale     float64          `json:"weight_reset_scale"`
+	WeightInitRange      float64          `json:"weight_init_range"`
+	BiasMutateProb       float64          `json:"bias_mutate_prob"`
+	BiasPerturbProb      float64          `json:"bias_perturb_prob"`
+	BiasPerturbScale     float64          `json:"bias_perturb_scale"`
+	BiasResetScale       float64          `json:"bias_reset_scale"`
+	ToggleEnableProb     float64          `json:"toggle_enable_prob"`
+	ActivationMutateProb float64          `json:"activation_mutate_prob"`
+	AllowedActivations   []ActivationType `json:"allowed_activations"`
+	MaxAttempts          int              `json:"max_attempts"`
 }
 
 // DefaultMutationConfig returns a conservative baseline.
@@ -61,32 +71,56 @@ func DefaultMutationConfig() MutationConfig {
 
 // Mutate applies NEAT mutations to the genome.
 func (m MutationConfig) Mutate(rng RNG, g *Genome, tracker *InnovationTracker) error {
+	_, err := m.MutateLogged(rng, g, tracker)
+	return err
+}
+
+// MutateLogged applies NEAT mutations and returns the names of the mutation
+// operators that changed the genome, in the order they were applied.
+func (m MutationConfig) MutateLogged(rng RNG, g *Genome, tracker *InnovationTracker) ([]string, error) {
 	if g == nil {
-		return fmt.Errorf("genome is nil")
+		return nil, fmt.Errorf("genome is nil")
 	}
 	if rng == nil {
-		return fmt.Errorf("rng is nil")
+		return nil, fmt.Errorf("rng is nil")
 	}
 	if tracker == nil {
-		return fmt.Errorf("innovation tracker is nil")
+		return nil, fmt.Errorf("innovation tracker is nil")
 	}
+	var applied []string
 	if randBool(rng, m.AddConnectionProb) {
-		if err := MutateAddConnection(rng, g, tracker, m.WeightInitRange, m.MaxAttempts); err != nil && !errors.Is(err, ErrNoConnectionCandidates) {
-			return err
+		err := MutateAddConnection(rng, g, tracker, m.WeightInitRange, m.MaxAttempts)
+		if err != nil && !errors.Is(err, ErrNoConnectionCandidates) {
+			return nil, err
+		}
+		if err == nil {
+			applied = append(applied, MutationAddConnection)
 		}
 	}
 	if randBool(rng, m.AddNodeProb) {
-		if err := MutateAddNode(rng, g, tracker, m.AllowedActivations); err != nil && !errors.Is(err, ErrNoEnabledConnections) {
-			return err
+		err := MutateAddNode(rng, g, tracker, m.AllowedActivations)
+		if err != nil && !errors.Is(err, ErrNoEnabledConnections) {
+			return nil, err
+		}
+		if err == nil {
+			applied = append(applied, MutationAddNode)
 		}
 	}
 
-	MutateWeights(rng, g, m.WeightMutateProb, m.WeightPerturbProb, m.WeightPerturbScale, m.WeightResetScale)
-	MutateBiases(rng, g, m.BiasMutateProb, m.BiasPerturbProb, m.BiasPerturbScale, m.BiasResetScale)
-	MutateToggleConnections(rng, g, m.ToggleEnableProb)
-	MutateActivations(rng, g, m.ActivationMutateProb, m.AllowedActivations)
+	if mutateWeights(rng, g, m.WeightMutateProb, m.WeightPerturbProb, m.WeightPerturbScale, m.WeightResetScale) > 0 {
+		applied = append(applied, MutationWeights)
+	}
+	if mutateBiases(rng, g, m.BiasMutateProb, m.BiasPerturbProb, m.BiasPerturbScale, m.BiasResetScale) > 0 {
+		applied = append(applied, MutationBiases)
+	}
+	if mutateToggleConnections(rng, g, m.ToggleEnableProb) > 0 {
+		applied = append(applied, MutationToggle)
+	}
+	if mutateActivations(rng, g, m.ActivationMutateProb, m.AllowedActivations) > 0 {
+		applied = append(applied, MutationActivation)
+	}
 
-	return nil
+	return applied, nil
 }
 
 // MutateAddConnection adds a new acyclic connection between existing nodes.
@@ -210,9 +244,29 @@ func MutateAddNode(rng RNG, g *Genome, tracker *InnovationTracker, activations [
 
 // MutateWeights mutates connection weights.
 func MutateWeights(rng RNG, g *Genome, mutateProb, perturbProb, perturbScale, resetScale float64) {
+	mutateWeights(rng, g, mutateProb, perturbProb, perturbScale, resetScale)
+}
+
+// MutateBiases mutates node biases (non-input nodes only).
+func MutateBiases(rng RNG, g *Genome, mutateProb, perturbProb, perturbScale, resetScale float64) {
+	mutateBiases(rng, g, mutateProb, perturbProb, perturbScale, resetScale)
+}
+
+// MutateToggleConnections flips the enabled flag for connections.
+func MutateToggleConnections(rng RNG, g *Genome, toggleProb float64) {
+	mutateToggleConnections(rng, g, toggleProb)
+}
+
+// MutateActivations changes activation functions on non-input nodes.
+func MutateActivations(rng RNG, g *Genome, mutateProb float64, activations []ActivationType) {
+	mutateActivations(rng, g, mutateProb, activations)
+}
+
+func mutateWeights(rng RNG, g *Genome, mutateProb, perturbProb, perturbScale, resetScale float64) int {
 	if rng == nil {
-		return
+		return 0
 	}
+	changed := 0
 	for i := range g.Connections {
 		if !randBool(rng, mutateProb) {
 			continue
@@ -222,14 +276,16 @@ func MutateWeights(rng RNG, g *Genome, mutateProb, perturbProb, perturbScale, re
 		} else {
 			g.Connections[i].Weight = randRange(rng, -resetScale, resetScale)
 		}
+		changed++
 	}
+	return changed
 }
 
-// MutateBiases mutates node biases (non-input nodes only).
-func MutateBiases(rng RNG, g *Genome, mutateProb, perturbProb, perturbScale, resetScale float64) {
+func mutateBiases(rng RNG, g *Genome, mutateProb, perturbProb, perturbScale, resetScale float64) int {
 	if rng == nil {
-		return
+		return 0
 	}
+	changed := 0
 	for i := range g.Nodes {
 		if g.Nodes[i].Kind == NodeInput {
 			continue
@@ -242,45 +298,53 @@ func MutateBiases(rng RNG, g *Genome, mutateProb, perturbProb, perturbScale, res
 		} else {
 			g.Nodes[i].Bias = randRange(rng, -resetScale, resetScale)
 		}
+		changed++
 	}
+	return changed
 }
 
-// MutateToggleConnections flips the enabled flag for connections.
-func MutateToggleConnections(rng RNG, g *Genome, toggleProb float64) {
+func mutateToggleConnections(rng RNG, g *Genome, toggleProb float64) int {
 	if rng == nil {
-		return
+		return 0
 	}
+	changed := 0
 	nodeMap := nodeGeneMap(g.Nodes)
 	for i := range g.Connections {
 		if randBool(rng, toggleProb) {
 			if g.Connections[i].Enabled {
 				g.Connections[i].Enabled = false
+				changed++
 				continue
 			}
 			g.Connections[i].Enabled = true
 			if _, err := topoOrder(nodeMap, g.Connections); err != nil {
 				g.Connections[i].Enabled = false
+				continue
 			}
+			changed++
 		}
 	}
+	return changed
 }
 
-// MutateActivations changes activation functions on non-input nodes.
-func MutateActivations(rng RNG, g *Genome, mutateProb float64, activations []ActivationType) {
+func mutateActivations(rng RNG, g *Genome, mutateProb float64, activations []ActivationType) int {
 	if rng == nil {
-		return
+		return 0
 	}
 	if len(activations) == 0 {
-		return
+		return 0
 	}
+	changed := 0
 	for i := range g.Nodes {
 		if g.Nodes[i].Kind == NodeInput {
 			continue
 		}
 		if randBool(rng, mutateProb) {
 			g.Nodes[i].Activation = activations[rng.Intn(len(activations))]
+			changed++
 		}
 	}
+	return changed
 }
 
 func connectionExists(g *Genome, in, out NodeID) bool {
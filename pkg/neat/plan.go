@@ -22,11 +22,15 @@ type CompiledNode struct {
 
 // Plan is a compiled, acyclic execution plan.
 type Plan struct {
-	Inputs     []NodeID
-	Outputs    []NodeID
-	nodes      []CompiledNode
-	valueIndex map[NodeID]int
-	outIndex   []int
+	Inputs []NodeID
+	// InputLabels holds each input node's Label, in Inputs order.
+	InputLabels []string
+	Outputs     []NodeID
+	// OutputLabels holds each output node's Label, in Outputs order.
+	OutputLabels []string
+	nodes        []CompiledNode
+	valueIndex   map[NodeID]int
+	outIndex     []int
 }
 
 // Executor reuses buffers for repeated evaluation.
@@ -149,12 +153,23 @@ func BuildAcyclicPlan(g Genome, inputs []NodeID, outputs []NodeID) (*Plan, error
 		outIndex = append(outIndex, idx)
 	}
 
+	inputLabels := make([]string, len(inputs))
+	for i, id := range inputs {
+		inputLabels[i] = nodeByID[id].Label
+	}
+	outputLabels := make([]string, len(outputs))
+	for i, id := range outputs {
+		outputLabels[i] = nodeByID[id].Label
+	}
+
 	return &Plan{
-		Inputs:     inputs,
-		Outputs:    outputs,
-		nodes:      compiledNodes,
-		valueIndex: valueIndex,
-		outIndex:   outIndex,
+		Inputs:       inputs,
+		InputLabels:  inputLabels,
+		Outputs:      outputs,
+		OutputLabels: outputLabels,
+		nodes:        compiledNodes,
+		valueIndex:   valueIndex,
+		outIndex:     outIndex,
 	}, nil
 }
 
@@ -0,0 +1,111 @@
+package neat
+
+import "fmt"
+
+type compiledNode32 struct {
+	valueIndex int32
+	bias       float32
+	activation ActivationType
+	connStart  int32
+	connEnd    int32
+}
+
+// Plan32 is a single-precision copy of a Plan. Connections are stored in flat
+// arrays so evaluation touches half the memory of the float64 plan.
+type Plan32 struct {
+	Inputs     []NodeID
+	Outputs    []NodeID
+	nodes      []compiledNode32
+	srcs       []int32
+	weights    []float32
+	valueCount int
+	outIndex   []int32
+}
+
+// Executor32 reuses float32 buffers for repeated evaluation.
+type Executor32 struct {
+	plan   *Plan32
+	values []float32
+	output []float32
+}
+
+// BuildAcyclicPlan32 compiles a genome into a single-precision execution plan.
+func BuildAcyclicPlan32(g Genome, inputs []NodeID, outputs []NodeID) (*Plan32, error) {
+	plan, err := BuildAcyclicPlan(g, inputs, outputs)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Float32(), nil
+}
+
+// Float32 converts the plan to single precision. Weights and biases are
+// rounded to the nearest float32.
+func (p *Plan) Float32() *Plan32 {
+	connCount := 0
+	for _, n := range p.nodes {
+		connCount += len(n.Incoming)
+	}
+
+	out := &Plan32{
+		Inputs:     p.Inputs,
+		Outputs:    p.Outputs,
+		nodes:      make([]compiledNode32, 0, len(p.nodes)),
+		srcs:       make([]int32, 0, connCount),
+		weights:    make([]float32, 0, connCount),
+		valueCount: len(p.valueIndex),
+		outIndex:   make([]int32, len(p.outIndex)),
+	}
+	for _, n := range p.nodes {
+		start := int32(len(out.srcs))
+		for _, c := range n.Incoming {
+			out.srcs = append(out.srcs, int32(c.Src))
+			out.weights = append(out.weights, float32(c.Weight))
+		}
+		out.nodes = append(out.nodes, compiledNode32{
+			valueIndex: int32(n.ValueIndex),
+			bias:       float32(n.Bias),
+			activation: n.Activation,
+			connStart:  start,
+			connEnd:    int32(len(out.srcs)),
+		})
+	}
+	for i, idx := range p.outIndex {
+		out.outIndex[i] = int32(idx)
+	}
+	return out
+}
+
+// NewExecutor creates a reusable evaluator for this plan.
+func (p *Plan32) NewExecutor() *Executor32 {
+	return &Executor32{
+		plan:   p,
+		values: make([]float32, p.valueCount),
+		output: make([]float32, len(p.outIndex)),
+	}
+}
+
+// Eval executes the plan with the provided inputs and returns output values.
+// The returned slice is reused between calls; copy it if you need to retain it.
+func (e *Executor32) Eval(inputs []float32) ([]float32, error) {
+	if e == nil || e.plan == nil {
+		return nil, fmt.Errorf("executor is nil")
+	}
+	p := e.plan
+	if len(inputs) != len(p.Inputs) {
+		return nil, fmt.Errorf("expected %d inputs, got %d", len(p.Inputs), len(inputs))
+	}
+
+	values := e.values
+	copy(values, inputs)
+	for _, n := range p.nodes {
+		sum := n.bias
+		for i := n.connStart; i < n.connEnd; i++ {
+			sum += values[p.srcs[i]] * p.weights[i]
+		}
+		values[n.valueIndex] = n.activation.Apply32(sum)
+	}
+	for i, idx := range p.outIndex {
+		e.output[i] = values[idx]
+	}
+	return e.output, nil
+}
@@ -0,0 +1,138 @@
+package neat
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPlan32MatchesPlan(t *testing.T) {
+	rng := NewRand(3)
+	tracker, err := NewInnovationTracker(nil)
+	if err != nil {
+		t.Fatalf("NewInnovationTracker error: %v", err)
+	}
+	g, err := NewMinimalGenome(4, 3, ActivationSigmoid, rng, tracker, 1.0)
+	if err != nil {
+		t.Fatalf("NewMinimalGenome error: %v", err)
+	}
+	tracker, err = NewInnovationTracker([]Genome{g})
+	if err != nil {
+		t.Fatalf("NewInnovationTracker error: %v", err)
+	}
+	mcfg := DefaultMutationConfig()
+	mcfg.AddNodeProb = 0.5
+	mcfg.AddConnectionProb = 0.5
+	for i := 0; i < 40; i++ {
+		if err := mcfg.Mutate(rng, &g, tracker); err != nil {
+			t.Fatalf("Mutate error: %v", err)
+		}
+	}
+
+	plan, err := BuildAcyclicPlan(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	plan32 := plan.Float32()
+	exec := plan.NewExecutor()
+	exec32 := plan32.NewExecutor()
+
+	in := make([]float64, 4)
+	in32 := make([]float32, 4)
+	for i := 0; i < 200; i++ {
+		for j := range in {
+			in32[j] = float32(rng.Float64()*2 - 1)
+			in[j] = float64(in32[j])
+		}
+		want, err := exec.Eval(in)
+		if err != nil {
+			t.Fatalf("Eval error: %v", err)
+		}
+		got, err := exec32.Eval(in32)
+		if err != nil {
+			t.Fatalf("Eval32 error: %v", err)
+		}
+		for k := range want {
+			if math.Abs(float64(got[k])-want[k]) > 1e-4 {
+				t.Fatalf("output %d: got %v want %v", k, got[k], want[k])
+			}
+		}
+	}
+}
+
+func TestPlan32InputCount(t *testing.T) {
+	g := Genome{
+		Nodes: []NodeGene{
+			{ID: 1, Kind: NodeInput, Activation: ActivationLinear},
+			{ID: 2, Kind: NodeOutput, Activation: ActivationLinear},
+		},
+		Connections: []ConnectionGene{
+			{Innovation: 1, In: 1, Out: 2, Weight: 2.0, Enabled: true},
+		},
+	}
+	plan, err := BuildAcyclicPlan32(g, nil, nil)
+	if err != nil {
+		t.Fatalf("BuildAcyclicPlan32 error: %v", err)
+	}
+	exec := plan.NewExecutor()
+	if _, err := exec.Eval([]float32{1, 2}); err == nil {
+		t.Fatalf("expected input count error")
+	}
+	out, err := exec.Eval([]float32{1.5})
+	if err != nil {
+		t.Fatalf("Eval error: %v", err)
+	}
+	if out[0] != 3 {
+		t.Fatalf("expected output 3, got %v", out[0])
+	}
+}
+
+func benchmarkGenome(b *testing.B) Genome {
+	rng := NewRand(9)
+	tracker, err := NewInnovationTracker(nil)
+	if err != nil {
+		b.Fatalf("NewInnovationTracker error: %v", err)
+	}
+	g, err := NewMinimalGenome(4, 3, ActivationSigmoid, rng, tracker, 1.0)
+	if err != nil {
+		b.Fatalf("NewMinimalGenome error: %v", err)
+	}
+	tracker, err = NewInnovationTracker([]Genome{g})
+	if err != nil {
+		b.Fatalf("NewInnovationTracker error: %v", err)
+	}
+	mcfg := DefaultMutationConfig()
+	mcfg.AddNodeProb = 0.5
+	mcfg.AddConnectionProb = 0.5
+	for i := 0; i < 60; i++ {
+		if err := mcfg.Mutate(rng, &g, tracker); err != nil {
+			b.Fatalf("Mutate error: %v", err)
+		}
+	}
+	return g
+}
+
+func BenchmarkExecutorEval(b *testing.B) {
+	plan, err := BuildAcyclicPlan(benchmarkGenome(b), nil, nil)
+	if err != nil {
+		b.Fatalf("BuildAcyclicPlan error: %v", err)
+	}
+	exec := plan.NewExecutor()
+	in := []float64{0.25, -0.5, 0.56, 1}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = exec.Eval(in)
+	}
+}
+
+func BenchmarkExecutor32Eval(b *testing.B) {
+	plan, err := BuildAcyclicPlan32(benchmarkGenome(b), nil, nil)
+	if err != nil {
+		b.Fatalf("BuildAcyclicPlan32 error: %v", err)
+	}
+	exec := plan.NewExecutor()
+	in := []float32{0.25, -0.5, 0.56, 1}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = exec.Eval(in)
+	}
+}
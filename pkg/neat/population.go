@@ -5,7 +5,7 @@ import "fmt"
 // PopulationConfig controls speciation behavior.
 type PopulationConfig struct {
 	DistanceConfig
-	CompatibilityThreshold float64
+	CompatibilityThreshold float64 `json:"compatibility_threshold"`
 }
 
 // DefaultPopulationConfig returns default speciation settings.
@@ -19,38 +19,100 @@ func DefaultPopulationConfig() PopulationConfig {
 
 // Species groups similar genomes.
 type Species struct {
-	ID            int
+	ID             int
 	Representative int
-	Members       []int
+	Members        []int
 }
 
 // Population tracks genomes and species.
 type Population struct {
-	Config  PopulationConfig
-	RNG     RNG
-	Tracker *InnovationTracker
-	Genomes []Genome
-	Species []Species
+	Config     PopulationConfig
+	RNG        RNG
+	Tracker    *InnovationTracker
+	Genomes    []Genome
+	Species    []Species
+	Generation int
+	Lineage    *LineageLog
+	nextID     GenomeID
 }
 
 // NewPopulation creates a population from genomes.
 func NewPopulation(rng RNG, cfg PopulationConfig, genomes []Genome) (*Population, error) {
+	return newPopulation(rng, cfg, genomes, nil, NewLineageLog(nil), 0)
+}
+
+// newPopulation creates a population that continues an existing lineage
+// log and genome id counter. Archived genomes, such as a hall of fame that
+// may be re-injected later, are not part of the population but their node
+// ids, innovations and genome ids are never handed out again.
+func newPopulation(rng RNG, cfg PopulationConfig, genomes, archived []Genome, lineage *LineageLog, nextID GenomeID) (*Population, error) {
 	if rng == nil {
 		return nil, fmt.Errorf("rng is nil")
 	}
 	if len(genomes) == 0 {
 		return nil, fmt.Errorf("no genomes provided")
 	}
-	tracker, err := NewInnovationTracker(genomes)
+	known := append(append([]Genome(nil), genomes...), archived...)
+	tracker, err := NewInnovationTracker(known)
 	if err != nil {
 		return nil, err
 	}
-	return &Population{
+	for _, g := range archived {
+		if g.ID > nextID {
+			nextID = g.ID
+		}
+	}
+	p := &Population{
 		Config:  cfg,
 		RNG:     rng,
 		Tracker: tracker,
 		Genomes: genomes,
-	}, nil
+		Lineage: lineage,
+		nextID:  nextID,
+	}
+	if err := p.registerFounders(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// registerFounders assigns ids to genomes that lack one and records every
+// genome in the lineage log. Loaded genomes keep their ids and parents. New
+// ids start above every id in the genomes and the lineage log, so ids of
+// genomes that have died are never reused.
+func (p *Population) registerFounders() error {
+	for _, g := range p.Genomes {
+		if g.ID >= p.nextID {
+			p.nextID = g.ID
+		}
+		if g.Birth > p.Generation {
+			p.Generation = g.Birth
+		}
+	}
+	for _, rec := range p.Lineage.records {
+		if rec.ID > p.nextID {
+			p.nextID = rec.ID
+		}
+	}
+	for i := range p.Genomes {
+		g := &p.Genomes[i]
+		if g.ID == 0 {
+			g.ID = p.newGenomeID()
+			g.Birth = p.Generation
+		}
+		if _, ok := p.Lineage.Get(g.ID); ok {
+			continue
+		}
+		if err := p.Lineage.Append(LineageRecord{ID: g.ID, Parents: g.Parents, Generation: g.Birth}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Population) newGenomeID() GenomeID {
+	p.nextID++
+	return p.nextID
 }
 
 // Speciate assigns genomes to species based on compatibility distance.
@@ -78,9 +140,9 @@ func (p *Population) Speciate() error {
 		}
 		if !placed {
 			p.Species = append(p.Species, Species{
-				ID:            speciesID,
+				ID:             speciesID,
 				Representative: idx,
-				Members:       []int{idx},
+				Members:        []int{idx},
 			})
 			speciesID++
 		}
@@ -1,6 +1,7 @@
 package neat
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 )
@@ -12,26 +13,105 @@ func SavePopulation(w io.Writer, genomes []Genome) error {
 	return enc.Encode(genomes)
 }
 
-// LoadPopulation reads genomes from JSON.
+// LoadPopulation reads genomes from JSON written by SavePopulation or
+// Population.Save.
 func LoadPopulation(r io.Reader) ([]Genome, error) {
-	dec := json.NewDecoder(r)
-	var genomes []Genome
-	if err := dec.Decode(&genomes); err != nil {
+	cp, err := LoadCheckpoint(r)
+	if err != nil {
 		return nil, err
 	}
-	return genomes, nil
+	return cp.Genomes, nil
 }
 
-// Save writes the population genomes as JSON.
+// Save writes the population as a checkpoint without a hall of fame, so the
+// genome id counter and lineage survive a reload.
 func (p *Population) Save(w io.Writer) error {
-	return SavePopulation(w, p.Genomes)
+	return SaveCheckpoint(w, p, nil)
 }
 
-// LoadPopulationWithConfig loads genomes and constructs a population.
+// LoadPopulationWithConfig loads a population written by Population.Save or
+// a genome array written by SavePopulation.
 func LoadPopulationWithConfig(r io.Reader, rng RNG, cfg PopulationConfig) (*Population, error) {
-	genomes, err := LoadPopulation(r)
+	cp, err := LoadCheckpoint(r)
+	if err != nil {
+		return nil, err
+	}
+	return cp.Population(rng, cfg)
+}
+
+// Checkpoint bundles a population's genomes with the run's hall of fame.
+type Checkpoint struct {
+	Generation int         `json:"generation"`
+	Genomes    []Genome    `json:"genomes"`
+	HallOfFame *HallOfFame `json:"hall_of_fame,omitempty"`
+	// NextID is the last genome id handed out. Ids of genomes that have
+	// died stay used after a resume.
+	NextID GenomeID `json:"next_id,omitempty"`
+	// Lineage holds every birth recorded so far. A restored population
+	// continues it, so attach a fresh sink with SetSink to mirror the full
+	// history rather than appending to the old one.
+	Lineage []LineageRecord `json:"lineage,omitempty"`
+}
+
+// SaveCheckpoint writes the population genomes and hall of fame as JSON.
+// hof may be nil.
+func SaveCheckpoint(w io.Writer, p *Population, hof *HallOfFame) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	cp := Checkpoint{
+		Generation: p.Generation,
+		Genomes:    p.Genomes,
+		HallOfFame: hof,
+		NextID:     p.nextID,
+	}
+	if p.Lineage != nil {
+		cp.Lineage = p.Lineage.Records()
+	}
+	return enc.Encode(cp)
+}
+
+// LoadCheckpoint reads a checkpoint written by SaveCheckpoint. A bare
+// genome array, as written by SavePopulation, loads as a checkpoint with
+// only genomes.
+func LoadCheckpoint(r io.Reader) (Checkpoint, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return Checkpoint{}, err
+	}
+	var cp Checkpoint
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		if err := json.Unmarshal(raw, &cp.Genomes); err != nil {
+			return Checkpoint{}, err
+		}
+		return cp, nil
+	}
+	if err := json.Unmarshal(raw, &cp); err != nil {
+		return Checkpoint{}, err
+	}
+	return cp, nil
+}
+
+// Population rebuilds a population from the checkpoint, continuing its
+// lineage log and genome id counter. The innovation tracker and id counter
+// also account for the hall of fame, so re-injected members never collide
+// with new genes or genomes.
+func (cp Checkpoint) Population(rng RNG, cfg PopulationConfig) (*Population, error) {
+	lineage := NewLineageLog(nil)
+	for _, rec := range cp.Lineage {
+		if err := lineage.Append(rec); err != nil {
+			return nil, err
+		}
+	}
+	var archived []Genome
+	if cp.HallOfFame != nil {
+		archived = cp.HallOfFame.Genomes()
+	}
+	pop, err := newPopulation(rng, cfg, cp.Genomes, archived, lineage, cp.NextID)
 	if err != nil {
 		return nil, err
 	}
-	return NewPopulation(rng, cfg, genomes)
+	if cp.Generation > pop.Generation {
+		pop.Generation = cp.Generation
+	}
+	return pop, nil
 }
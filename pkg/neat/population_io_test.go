@@ -30,3 +30,50 @@ func TestPopulationSaveLoad(t *testing.T) {
 		t.Fatalf("fitness mismatch")
 	}
 }
+
+func TestPopulationSaveKeepsIDsAndLineage(t *testing.T) {
+	pop := evolvedLineagePopulation(t, 3)
+	// Drop the newest genome, as rtNEAT replacement or migration would.
+	newest := 0
+	for i, g := range pop.Genomes {
+		if g.ID > pop.Genomes[newest].ID {
+			newest = i
+		}
+	}
+	pop.Genomes = append(pop.Genomes[:newest], pop.Genomes[newest+1:]...)
+	buf := &bytes.Buffer{}
+	if err := pop.Save(buf); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	restored, err := LoadPopulationWithConfig(bytes.NewReader(buf.Bytes()), NewRand(3), DefaultPopulationConfig())
+	if err != nil {
+		t.Fatalf("LoadPopulationWithConfig error: %v", err)
+	}
+	if restored.Lineage.Len() != pop.Lineage.Len() || restored.Generation != pop.Generation {
+		t.Fatalf("expected %d lineage records at generation %d, got %d at %d",
+			pop.Lineage.Len(), pop.Generation, restored.Lineage.Len(), restored.Generation)
+	}
+
+	// Ids of genomes that died before the save must not come back.
+	for i := range restored.Genomes {
+		restored.Genomes[i].Fitness = float64(i)
+	}
+	if err := restored.NextGeneration(DefaultMutationConfig(), DefaultReproductionConfig()); err != nil {
+		t.Fatalf("NextGeneration error: %v", err)
+	}
+	for _, g := range restored.Genomes {
+		if g.Birth == restored.Generation {
+			if _, ok := pop.Lineage.Get(g.ID); ok {
+				t.Fatalf("genome id %d was reused after the reload", g.ID)
+			}
+		}
+	}
+
+	genomes, err := LoadPopulation(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("LoadPopulation error: %v", err)
+	}
+	if len(genomes) != len(pop.Genomes) {
+		t.Fatalf("expected %d genomes, got %d", len(pop.Genomes), len(genomes))
+	}
+}
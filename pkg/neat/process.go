@@ -0,0 +1,404 @@
+package neat
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ProcessConfig configures an out-of-process fitness evaluator.
+//
+// Each worker is a long-lived child process that reads one JSON request per
+// line on stdin, {"id": 7, "genome": {...}}, and writes one JSON response per
+// line on stdout, {"id": 7, "fitness": 1.5} or {"id": 7, "error": "..."}.
+// Responses may arrive in any order. Requests are sent BatchSize at a time
+// before any response is awaited.
+type ProcessConfig struct {
+	Command string
+	Args    []string
+	// Env is appended to the parent environment.
+	Env []string
+	Dir string
+	// Workers is the number of child processes evaluating in parallel.
+	Workers   int
+	BatchSize int
+	// Timeout bounds the wait for each response. When it passes, the child is
+	// restarted and the oldest outstanding genome gets TimeoutFitness; the
+	// rest of the batch is resent. Zero disables timeouts.
+	Timeout        time.Duration
+	TimeoutFitness float64
+	// MaxRetries is how many times a batch is resent after the child exits
+	// unexpectedly before the evaluation fails.
+	MaxRetries int
+	// Stderr receives the children's stderr; nil discards it.
+	Stderr io.Writer
+}
+
+// DefaultProcessConfig returns a single-worker, unbatched configuration that
+// passes child stderr through. Command must still be set.
+func DefaultProcessConfig() ProcessConfig {
+	return ProcessConfig{
+		Workers:    1,
+		BatchSize:  1,
+		MaxRetries: 1,
+		Stderr:     os.Stderr,
+	}
+}
+
+type processRequest struct {
+	ID     int64   `json:"id"`
+	Genome *Genome `json:"genome"`
+}
+
+type processResponse struct {
+	ID      int64   `json:"id"`
+	Fitness float64 `json:"fitness"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// errProcessExited marks failures caused by the child going away, which are
+// retried on a fresh process.
+var errProcessExited = errors.New("evaluator process exited")
+
+// ProcessEvaluator evaluates genomes in a pool of child processes. Use
+// EvaluateBatch as a Runner's BatchFitness, or Fitness as a
+// ContextFitnessFunc for one genome at a time.
+type ProcessEvaluator struct {
+	cfg     ProcessConfig
+	workers []*processWorker
+	idle    chan *processWorker
+	nextID  atomic.Int64
+}
+
+// NewProcessEvaluator starts cfg.Workers child processes.
+func NewProcessEvaluator(cfg ProcessConfig) (*ProcessEvaluator, error) {
+	if cfg.Command == "" {
+		return nil, fmt.Errorf("command is empty")
+	}
+	if cfg.Workers <= 0 {
+		return nil, fmt.Errorf("workers must be > 0")
+	}
+	if cfg.BatchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be > 0")
+	}
+	if cfg.Timeout < 0 || cfg.MaxRetries < 0 {
+		return nil, fmt.Errorf("timeout and max retries must be >= 0")
+	}
+
+	e := &ProcessEvaluator{cfg: cfg, idle: make(chan *processWorker, cfg.Workers)}
+	for i := 0; i < cfg.Workers; i++ {
+		w := &processWorker{cfg: &e.cfg}
+		if err := w.start(); err != nil {
+			e.Close()
+			return nil, err
+		}
+		e.workers = append(e.workers, w)
+		e.idle <- w
+	}
+	return e, nil
+}
+
+// Close stops all child processes. It must not be called while an
+// evaluation is running.
+func (e *ProcessEvaluator) Close() error {
+	var first error
+	for _, w := range e.workers {
+		if err := w.stop(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Fitness evaluates a single genome.
+func (e *ProcessEvaluator) Fitness(ctx context.Context, g *Genome) (float64, error) {
+	out, err := e.EvaluateBatch(ctx, []Genome{*g})
+	if err != nil {
+		return 0, err
+	}
+	return out[0], nil
+}
+
+// EvaluateBatch splits genomes into batches spread across the worker pool
+// and returns their fitness values in order.
+func (e *ProcessEvaluator) EvaluateBatch(ctx context.Context, genomes []Genome) ([]float64, error) {
+	out := make([]float64, len(genomes))
+	jobs := make(chan [2]int, len(genomes)/e.cfg.BatchSize+1)
+	for start := 0; start < len(genomes); start += e.cfg.BatchSize {
+		end := start + e.cfg.BatchSize
+		if end > len(genomes) {
+			end = len(genomes)
+		}
+		jobs <- [2]int{start, end}
+	}
+	close(jobs)
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := 0; i < e.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				w := <-e.idle
+				err := e.runBatch(ctx, w, genomes[job[0]:job[1]], out[job[0]:job[1]])
+				e.idle <- w
+				if err != nil {
+					errOnce.Do(func() { firstErr = err })
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := parent.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// runBatch evaluates one batch on w, restarting the child after crashes and
+// timeouts.
+func (e *ProcessEvaluator) runBatch(ctx context.Context, w *processWorker, genomes []Genome, out []float64) error {
+	pending := make([]int, len(genomes))
+	for i := range pending {
+		pending[i] = i
+	}
+	retries := 0
+	for len(pending) > 0 {
+		if !w.running() {
+			if err := w.start(); err != nil {
+				return err
+			}
+		}
+		remaining, err := e.send(ctx, w, genomes, pending, out)
+		switch {
+		case err == nil:
+			pending = remaining
+		case errors.Is(err, errProcessExited) && retries < e.cfg.MaxRetries:
+			retries++
+			pending = remaining
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// send writes the pending genomes to w and collects responses. It returns
+// the genomes still unanswered when the child had to be restarted.
+func (e *ProcessEvaluator) send(ctx context.Context, w *processWorker, genomes []Genome, pending []int, out []float64) ([]int, error) {
+	ids := make(map[int64]int, len(pending))
+	for _, idx := range pending {
+		id := e.nextID.Add(1)
+		ids[id] = idx
+		if err := w.enc.Encode(processRequest{ID: id, Genome: &genomes[idx]}); err != nil {
+			w.kill()
+			return pending, fmt.Errorf("%w: %v", errProcessExited, err)
+		}
+	}
+	if err := w.stdin.Flush(); err != nil {
+		w.kill()
+		return pending, fmt.Errorf("%w: %v", errProcessExited, err)
+	}
+
+	var timer *time.Timer
+	var timeout <-chan time.Time
+	if e.cfg.Timeout > 0 {
+		timer = time.NewTimer(e.cfg.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for len(ids) > 0 {
+		select {
+		case reply, ok := <-w.replies:
+			if !ok {
+				err := w.wait()
+				return unanswered(ids), fmt.Errorf("%w: %v", errProcessExited, err)
+			}
+			if reply.err != nil {
+				w.kill()
+				return nil, reply.err
+			}
+			idx, known := ids[reply.resp.ID]
+			if !known {
+				continue
+			}
+			if reply.resp.Error != "" {
+				return nil, fmt.Errorf("evaluator failed on genome %d: %s", genomes[idx].ID, reply.resp.Error)
+			}
+			out[idx] = reply.resp.Fitness
+			delete(ids, reply.resp.ID)
+			if timer != nil {
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(e.cfg.Timeout)
+			}
+		case <-timeout:
+			w.kill()
+			rest := unanswered(ids)
+			out[rest[0]] = e.cfg.TimeoutFitness
+			return rest[1:], nil
+		case <-ctx.Done():
+			w.kill()
+			return nil, ctx.Err()
+		}
+	}
+	return nil, nil
+}
+
+// unanswered returns the batch indices still awaiting a response, oldest
+// request first.
+func unanswered(ids map[int64]int) []int {
+	keys := make([]int64, 0, len(ids))
+	for id := range ids {
+		keys = append(keys, id)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	out := make([]int, len(keys))
+	for i, id := range keys {
+		out[i] = ids[id]
+	}
+	return out
+}
+
+type processReply struct {
+	resp processResponse
+	err  error
+}
+
+// processWorker is one child process and its pipes.
+type processWorker struct {
+	cfg     *ProcessConfig
+	cmd     *exec.Cmd
+	stdin   *bufio.Writer
+	closer  io.Closer
+	enc     *json.Encoder
+	replies chan processReply
+}
+
+func (w *processWorker) running() bool {
+	return w.cmd != nil
+}
+
+func (w *processWorker) start() error {
+	cmd := exec.Command(w.cfg.Command, w.cfg.Args...)
+	cmd.Dir = w.cfg.Dir
+	cmd.Env = append(os.Environ(), w.cfg.Env...)
+	cmd.Stderr = w.cfg.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	// An *os.File stdout lets us read until EOF without racing cmd.Wait.
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stdout = pw
+	if err := cmd.Start(); err != nil {
+		pr.Close()
+		pw.Close()
+		return fmt.Errorf("start evaluator: %w", err)
+	}
+	pw.Close()
+
+	w.cmd = cmd
+	w.closer = stdin
+	w.stdin = bufio.NewWriter(stdin)
+	w.enc = json.NewEncoder(w.stdin)
+	w.replies = make(chan processReply, w.cfg.BatchSize)
+	go readReplies(pr, w.replies)
+	return nil
+}
+
+func readReplies(r io.ReadCloser, replies chan<- processReply) {
+	defer close(replies)
+	defer r.Close()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var resp processResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			replies <- processReply{err: fmt.Errorf("invalid evaluator response %q: %w", line, err)}
+			continue
+		}
+		replies <- processReply{resp: resp}
+	}
+}
+
+// wait reaps a child whose stdout has closed.
+func (w *processWorker) wait() error {
+	if w.cmd == nil {
+		return nil
+	}
+	w.cmd.Process.Kill()
+	err := w.cmd.Wait()
+	w.cmd = nil
+	if err == nil {
+		err = io.EOF
+	}
+	return err
+}
+
+func (w *processWorker) kill() {
+	if w.cmd == nil {
+		return
+	}
+	w.cmd.Process.Kill()
+	w.cmd.Wait()
+	w.cmd = nil
+	// Drain so the reader goroutine can finish.
+	go func(replies <-chan processReply) {
+		for range replies {
+		}
+	}(w.replies)
+}
+
+// stop closes stdin so the child can exit cleanly, killing it if it has not
+// exited shortly after.
+func (w *processWorker) stop() error {
+	if w.cmd == nil {
+		return nil
+	}
+	w.closer.Close()
+	done := make(chan error, 1)
+	cmd := w.cmd
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		w.cmd = nil
+		return err
+	case <-time.After(2 * time.Second):
+		cmd.Process.Kill()
+		<-done
+		w.cmd = nil
+		return fmt.Errorf("evaluator did not exit after stdin closed")
+	}
+}
@@ -0,0 +1,208 @@
+package neat
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestHelperProcess is not a real test: it is the child evaluator started by
+// the ProcessEvaluator tests.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("NEAT_HELPER_PROCESS") != "1" {
+		return
+	}
+	mode := os.Getenv("NEAT_HELPER_MODE")
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1<<20), 1<<20)
+	enc := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var req processRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			os.Exit(2)
+		}
+		resp := processResponse{ID: req.ID, Fitness: float64(req.Genome.ID) + float64(len(req.Genome.Connections))/10}
+		switch mode {
+		case "hang":
+			if req.Genome.ID == 3 {
+				time.Sleep(time.Hour)
+			}
+		case "crash":
+			marker := filepath.Join(os.Getenv("NEAT_HELPER_DIR"), "crashed")
+			if req.Genome.ID == 2 {
+				if _, err := os.Stat(marker); err != nil {
+					os.WriteFile(marker, nil, 0o644)
+					os.Exit(3)
+				}
+			}
+		case "error":
+			if req.Genome.ID == 2 {
+				resp.Error = "simulation diverged"
+			}
+		case "pid":
+			time.Sleep(20 * time.Millisecond)
+			resp.Fitness = float64(os.Getpid())
+		}
+		enc.Encode(resp)
+	}
+	os.Exit(0)
+}
+
+func helperProcessConfig(t *testing.T, mode string) ProcessConfig {
+	cfg := DefaultProcessConfig()
+	cfg.Command = os.Args[0]
+	cfg.Args = []string{"-test.run=^TestHelperProcess$"}
+	cfg.Env = []string{"NEAT_HELPER_PROCESS=1", "NEAT_HELPER_MODE=" + mode, "NEAT_HELPER_DIR=" + t.TempDir()}
+	cfg.Stderr = nil
+	return cfg
+}
+
+func helperGenomes(n int) []Genome {
+	genomes := make([]Genome, n)
+	for i := range genomes {
+		genomes[i] = Genome{
+			ID:          GenomeID(i + 1),
+			Nodes:       []NodeGene{{ID: 1, Kind: NodeInput}, {ID: 2, Kind: NodeOutput}},
+			Connections: []ConnectionGene{{Innovation: 1, In: 1, Out: 2, Weight: float64(i), Enabled: true}},
+		}
+	}
+	return genomes
+}
+
+func newHelperEvaluator(t *testing.T, cfg ProcessConfig) *ProcessEvaluator {
+	e, err := NewProcessEvaluator(cfg)
+	if err != nil {
+		t.Fatalf("NewProcessEvaluator error: %v", err)
+	}
+	t.Cleanup(func() { e.Close() })
+	return e
+}
+
+func TestProcessEvaluatorBatches(t *testing.T) {
+	cfg := helperProcessConfig(t, "")
+	cfg.Workers = 3
+	cfg.BatchSize = 4
+	e := newHelperEvaluator(t, cfg)
+
+	genomes := helperGenomes(11)
+	out, err := e.EvaluateBatch(context.Background(), genomes)
+	if err != nil {
+		t.Fatalf("EvaluateBatch error: %v", err)
+	}
+	for i, f := range out {
+		if want := float64(i+1) + 0.1; f != want {
+			t.Fatalf("genome %d: got %v want %v", i, f, want)
+		}
+	}
+
+	single, err := e.Fitness(context.Background(), &genomes[4])
+	if err != nil || single != 5.1 {
+		t.Fatalf("Fitness got %v, %v", single, err)
+	}
+}
+
+func TestProcessEvaluatorUsesAllWorkers(t *testing.T) {
+	cfg := helperProcessConfig(t, "pid")
+	cfg.Workers = 2
+	e := newHelperEvaluator(t, cfg)
+
+	out, err := e.EvaluateBatch(context.Background(), helperGenomes(6))
+	if err != nil {
+		t.Fatalf("EvaluateBatch error: %v", err)
+	}
+	pids := map[float64]bool{}
+	for _, f := range out {
+		pids[f] = true
+	}
+	if len(pids) != 2 {
+		t.Fatalf("expected 2 worker processes, saw %d", len(pids))
+	}
+}
+
+func TestProcessEvaluatorTimeout(t *testing.T) {
+	cfg := helperProcessConfig(t, "hang")
+	cfg.BatchSize = 5
+	cfg.Timeout = 200 * time.Millisecond
+	cfg.TimeoutFitness = -1
+	e := newHelperEvaluator(t, cfg)
+
+	out, err := e.EvaluateBatch(context.Background(), helperGenomes(5))
+	if err != nil {
+		t.Fatalf("EvaluateBatch error: %v", err)
+	}
+	for i, f := range out {
+		want := float64(i+1) + 0.1
+		if i == 2 {
+			want = -1
+		}
+		if f != want {
+			t.Fatalf("genome %d: got %v want %v", i, f, want)
+		}
+	}
+}
+
+func TestProcessEvaluatorRestartsAfterCrash(t *testing.T) {
+	cfg := helperProcessConfig(t, "crash")
+	cfg.BatchSize = 3
+	e := newHelperEvaluator(t, cfg)
+	out, err := e.EvaluateBatch(context.Background(), helperGenomes(4))
+	if err != nil {
+		t.Fatalf("EvaluateBatch error: %v", err)
+	}
+	if out[1] != 2.1 || out[3] != 4.1 {
+		t.Fatalf("unexpected fitness after restart: %v", out)
+	}
+
+	cfg = helperProcessConfig(t, "crash")
+	cfg.MaxRetries = 0
+	e = newHelperEvaluator(t, cfg)
+	if _, err := e.EvaluateBatch(context.Background(), helperGenomes(3)); !errors.Is(err, errProcessExited) {
+		t.Fatalf("expected exit error without retries, got %v", err)
+	}
+}
+
+func TestProcessEvaluatorErrors(t *testing.T) {
+	e := newHelperEvaluator(t, helperProcessConfig(t, "error"))
+	if _, err := e.EvaluateBatch(context.Background(), helperGenomes(3)); err == nil {
+		t.Fatalf("expected evaluator error")
+	}
+
+	e = newHelperEvaluator(t, helperProcessConfig(t, "hang"))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := e.EvaluateBatch(ctx, helperGenomes(4)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+
+	if _, err := NewProcessEvaluator(ProcessConfig{Workers: 1, BatchSize: 1}); err == nil {
+		t.Fatalf("expected error for empty command")
+	}
+	cfg := DefaultProcessConfig()
+	cfg.Command = filepath.Join(t.TempDir(), "missing")
+	if _, err := NewProcessEvaluator(cfg); err == nil {
+		t.Fatalf("expected error for missing command")
+	}
+}
+
+func TestRunnerWithProcessEvaluator(t *testing.T) {
+	e := newHelperEvaluator(t, helperProcessConfig(t, ""))
+	pop := contextTestPopulation(t, 8)
+	runner := Runner{
+		Population:   pop,
+		Mutation:     DefaultMutationConfig(),
+		Reproduction: DefaultReproductionConfig(),
+		BatchFitness: e.EvaluateBatch,
+	}
+	best, _, err := runner.Run(3, 1e9)
+	if err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	if best.Fitness < float64(best.ID) {
+		t.Fatalf("best fitness %v not from evaluator (id %d)", best.Fitness, best.ID)
+	}
+}
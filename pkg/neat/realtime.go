@@ -0,0 +1,286 @@
+package neat
+
+import "fmt"
+
+// RealtimeConfig controls steady-state (rtNEAT) replacement.
+type RealtimeConfig struct {
+	// Interval is the number of ticks between replacements.
+	Interval int
+	// MinAge is the number of ticks a genome must live before it may be
+	// removed, so it has time to be evaluated.
+	MinAge int
+	// TargetSpecies is the species count the compatibility threshold is
+	// steered toward after each replacement; zero keeps the threshold fixed.
+	TargetSpecies int
+	// ThresholdStep is how far the threshold moves per replacement.
+	ThresholdStep float64
+	// MinThreshold is the lowest the threshold may go.
+	MinThreshold float64
+}
+
+// DefaultRealtimeConfig returns a replacement every 20 ticks of genomes at
+// least 200 ticks old, steering toward eight species.
+func DefaultRealtimeConfig() RealtimeConfig {
+	return RealtimeConfig{
+		Interval:      20,
+		MinAge:        200,
+		TargetSpecies: 8,
+		ThresholdStep: 0.1,
+		MinThreshold:  0.3,
+	}
+}
+
+// Replacement describes one rtNEAT replacement: the genome at Index was
+// removed and Added now occupies its slot.
+type Replacement struct {
+	Index   int
+	Removed Genome
+	Added   Genome
+}
+
+// RealtimeRunner evolves a population one genome at a time while it is being
+// evaluated. The caller keeps each genome's Fitness current, typically from a
+// running simulation, and calls Tick once per simulation step. Every Interval
+// ticks the worst genome older than MinAge, by fitness shared within its
+// species, is replaced in place by an offspring of a species chosen in
+// proportion to its average fitness. Only the offspring is speciated; the
+// rest of the population keeps its species.
+//
+// There are no generations in rtNEAT. Population.Generation counts one
+// generation per population-size replacements and is used for Birth and
+// lineage records.
+type RealtimeRunner struct {
+	Population   *Population
+	Mutation     MutationConfig
+	Reproduction ReproductionConfig
+	Config       RealtimeConfig
+
+	ages          []int
+	ticks         int
+	replacements  int
+	nextSpeciesID int
+}
+
+// NewRealtimeRunner speciates the population if it has no species yet.
+// Every genome starts at age zero.
+func NewRealtimeRunner(pop *Population, mcfg MutationConfig, rcfg ReproductionConfig, cfg RealtimeConfig) (*RealtimeRunner, error) {
+	if pop == nil || len(pop.Genomes) < 2 {
+		return nil, fmt.Errorf("rtNEAT needs at least two genomes")
+	}
+	if pop.RNG == nil {
+		return nil, fmt.Errorf("rng is nil")
+	}
+	if cfg.Interval <= 0 {
+		return nil, fmt.Errorf("interval must be > 0")
+	}
+	if cfg.MinAge < 0 || cfg.TargetSpecies < 0 || cfg.ThresholdStep < 0 || cfg.MinThreshold < 0 {
+		return nil, fmt.Errorf("realtime config values must be >= 0")
+	}
+	if len(pop.Species) == 0 {
+		if err := pop.Speciate(); err != nil {
+			return nil, err
+		}
+	}
+	if pop.Lineage == nil {
+		pop.Lineage = NewLineageLog(nil)
+	}
+	r := &RealtimeRunner{
+		Population:   pop,
+		Mutation:     mcfg,
+		Reproduction: rcfg,
+		Config:       cfg,
+		ages:         make([]int, len(pop.Genomes)),
+	}
+	for _, s := range pop.Species {
+		if s.ID >= r.nextSpeciesID {
+			r.nextSpeciesID = s.ID + 1
+		}
+	}
+	return r, nil
+}
+
+// Tick ages every genome by one tick and, every Interval ticks, performs a
+// replacement. It reports whether a replacement happened.
+func (r *RealtimeRunner) Tick() (Replacement, bool, error) {
+	r.ticks++
+	for i := range r.ages {
+		r.ages[i]++
+	}
+	if r.ticks%r.Config.Interval != 0 {
+		return Replacement{}, false, nil
+	}
+	return r.Replace()
+}
+
+// Age returns how many ticks the genome at index i has lived.
+func (r *RealtimeRunner) Age(i int) int {
+	return r.ages[i]
+}
+
+// Ticks returns the number of ticks so far.
+func (r *RealtimeRunner) Ticks() int {
+	return r.ticks
+}
+
+// Replace removes the worst eligible genome and breeds its replacement now.
+// It reports false when no genome has reached MinAge.
+func (r *RealtimeRunner) Replace() (Replacement, bool, error) {
+	p := r.Population
+	worst := r.worstEligible()
+	if worst < 0 {
+		return Replacement{}, false, nil
+	}
+	removed := p.Genomes[worst]
+	r.removeMember(worst)
+
+	infos := buildSpeciesInfo(p.Genomes, p.Species)
+	parent := r.pickSpecies(infos)
+	survivors := survivorPool(infos[parent].sortedMembers, r.Reproduction.SurvivalThreshold)
+	child, err := p.makeOffspring(survivors, parent, r.Reproduction)
+	if err != nil {
+		return Replacement{}, false, err
+	}
+	mutations, err := r.Mutation.MutateLogged(p.RNG, &child, p.Tracker)
+	if err != nil {
+		return Replacement{}, false, err
+	}
+	child.ID = p.newGenomeID()
+	child.Birth = p.Generation
+	child.Fitness = 0
+	record := LineageRecord{
+		ID:         child.ID,
+		Parents:    child.Parents,
+		Generation: child.Birth,
+		Mutations:  mutations,
+	}
+	if err := p.Lineage.Append(record); err != nil {
+		return Replacement{}, false, err
+	}
+
+	p.Genomes[worst] = child
+	r.ages[worst] = 0
+	r.placeMember(worst)
+	r.adjustThreshold()
+
+	r.replacements++
+	if r.replacements%len(p.Genomes) == 0 {
+		p.Generation++
+	}
+	return Replacement{Index: worst, Removed: removed, Added: child}, true, nil
+}
+
+// worstEligible returns the index of the genome old enough to be removed with
+// the lowest fitness divided by its species size, or -1.
+func (r *RealtimeRunner) worstEligible() int {
+	p := r.Population
+	size := make(map[int]int, len(p.Genomes))
+	for _, s := range p.Species {
+		for _, m := range s.Members {
+			size[m] = len(s.Members)
+		}
+	}
+	worst, worstScore := -1, 0.0
+	for i, g := range p.Genomes {
+		if r.ages[i] < r.Config.MinAge {
+			continue
+		}
+		n := size[i]
+		if n < 1 {
+			n = 1
+		}
+		score := g.Fitness / float64(n)
+		if worst < 0 || score < worstScore {
+			worst, worstScore = i, score
+		}
+	}
+	return worst
+}
+
+// pickSpecies chooses a species in proportion to its average fitness.
+func (r *RealtimeRunner) pickSpecies(infos []speciesInfo) int {
+	total := 0.0
+	for _, info := range infos {
+		if info.adjustedSum > 0 {
+			total += info.adjustedSum
+		}
+	}
+	rng := r.Population.RNG
+	if total == 0 {
+		return rng.Intn(len(infos))
+	}
+	target := rng.Float64() * total
+	acc := 0.0
+	for i, info := range infos {
+		if info.adjustedSum > 0 {
+			acc += info.adjustedSum
+		}
+		if acc >= target {
+			return i
+		}
+	}
+	return len(infos) - 1
+}
+
+// removeMember drops genome idx from its species, choosing a new
+// representative or deleting the species as needed.
+func (r *RealtimeRunner) removeMember(idx int) {
+	p := r.Population
+	for s := range p.Species {
+		members := p.Species[s].Members
+		for k, m := range members {
+			if m != idx {
+				continue
+			}
+			p.Species[s].Members = append(members[:k], members[k+1:]...)
+			if len(p.Species[s].Members) == 0 {
+				p.Species = append(p.Species[:s], p.Species[s+1:]...)
+				return
+			}
+			if p.Species[s].Representative == idx {
+				p.Species[s].Representative = p.Species[s].Members[0]
+			}
+			return
+		}
+	}
+}
+
+// placeMember adds genome idx to the first compatible species, or founds a
+// new one.
+func (r *RealtimeRunner) placeMember(idx int) {
+	p := r.Population
+	for s := range p.Species {
+		rep := p.Genomes[p.Species[s].Representative]
+		if CompatibilityDistance(p.Genomes[idx], rep, p.Config.DistanceConfig) <= p.Config.CompatibilityThreshold {
+			p.Species[s].Members = append(p.Species[s].Members, idx)
+			return
+		}
+	}
+	p.Species = append(p.Species, Species{ID: r.newSpeciesID(), Representative: idx, Members: []int{idx}})
+}
+
+func (r *RealtimeRunner) newSpeciesID() int {
+	if r.nextSpeciesID < 1 {
+		r.nextSpeciesID = 1
+	}
+	id := r.nextSpeciesID
+	r.nextSpeciesID++
+	return id
+}
+
+// adjustThreshold nudges the compatibility threshold toward TargetSpecies.
+func (r *RealtimeRunner) adjustThreshold() {
+	cfg := r.Config
+	if cfg.TargetSpecies == 0 {
+		return
+	}
+	pc := &r.Population.Config
+	switch n := len(r.Population.Species); {
+	case n < cfg.TargetSpecies:
+		pc.CompatibilityThreshold -= cfg.ThresholdStep
+		if pc.CompatibilityThreshold < cfg.MinThreshold {
+			pc.CompatibilityThreshold = cfg.MinThreshold
+		}
+	case n > cfg.TargetSpecies:
+		pc.CompatibilityThreshold += cfg.ThresholdStep
+	}
+}
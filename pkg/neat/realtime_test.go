@@ -0,0 +1,174 @@
+package neat
+
+import "testing"
+
+func realtimeTestRunner(t *testing.T, size int, cfg RealtimeConfig) *RealtimeRunner {
+	t.Helper()
+	pop := contextTestPopulation(t, size)
+	r, err := NewRealtimeRunner(pop, DefaultMutationConfig(), DefaultReproductionConfig(), cfg)
+	if err != nil {
+		t.Fatalf("NewRealtimeRunner error: %v", err)
+	}
+	return r
+}
+
+func checkSpeciesCoverPopulation(t *testing.T, pop *Population) {
+	t.Helper()
+	seen := make(map[int]bool, len(pop.Genomes))
+	for _, s := range pop.Species {
+		if len(s.Members) == 0 {
+			t.Fatalf("species %d is empty", s.ID)
+		}
+		repFound := false
+		for _, m := range s.Members {
+			if seen[m] {
+				t.Fatalf("genome %d in two species", m)
+			}
+			seen[m] = true
+			repFound = repFound || m == s.Representative
+		}
+		if !repFound {
+			t.Fatalf("species %d representative %d is not a member", s.ID, s.Representative)
+		}
+	}
+	if len(seen) != len(pop.Genomes) {
+		t.Fatalf("species cover %d of %d genomes", len(seen), len(pop.Genomes))
+	}
+}
+
+func TestRealtimeTickReplacesOnInterval(t *testing.T) {
+	cfg := DefaultRealtimeConfig()
+	cfg.Interval = 5
+	cfg.MinAge = 0
+	r := realtimeTestRunner(t, 6, cfg)
+	for i := 1; i <= 12; i++ {
+		_, replaced, err := r.Tick()
+		if err != nil {
+			t.Fatalf("Tick error: %v", err)
+		}
+		if replaced != (i%5 == 0) {
+			t.Fatalf("tick %d: replaced=%v", i, replaced)
+		}
+	}
+	if r.Ticks() != 12 {
+		t.Fatalf("expected 12 ticks, got %d", r.Ticks())
+	}
+}
+
+func TestRealtimeReplaceRespectsMinAge(t *testing.T) {
+	cfg := DefaultRealtimeConfig()
+	cfg.Interval = 1
+	cfg.MinAge = 3
+	r := realtimeTestRunner(t, 4, cfg)
+	for i := 0; i < 2; i++ {
+		if _, replaced, err := r.Tick(); err != nil || replaced {
+			t.Fatalf("tick %d: replaced=%v err=%v before min age", i+1, replaced, err)
+		}
+	}
+	rep, replaced, err := r.Tick()
+	if err != nil || !replaced {
+		t.Fatalf("expected replacement at min age, replaced=%v err=%v", replaced, err)
+	}
+	if r.Age(rep.Index) != 0 {
+		t.Fatalf("expected new genome age 0, got %d", r.Age(rep.Index))
+	}
+	// The newborn is too young; the others are not.
+	for i := 0; i < 4; i++ {
+		if i != rep.Index && r.Age(i) != 3 {
+			t.Fatalf("genome %d age %d, expected 3", i, r.Age(i))
+		}
+	}
+}
+
+func TestRealtimeReplaceRemovesWorst(t *testing.T) {
+	cfg := DefaultRealtimeConfig()
+	cfg.MinAge = 0
+	cfg.TargetSpecies = 0
+	r := realtimeTestRunner(t, 6, cfg)
+	pop := r.Population
+	for i := range pop.Genomes {
+		pop.Genomes[i].Fitness = 10
+	}
+	pop.Genomes[4].Fitness = 0.5
+	worstID := pop.Genomes[4].ID
+
+	rep, replaced, err := r.Replace()
+	if err != nil || !replaced {
+		t.Fatalf("Replace: replaced=%v err=%v", replaced, err)
+	}
+	if rep.Index != 4 || rep.Removed.ID != worstID {
+		t.Fatalf("expected genome %d at 4 removed, got %d at %d", worstID, rep.Removed.ID, rep.Index)
+	}
+	if pop.Genomes[4].ID != rep.Added.ID || rep.Added.ID == worstID {
+		t.Fatalf("slot 4 not filled with the offspring")
+	}
+	for _, parent := range rep.Added.Parents {
+		if parent == worstID {
+			t.Fatalf("removed genome became a parent")
+		}
+	}
+	if _, ok := pop.Lineage.Get(rep.Added.ID); !ok {
+		t.Fatalf("offspring missing from lineage")
+	}
+	checkSpeciesCoverPopulation(t, pop)
+}
+
+func TestRealtimeSpeciesStayConsistent(t *testing.T) {
+	cfg := DefaultRealtimeConfig()
+	cfg.Interval = 1
+	cfg.MinAge = 2
+	cfg.TargetSpecies = 3
+	r := realtimeTestRunner(t, 10, cfg)
+	pop := r.Population
+	start := pop.Config.CompatibilityThreshold
+	for i := 0; i < 50; i++ {
+		for j := range pop.Genomes {
+			pop.Genomes[j].Fitness = float64(len(pop.Genomes[j].Connections)) + float64(j%3)
+		}
+		if _, _, err := r.Tick(); err != nil {
+			t.Fatalf("Tick error: %v", err)
+		}
+		checkSpeciesCoverPopulation(t, pop)
+	}
+	if len(pop.Genomes) != 10 {
+		t.Fatalf("population size changed to %d", len(pop.Genomes))
+	}
+	if pop.Config.CompatibilityThreshold == start {
+		t.Fatalf("expected the compatibility threshold to move")
+	}
+	if pop.Generation == 0 {
+		t.Fatalf("expected generation to advance after a population's worth of replacements")
+	}
+}
+
+func TestRealtimeAdjustThreshold(t *testing.T) {
+	cfg := DefaultRealtimeConfig()
+	cfg.TargetSpecies = 2
+	cfg.ThresholdStep = 0.5
+	cfg.MinThreshold = 1
+	r := realtimeTestRunner(t, 4, cfg)
+	pop := r.Population
+	pop.Config.CompatibilityThreshold = 1.2
+	pop.Species = pop.Species[:1]
+	r.adjustThreshold()
+	if pop.Config.CompatibilityThreshold != 1 {
+		t.Fatalf("expected threshold clamped to 1, got %v", pop.Config.CompatibilityThreshold)
+	}
+	pop.Species = []Species{{ID: 1}, {ID: 2}, {ID: 3}}
+	r.adjustThreshold()
+	if pop.Config.CompatibilityThreshold != 1.5 {
+		t.Fatalf("expected threshold 1.5, got %v", pop.Config.CompatibilityThreshold)
+	}
+}
+
+func TestNewRealtimeRunnerValidates(t *testing.T) {
+	pop := contextTestPopulation(t, 4)
+	cfg := DefaultRealtimeConfig()
+	cfg.Interval = 0
+	if _, err := NewRealtimeRunner(pop, DefaultMutationConfig(), DefaultReproductionConfig(), cfg); err == nil {
+		t.Fatalf("expected error for zero interval")
+	}
+	if _, err := NewRealtimeRunner(contextTestPopulation(t, 1), DefaultMutationConfig(), DefaultReproductionConfig(), DefaultRealtimeConfig()); err == nil {
+		t.Fatalf("expected error for a single genome")
+	}
+}
@@ -0,0 +1,136 @@
+package neat
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Reporter observes the Runner loop. A non-nil error stops the run.
+type Reporter interface {
+	// GenerationStart is called before the generation is evaluated.
+	GenerationStart(generation int) error
+	// PostEvaluate is called once every genome has a fitness. The genomes
+	// are not speciated yet, so SpeciesCount and SpeciesSizes are zero.
+	PostEvaluate(stats GenerationStats) error
+	// PostSpeciate is called after the evaluated genomes are speciated.
+	PostSpeciate(stats GenerationStats) error
+	// GenerationEnd is called once the generation is finished, after the
+	// next generation has been reproduced (if there is one).
+	GenerationEnd(stats GenerationStats) error
+}
+
+// BaseReporter implements Reporter with no-ops; embed it to override only
+// the hooks you need.
+type BaseReporter struct{}
+
+func (BaseReporter) GenerationStart(int) error           { return nil }
+func (BaseReporter) PostEvaluate(GenerationStats) error  { return nil }
+func (BaseReporter) PostSpeciate(GenerationStats) error  { return nil }
+func (BaseReporter) GenerationEnd(GenerationStats) error { return nil }
+
+// TextReporter prints one human-readable line per generation.
+type TextReporter struct {
+	BaseReporter
+	w io.Writer
+}
+
+// NewTextReporter writes progress lines to w.
+func NewTextReporter(w io.Writer) *TextReporter {
+	return &TextReporter{w: w}
+}
+
+// NewStdoutReporter writes progress lines to standard output.
+func NewStdoutReporter() *TextReporter {
+	return NewTextReporter(os.Stdout)
+}
+
+// GenerationEnd prints the generation summary.
+func (r *TextReporter) GenerationEnd(s GenerationStats) error {
+	_, err := fmt.Fprintf(r.w, "gen %d best=%.4f mean=%.4f std=%.4f species=%d nodes=%.1f conns=%.1f\n",
+		s.Generation, s.MaxFitness, s.MeanFitness, s.StdDevFitness, s.SpeciesCount, s.MeanNodes, s.MeanConnections)
+	return err
+}
+
+var csvHeader = []string{
+	"generation",
+	"population_size",
+	"min_fitness",
+	"mean_fitness",
+	"max_fitness",
+	"stddev_fitness",
+	"best_id",
+	"species_count",
+	"species_sizes",
+	"mean_nodes",
+	"mean_connections",
+}
+
+// CSVReporter writes one CSV row per generation, preceded by a header row.
+// Species sizes are joined with ';'.
+type CSVReporter struct {
+	BaseReporter
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+// NewCSVReporter writes CSV rows to w.
+func NewCSVReporter(w io.Writer) *CSVReporter {
+	return &CSVReporter{w: csv.NewWriter(w)}
+}
+
+// GenerationEnd writes the generation row.
+func (r *CSVReporter) GenerationEnd(s GenerationStats) error {
+	if !r.wroteHeader {
+		if err := r.w.Write(csvHeader); err != nil {
+			return err
+		}
+		r.wroteHeader = true
+	}
+	sizes := make([]string, len(s.SpeciesSizes))
+	for i, n := range s.SpeciesSizes {
+		sizes[i] = strconv.Itoa(n)
+	}
+	row := []string{
+		strconv.Itoa(s.Generation),
+		strconv.Itoa(s.PopulationSize),
+		formatFloat(s.MinFitness),
+		formatFloat(s.MeanFitness),
+		formatFloat(s.MaxFitness),
+		formatFloat(s.StdDevFitness),
+		strconv.FormatInt(int64(s.BestID), 10),
+		strconv.Itoa(s.SpeciesCount),
+		strings.Join(sizes, ";"),
+		formatFloat(s.MeanNodes),
+		formatFloat(s.MeanConnections),
+	}
+	if err := r.w.Write(row); err != nil {
+		return err
+	}
+	r.w.Flush()
+	return r.w.Error()
+}
+
+// JSONLReporter writes one JSON object per generation (JSON Lines).
+type JSONLReporter struct {
+	BaseReporter
+	enc *json.Encoder
+}
+
+// NewJSONLReporter writes JSON lines to w.
+func NewJSONLReporter(w io.Writer) *JSONLReporter {
+	return &JSONLReporter{enc: json.NewEncoder(w)}
+}
+
+// GenerationEnd writes the generation stats.
+func (r *JSONLReporter) GenerationEnd(s GenerationStats) error {
+	return r.enc.Encode(s)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,153 @@
+package neat
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+type recordingReporter struct {
+	events []string
+}
+
+func (r *recordingReporter) GenerationStart(gen int) error {
+	r.events = append(r.events, fmt.Sprintf("start %d", gen))
+	return nil
+}
+
+func (r *recordingReporter) PostEvaluate(s GenerationStats) error {
+	r.events = append(r.events, fmt.Sprintf("evaluate %d species=%d", s.Generation, s.SpeciesCount))
+	return nil
+}
+
+func (r *recordingReporter) PostSpeciate(s GenerationStats) error {
+	r.events = append(r.events, fmt.Sprintf("speciate %d species=%d", s.Generation, s.SpeciesCount))
+	return nil
+}
+
+func (r *recordingReporter) GenerationEnd(s GenerationStats) error {
+	r.events = append(r.events, fmt.Sprintf("end %d", s.Generation))
+	return nil
+}
+
+func reporterRunner(t *testing.T, reporters ...Reporter) *Runner {
+	t.Helper()
+	rng := NewRand(8)
+	tracker, err := NewInnovationTracker(nil)
+	if err != nil {
+		t.Fatalf("NewInnovationTracker error: %v", err)
+	}
+	genomes := make([]Genome, 0, 6)
+	for i := 0; i < 6; i++ {
+		g, err := NewMinimalGenome(2, 1, ActivationSigmoid, rng, tracker, 1.0)
+		if err != nil {
+			t.Fatalf("NewMinimalGenome error: %v", err)
+		}
+		genomes = append(genomes, g)
+	}
+	pop, err := NewPopulation(rng, DefaultPopulationConfig(), genomes)
+	if err != nil {
+		t.Fatalf("NewPopulation error: %v", err)
+	}
+	return &Runner{
+		Population:   pop,
+		Mutation:     DefaultMutationConfig(),
+		Reproduction: DefaultReproductionConfig(),
+		Fitness: func(g *Genome) (float64, error) {
+			return float64(len(g.Connections)), nil
+		},
+		Reporters: reporters,
+	}
+}
+
+func TestRunnerCallsReportersInOrder(t *testing.T) {
+	rec := &recordingReporter{}
+	runner := reporterRunner(t, rec)
+	if _, _, err := runner.Run(2, math.Inf(1)); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	want := []string{
+		"start 0", "evaluate 0 species=0", "speciate 0 species=1", "end 0",
+		"start 1", "evaluate 1 species=0", "speciate 1 species=1", "end 1",
+	}
+	if strings.Join(rec.events, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected events:\n%v\nwant:\n%v", rec.events, want)
+	}
+}
+
+func TestRunnerStopsOnReporterError(t *testing.T) {
+	runner := reporterRunner(t, failingReporter{})
+	if _, _, err := runner.Run(3, math.Inf(1)); err == nil {
+		t.Fatalf("expected reporter error")
+	}
+}
+
+type failingReporter struct {
+	BaseReporter
+}
+
+func (failingReporter) PostSpeciate(GenerationStats) error {
+	return fmt.Errorf("stop")
+}
+
+func TestBuiltinReporters(t *testing.T) {
+	text := &bytes.Buffer{}
+	csvBuf := &bytes.Buffer{}
+	jsonBuf := &bytes.Buffer{}
+	runner := reporterRunner(t, NewTextReporter(text), NewCSVReporter(csvBuf), NewJSONLReporter(jsonBuf))
+	if _, _, err := runner.Run(3, math.Inf(1)); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+
+	if lines := strings.Count(text.String(), "\n"); lines != 3 {
+		t.Fatalf("expected 3 text lines, got %d:\n%s", lines, text.String())
+	}
+
+	rows, err := csv.NewReader(csvBuf).ReadAll()
+	if err != nil {
+		t.Fatalf("csv read error: %v", err)
+	}
+	if len(rows) != 4 || rows[0][0] != "generation" || rows[3][0] != "2" {
+		t.Fatalf("unexpected csv rows: %v", rows)
+	}
+
+	dec := json.NewDecoder(jsonBuf)
+	count := 0
+	for dec.More() {
+		var s GenerationStats
+		if err := dec.Decode(&s); err != nil {
+			t.Fatalf("json decode error: %v", err)
+		}
+		if s.PopulationSize != 6 || len(s.SpeciesSizes) != s.SpeciesCount {
+			t.Fatalf("unexpected stats %+v", s)
+		}
+		count++
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 json lines, got %d", count)
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	pop := &Population{
+		Genomes: []Genome{
+			{ID: 1, Fitness: 1, Nodes: make([]NodeGene, 2), Connections: make([]ConnectionGene, 1)},
+			{ID: 2, Fitness: 3, Nodes: make([]NodeGene, 4), Connections: make([]ConnectionGene, 3)},
+		},
+		Species: []Species{{ID: 1, Members: []int{0, 1}}},
+	}
+	s := ComputeStats(pop)
+	if s.MinFitness != 1 || s.MaxFitness != 3 || s.MeanFitness != 2 || s.StdDevFitness != 1 {
+		t.Fatalf("unexpected fitness stats %+v", s)
+	}
+	if s.BestID != 2 || s.MeanNodes != 3 || s.MeanConnections != 2 {
+		t.Fatalf("unexpected structure stats %+v", s)
+	}
+	if s.SpeciesCount != 1 || s.SpeciesSizes[0] != 2 {
+		t.Fatalf("unexpected species stats %+v", s)
+	}
+}
@@ -8,10 +8,10 @@ import (
 
 // ReproductionConfig controls selection and mating behavior.
 type ReproductionConfig struct {
-	SurvivalThreshold     float64
-	Elitism               int
-	CrossoverProb         float64
-	InterspeciesMateProb  float64
+	SurvivalThreshold    float64 `json:"survival_threshold"`
+	Elitism              int     `json:"elitism"`
+	CrossoverProb        float64 `json:"crossover_prob"`
+	InterspeciesMateProb float64 `json:"interspecies_mate_prob"`
 }
 
 // DefaultReproductionConfig returns common NEAT defaults.
@@ -32,29 +32,67 @@ func (p *Population) NextGeneration(mcfg MutationConfig, rcfg ReproductionConfig
 	if err := p.Speciate(); err != nil {
 		return err
 	}
+	return p.Reproduce(mcfg, rcfg)
+}
+
+// Reproduce produces the next population from the current species
+// assignment without re-speciating. Call Speciate first.
+func (p *Population) Reproduce(mcfg MutationConfig, rcfg ReproductionConfig) error {
+	if p == nil {
+		return fmt.Errorf("population is nil")
+	}
+	return p.reproduceKeeping(mcfg, rcfg, nil)
+}
 
-	next, err := p.reproduce(mcfg, rcfg)
+// reproduceKeeping is Reproduce with kept copied unchanged into the end of
+// the next generation. Only the remaining slots are bred, so no child is
+// created or logged for the kept genomes' places.
+func (p *Population) reproduceKeeping(mcfg MutationConfig, rcfg ReproductionConfig, kept []Genome) error {
+	next, births, err := p.reproduce(mcfg, rcfg, len(kept))
 	if err != nil {
 		return err
 	}
+	for _, g := range kept {
+		g.Fitness = 0
+		next = append(next, g)
+	}
+	return p.commitGeneration(next, births)
+}
+
+// commitGeneration records the births of the next generation and then
+// installs it. If the lineage log rejects a birth, neither the log nor the
+// population changes.
+func (p *Population) commitGeneration(next []Genome, births []LineageRecord) error {
+	if p.Lineage == nil {
+		p.Lineage = NewLineageLog(nil)
+	}
+	if err := p.Lineage.appendAll(births); err != nil {
+		return err
+	}
 	p.Genomes = next
+	p.Generation++
 	return nil
 }
 
-func (p *Population) reproduce(mcfg MutationConfig, rcfg ReproductionConfig) ([]Genome, error) {
+// reproduce breeds the next generation minus reserved slots.
+func (p *Population) reproduce(mcfg MutationConfig, rcfg ReproductionConfig, reserved int) ([]Genome, []LineageRecord, error) {
 	if p.RNG == nil {
-		return nil, fmt.Errorf("rng is nil")
+		return nil, nil, fmt.Errorf("rng is nil")
 	}
 
-	popSize := len(p.Genomes)
-	if popSize == 0 {
-		return nil, fmt.Errorf("population has no genomes")
+	if len(p.Genomes) == 0 {
+		return nil, nil, fmt.Errorf("population has no genomes")
+	}
+	if reserved < 0 || reserved > len(p.Genomes) {
+		return nil, nil, fmt.Errorf("cannot reserve %d of %d slots", reserved, len(p.Genomes))
 	}
+	popSize := len(p.Genomes) - reserved
 
 	speciesInfos := buildSpeciesInfo(p.Genomes, p.Species)
 	offspringCounts := allocateOffspring(speciesInfos, popSize)
 
 	next := make([]Genome, 0, popSize)
+	births := make([]LineageRecord, 0, popSize)
 	for i, info := range speciesInfos {
 		count := offspringCounts[i]
 		if count <= 0 {
@@ -85,20 +123,29 @@ func (p *Population) reproduce(mcfg MutationConfig, rcfg ReproductionConfig) ([]
 		for k := 0; k < remaining; k++ {
 			child, err := p.makeOffspring(survivors, i, rcfg)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
-			if err := mcfg.Mutate(p.RNG, &child, p.Tracker); err != nil {
-				return nil, err
+			mutations, err := mcfg.MutateLogged(p.RNG, &child, p.Tracker)
+			if err != nil {
+				return nil, nil, err
 			}
+			child.ID = p.newGenomeID()
+			child.Birth = p.Generation + 1
 			child.Fitness = 0
 			next = append(next, child)
+			births = append(births, LineageRecord{
+				ID:         child.ID,
+				Parents:    child.Parents,
+				Generation: child.Birth,
+				Mutations:  mutations,
+			})
 		}
 	}
 
 	if len(next) != popSize {
-		return nil, fmt.Errorf("reproduction size mismatch: got %d want %d", len(next), popSize)
+		return nil, nil, fmt.Errorf("reproduction size mismatch: got %d want %d", len(next), popSize)
 	}
-	return next, nil
+	return next, births, nil
 }
 
 func (p *Population) makeOffspring(survivors []int, speciesIndex int, rcfg ReproductionConfig) (Genome, error) {
@@ -118,6 +165,7 @@ func (p *Population) makeOffspring(survivors []int, speciesIndex int, rcfg Repro
 
 	parent := p.selectParent(survivors)
 	child := cloneGenome(parent)
+	child.Parents = []GenomeID{parent.ID}
 	return child, nil
 }
 
@@ -0,0 +1,65 @@
+package neat
+
+import "math"
+
+// GenerationStats summarises the fitness and structure of one generation.
+type GenerationStats struct {
+	Generation      int      `json:"generation"`
+	PopulationSize  int      `json:"population_size"`
+	MinFitness      float64  `json:"min_fitness"`
+	MeanFitness     float64  `json:"mean_fitness"`
+	MaxFitness      float64  `json:"max_fitness"`
+	StdDevFitness   float64  `json:"stddev_fitness"`
+	BestID          GenomeID `json:"best_id,omitempty"`
+	SpeciesCount    int      `json:"species_count"`
+	SpeciesSizes    []int    `json:"species_sizes"`
+	MeanNodes       float64  `json:"mean_nodes"`
+	MeanConnections float64  `json:"mean_connections"`
+}
+
+// ComputeStats summarises the population's current genomes and species.
+// Species figures reflect the most recent call to Speciate.
+func ComputeStats(p *Population) GenerationStats {
+	stats := GenerationStats{}
+	if p == nil {
+		return stats
+	}
+	stats.Generation = p.Generation
+	stats.PopulationSize = len(p.Genomes)
+	stats.SpeciesCount = len(p.Species)
+	stats.SpeciesSizes = make([]int, len(p.Species))
+	for i, s := range p.Species {
+		stats.SpeciesSizes[i] = len(s.Members)
+	}
+	if len(p.Genomes) == 0 {
+		return stats
+	}
+
+	var sum, nodes, conns float64
+	stats.MinFitness = math.Inf(1)
+	stats.MaxFitness = math.Inf(-1)
+	for _, g := range p.Genomes {
+		sum += g.Fitness
+		nodes += float64(len(g.Nodes))
+		conns += float64(len(g.Connections))
+		if g.Fitness < stats.MinFitness {
+			stats.MinFitness = g.Fitness
+		}
+		if g.Fitness > stats.MaxFitness {
+			stats.MaxFitness = g.Fitness
+			stats.BestID = g.ID
+		}
+	}
+	count := float64(len(p.Genomes))
+	stats.MeanFitness = sum / count
+	stats.MeanNodes = nodes / count
+	stats.MeanConnections = conns / count
+
+	variance := 0.0
+	for _, g := range p.Genomes {
+		d := g.Fitness - stats.MeanFitness
+		variance += d * d
+	}
+	stats.StdDevFitness = math.Sqrt(variance / count)
+	return stats
+}
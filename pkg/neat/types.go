@@ -21,6 +21,9 @@ type NodeGene struct {
 	Kind       NodeKind       `json:"kind"`
 	Activation ActivationType `json:"activation"`
 	Bias       float64        `json:"bias"`
+	// Label optionally names what the node means to the host, such as the
+	// coordinate feature an input is fed.
+	Label string `json:"label,omitempty"`
 }
 
 // ConnectionGene represents a directed weighted edge between nodes.
@@ -32,8 +35,15 @@ type ConnectionGene struct {
 	Enabled    bool    `json:"enabled"`
 }
 
+// GenomeID uniquely identifies a genome within a population's history.
+// Zero means the genome has not been assigned an id.
+type GenomeID int64
+
 // Genome is a collection of node and connection genes.
 type Genome struct {
+	ID          GenomeID         `json:"id,omitempty"`
+	Parents     []GenomeID       `json:"parents,omitempty"`
+	Birth       int              `json:"birth,omitempty"`
 	Nodes       []NodeGene       `json:"nodes"`
 	Connections []ConnectionGene `json:"connections"`
 	Fitness     float64          `json:"fitness"`
@@ -3,13 +3,18 @@ const seedInput = document.getElementById("seed");
 const populationInput = document.getElementById("population");
 const generationsInput = document.getElementById("generations");
 const modeSelect = document.getElementById("mode");
+const colorModelSelect = document.getElementById("color-model");
 const renderButton = document.getElementById("render");
 const stopButton = document.getElementById("stop");
+const breedButton = document.getElementById("breed");
+const undoButton = document.getElementById("undo");
+const redoButton = document.getElementById("redo");
 const presetSelect = document.getElementById("preset");
 const galleryEl = document.getElementById("gallery");
 const modal = document.getElementById("modal");
 const modalBackdrop = document.getElementById("modal-backdrop");
 const modalClose = document.getElementById("modal-close");
+const viewResetButton = document.getElementById("view-reset");
 const detailCanvas = document.getElementById("detail-canvas");
 const detailFitness = document.getElementById("detail-fitness");
 const detailNodes = document.getElementById("detail-nodes");
@@ -17,6 +22,7 @@ const detailConnections = document.getElementById("detail-connections");
 const detailHidden = document.getElementById("detail-hidden");
 const detailOutputs = document.getElementById("detail-outputs");
 const detailSummary = document.getElementById("detail-summary");
+const detailNetwork = document.getElementById("detail-network");
 
 const weightInputs = {
   entropy: document.getElementById("w-entropy"),
@@ -94,15 +100,41 @@ const presets = {
 };
 
 let tiles = [];
+let selected = new Set();
+let running = false;
+let history = { canUndo: false, canRedo: false };
 
 const setStatus = (message) => {
   statusEl.textContent = message;
 };
 
-const setRunning = (running) => {
+const updateBreedButtons = () => {
+  breedButton.disabled = running || selected.size === 0;
+  breedButton.textContent =
+    selected.size > 0 ? `Breed ${selected.size} selected` : "Breed selected";
+  undoButton.disabled = running || !history.canUndo;
+  redoButton.disabled = running || !history.canRedo;
+};
+
+const clearSelection = () => {
+  selected = new Set();
+  galleryEl.querySelectorAll(".tile.selected").forEach((tile) => {
+    tile.classList.remove("selected");
+  });
+  updateBreedButtons();
+};
+
+const setRunning = (value) => {
+  running = value;
   renderButton.disabled = running || !wasmReady;
   stopButton.disabled = !running;
   renderButton.textContent = running ? "Generating…" : "Generate";
+  updateBreedButtons();
+};
+
+const setHistory = (canUndo, canRedo) => {
+  history = { canUndo, canRedo };
+  clearSelection();
 };
 
 const openModal = () => {
@@ -144,9 +176,11 @@ const readWeights = () => ({
 
 window.setStatus = setStatus;
 window.setRunning = setRunning;
+window.setHistory = setHistory;
 
 window.prepareGallery = (count, tileSize) => {
   tiles = [];
+  selected = new Set();
   galleryEl.innerHTML = "";
   galleryEl.style.setProperty("--tile-size", `${tileSize}px`);
   for (let i = 0; i < count; i += 1) {
@@ -157,7 +191,17 @@ window.prepareGallery = (count, tileSize) => {
     canvas.width = tileSize;
     canvas.height = tileSize;
     tile.appendChild(canvas);
-    tile.addEventListener("click", () => {
+    tile.addEventListener("click", (event) => {
+      if (event.shiftKey || event.ctrlKey || event.metaKey) {
+        if (selected.has(i)) {
+          selected.delete(i);
+        } else {
+          selected.add(i);
+        }
+        tile.classList.toggle("selected", selected.has(i));
+        updateBreedButtons();
+        return;
+      }
       if (typeof window.renderDetail === "function") {
         const detailSize = Math.max(512, tileSize * 2);
         window.renderDetail(i, detailSize);
@@ -190,7 +234,8 @@ window.updateDetail = (
   conns,
   hidden,
   outputs,
-  summary
+  summary,
+  network
 ) => {
   detailCanvas.width = width;
   detailCanvas.height = height;
@@ -203,6 +248,7 @@ window.updateDetail = (
   detailHidden.textContent = hidden;
   detailOutputs.textContent = outputs;
   detailSummary.textContent = summary;
+  detailNetwork.innerHTML = network || "";
 };
 
 Object.keys(weightInputs).forEach((key) => {
@@ -262,7 +308,8 @@ renderButton.addEventListener("click", () => {
     weights.symmetry,
     weights.color,
     weights.highfreq,
-    weights.novelty
+    weights.novelty,
+    colorModelSelect.value
   );
 });
 
@@ -272,9 +319,91 @@ stopButton.addEventListener("click", () => {
   }
 });
 
+breedButton.addEventListener("click", () => {
+  if (typeof window.breedSelected === "function" && selected.size > 0) {
+    window.breedSelected(Array.from(selected).sort((a, b) => a - b));
+  }
+});
+
+undoButton.addEventListener("click", () => {
+  if (typeof window.undoGeneration === "function") {
+    window.undoGeneration();
+  }
+});
+
+redoButton.addEventListener("click", () => {
+  if (typeof window.redoGeneration === "function") {
+    window.redoGeneration();
+  }
+});
+
 modalBackdrop.addEventListener("click", closeModal);
 modalClose.addEventListener("click", closeModal);
 
+// canvasPoint converts a pointer event to detail canvas pixels, which differ
+// from CSS pixels when the canvas is scaled to fit the modal.
+const canvasPoint = (event) => {
+  const rect = detailCanvas.getBoundingClientRect();
+  const scale = detailCanvas.width / rect.width;
+  return {
+    x: (event.clientX - rect.left) * scale,
+    y: (event.clientY - rect.top) * scale,
+  };
+};
+
+// Dragging previews with a CSS offset and re-renders once on release.
+let drag = null;
+
+detailCanvas.addEventListener("pointerdown", (event) => {
+  drag = { clientX: event.clientX, clientY: event.clientY };
+  detailCanvas.setPointerCapture(event.pointerId);
+});
+
+detailCanvas.addEventListener("pointermove", (event) => {
+  if (!drag) {
+    return;
+  }
+  const dx = event.clientX - drag.clientX;
+  const dy = event.clientY - drag.clientY;
+  detailCanvas.style.transform = `translate(${dx}px, ${dy}px)`;
+});
+
+detailCanvas.addEventListener("pointerup", (event) => {
+  if (!drag) {
+    return;
+  }
+  // The bounding rect still includes the preview offset, so the pan comes
+  // from the raw pointer delta rather than canvasPoint.
+  const scale = detailCanvas.width / detailCanvas.getBoundingClientRect().width;
+  const dx = (event.clientX - drag.clientX) * scale;
+  const dy = (event.clientY - drag.clientY) * scale;
+  drag = null;
+  detailCanvas.style.transform = "";
+  if ((dx !== 0 || dy !== 0) && typeof window.panDetail === "function") {
+    window.panDetail(dx, dy);
+  }
+});
+
+detailCanvas.addEventListener(
+  "wheel",
+  (event) => {
+    event.preventDefault();
+    if (typeof window.zoomDetail !== "function") {
+      return;
+    }
+    const point = canvasPoint(event);
+    const factor = event.deltaY < 0 ? 1.25 : 0.8;
+    window.zoomDetail(factor, point.x, point.y);
+  },
+  { passive: false }
+);
+
+viewResetButton.addEventListener("click", () => {
+  if (typeof window.resetDetailView === "function") {
+    window.resetDetailView();
+  }
+});
+
 setWeights(presets.balanced);
 
 loadWasm();